@@ -6,8 +6,8 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"time"
 
-	"github.com/cozodb/cozo-lib-go"
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/genkit"
 	"github.com/firebase/genkit/go/plugins/compat_oai/openai"
@@ -18,6 +18,7 @@ import (
 	ghscraper "mimi/internal/provider/github/scraper"
 	"mimi/internal/provider/logseq"
 	"mimi/internal/provider/logseq/db"
+	"mimi/internal/provider/logseq/rag"
 	tgscraper "mimi/internal/provider/telegram/scraper"
 )
 
@@ -26,6 +27,10 @@ const (
 	telegramBotTokenEnv = "TELEGRAM_BOT_API_TOKEN"
 	openrouterApiKeyEnv = "OPENROUTER_API_KEY"
 	openrouterApiUrlEnv = "OPENROUTER_API_URL"
+	// Optional, enables vector search over LogSeq pages
+	openaiApiKeyEnv = "OPENAI_API_KEY"
+	// Optional, enables live sync of the local graph changes
+	logseqWatchEnv = "LOGSEQ_WATCH"
 )
 
 func main() {
@@ -104,23 +109,35 @@ func main() {
 		log.Fatalf("failed to connect to postgres with: %s", err)
 	}
 
-	// Can be replaced to ("slite", "path/to/file", nil)
-	conn, err := cozo.New("mem", "", nil)
+	// Durable engine keeps the synced graph between restarts
+	conn, err := db.Connect()
 	if err != nil {
 		log.Fatalf("failed to connect to cozo with %s", err)
 	}
+	defer conn.Close()
 	q := db.New(conn)
-	err = q.CreateRelations()
-	if err != nil {
-		log.Fatalf("failed to create relations with %s", err)
+
+	var r *rag.RAG
+	if os.Getenv(openaiApiKeyEnv) != "" {
+		tmp := rag.New(ctx, q)
+		r = &tmp
+	} else {
+		slog.Warn("LogSeq vector search is disabled", "missing", openaiApiKeyEnv)
 	}
 
+	graph := logseq.NewIndexedGraph(logseq.NewRegexGraph(logseqPath))
+	syncer := logseq.NewSyncer(q, r)
+
 	// Setup LogSeq push event hook
 	hooks := []ghscraper.PushEventHook{
 		ghscraper.PushEventHook{
 			RepoOwner: "cyber-valley",
 			RepoName:  "cvland",
-			Hook:      logseq.NewSyncer(q),
+			Hook: func(ctx context.Context, path string) error {
+				// Reparse pulled pages once instead of on every query
+				graph.Refresh()
+				return syncer(ctx, path)
+			},
 		},
 	}
 
@@ -140,8 +157,19 @@ func main() {
 			slog.Info("GitHub scraper exited without an error")
 		}
 	}()
+	if os.Getenv(logseqWatchEnv) != "" {
+		go func() {
+			// Editors save pages in bursts, wait for them to settle
+			err := logseq.Watch(ctx, graph, 2*time.Second, logseq.NewWatchSyncer(q, r))
+			if err != nil {
+				log.Fatalf("LogSeq graph watcher exited with %s", err)
+			} else {
+				slog.Info("LogSeq graph watcher exited without an error")
+			}
+		}()
+	}
 	go func() {
-		err := bot.Start(ctx, tgBotToken, logseqPath, g, conn)
+		err := bot.Start(ctx, tgBotToken, graph, g, conn, r)
 		if err != nil {
 			log.Fatalf("Telegram bot exited with %s", err)
 		} else {
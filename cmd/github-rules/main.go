@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mimi/internal/persist"
+)
+
+const usage = `usage: %[1]s list
+       %[1]s set <position> <pattern> <tasks|supply|inventory|exclude>
+       %[1]s delete <position>
+
+Rules select GitHub project boards for summaries, the first rule matching
+the lower cased project title by its glob pattern wins`
+
+// Manages GitHub project rules of the summaries, e.g.
+// go run ./cmd/github-rules set 50 "garden*" tasks
+func main() {
+	ctx := context.Background()
+	if len(os.Args) < 2 {
+		log.Fatalf(usage, os.Args[0])
+	}
+
+	pool, err := pgxpool.New(ctx, os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatalf("failed to connect to postgres with: %s", err)
+	}
+	q := persist.New(pool)
+
+	switch args := os.Args[2:]; {
+	case os.Args[1] == "list" && len(args) == 0:
+		rules, err := q.FindGitHubProjectRules(ctx)
+		if err != nil {
+			log.Fatalf("failed to find rules with %s", err)
+		}
+		for _, rule := range rules {
+			role := rule.Role
+			if !rule.Include {
+				role = "exclude"
+			}
+			fmt.Printf("%d\t%s\t%s\n", rule.Position, rule.Pattern, role)
+		}
+	case os.Args[1] == "set" && len(args) == 3:
+		params := persist.SaveGitHubProjectRuleParams{
+			Position: parsePosition(args[0]),
+			Pattern:  args[1],
+			Include:  args[2] != "exclude",
+			Role:     args[2],
+		}
+		if !params.Include {
+			params.Role = "tasks"
+		}
+		if err := q.SaveGitHubProjectRule(ctx, params); err != nil {
+			log.Fatalf("failed to save rule with %s", err)
+		}
+	case os.Args[1] == "delete" && len(args) == 1:
+		if err := q.DeleteGitHubProjectRule(ctx, parsePosition(args[0])); err != nil {
+			log.Fatalf("failed to delete rule with %s", err)
+		}
+	default:
+		log.Fatalf(usage, os.Args[0])
+	}
+}
+
+func parsePosition(s string) int32 {
+	position, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		log.Fatalf("failed to parse position '%s' with %s", s, err)
+	}
+	return int32(position)
+}
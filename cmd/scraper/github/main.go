@@ -6,7 +6,6 @@ import (
 	"os"
 	"os/signal"
 
-	"github.com/cozodb/cozo-lib-go"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"mimi/internal/provider/github/scraper"
@@ -26,19 +25,16 @@ func main() {
 	var hooks []scraper.PushEventHook
 
 	// Setup LogSeq push event hook
-	conn, err := cozo.New("mem", "", nil)
+	conn, err := db.Connect()
 	if err != nil {
 		log.Fatalf("failed to connect to cozo with %s", err)
 	}
+	defer conn.Close()
 	q := db.New(conn)
-	err = q.CreateRelations()
-	if err != nil {
-		log.Fatalf("failed to create relations with %s", err)
-	}
 	hooks = append(hooks, scraper.PushEventHook{
 		RepoOwner: "cyber-valley",
 		RepoName:  "cvland",
-		Hook:      logseq.NewSyncer(q),
+		Hook:      logseq.NewSyncer(q, nil),
 	})
 
 	if err := scraper.Run(ctx, pool, hooks...); err != nil {
@@ -6,8 +6,6 @@ import (
 	"os"
 	"os/signal"
 
-	"github.com/cozodb/cozo-lib-go"
-
 	"mimi/internal/provider/logseq"
 	"mimi/internal/provider/logseq/db"
 )
@@ -18,21 +16,18 @@ func main() {
 	defer cancel()
 
 	// Connect to CozoDB
-	conn, err := cozo.New("mem", "", nil)
+	conn, err := db.Connect()
 	if err != nil {
 		log.Fatalf("failed to connect to cozo with %s", err)
 	}
+	defer conn.Close()
 	q := db.New(conn)
-	err = q.CreateRelations()
-	if err != nil {
-		log.Fatalf("failed to create relations with %s", err)
-	}
 
 	// Initialize
 	g := logseq.NewRegexGraph("/home/user/code/clone/cvland")
 
-	// Synchronize LogSeq contents
-	if err := logseq.Sync(ctx, g, q); err != nil {
+	// Rebuild LogSeq contents on demand, hooks sync only changed pages
+	if err := logseq.Sync(ctx, g, q, nil); err != nil {
 		log.Fatalf("failed to sync graph with %s", err)
 	}
 }
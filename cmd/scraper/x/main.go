@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mimi/internal/persist"
+	"mimi/internal/provider/x"
+)
+
+// Imports UserTweets responses saved from the browser, e.g.
+// go run ./cmd/scraper/x user-tweets/*.json
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if len(os.Args) < 2 {
+		log.Fatalf("usage: %s <UserTweets export>...", os.Args[0])
+	}
+
+	pool, err := pgxpool.New(ctx, os.Getenv("DATABASE_URL"))
+	if err != nil {
+		log.Fatalf("failed to connect to postgres with: %s", err)
+	}
+	q := persist.New(pool)
+
+	for _, path := range os.Args[1:] {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatalf("failed to open %s with %s", path, err)
+		}
+		tweets, err := x.ParseUserTweets(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("failed to parse %s with %s", path, err)
+		}
+		if err := x.Save(ctx, q, tweets); err != nil {
+			log.Fatalf("failed to import %s with %s", path, err)
+		}
+		slog.Info("imported tweets", "path", path, "length", len(tweets))
+	}
+}
@@ -6,12 +6,16 @@ require (
 	github.com/ai-shift/tgmd v0.1.6
 	github.com/cozodb/cozo-lib-go v0.7.5
 	github.com/firebase/genkit/go v0.6.0
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
+	github.com/gomarkdown/markdown v0.0.0-20250311123330-531bef5e742b
 	github.com/gotd/contrib v0.21.0
 	github.com/gotd/td v0.124.0
 	github.com/jackc/pgx/v5 v5.7.2
 	github.com/openai/openai-go v0.1.0-alpha.65
 	github.com/pgvector/pgvector-go v0.3.0
+	golang.org/x/image v0.24.0
 	golang.org/x/term v0.32.0
 	golang.org/x/time v0.11.0
 )
@@ -37,7 +41,6 @@ require (
 	github.com/go-logr/logr v1.4.2 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/goccy/go-yaml v1.17.1 // indirect
-	github.com/gomarkdown/markdown v0.0.0-20250311123330-531bef5e742b // indirect
 	github.com/google/dotprompt/go v0.0.0-20250611200215-bb73406b05ca // indirect
 	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/google/s2a-go v0.1.9 // indirect
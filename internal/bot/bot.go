@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,9 +18,10 @@ import (
 	"mimi/internal/bot/llm"
 	"mimi/internal/bot/llm/agent"
 	"mimi/internal/provider/logseq"
+	"mimi/internal/provider/logseq/rag"
 )
 
-func Start(ctx context.Context, token string, logseqPath string, g *genkit.Genkit, conn cozo.CozoDB) error {
+func Start(ctx context.Context, token string, graph *logseq.IndexedGraph, g *genkit.Genkit, conn cozo.CozoDB, r *rag.RAG) error {
 	slog.Info("starting Telegram Bot")
 	bot, err := tgbotapi.NewBotAPI(token)
 	if err != nil {
@@ -32,11 +34,10 @@ func Start(ctx context.Context, token string, logseqPath string, g *genkit.Genki
 		return fmt.Errorf("failed to connect to postgres with %w", err)
 	}
 
-	graph := logseq.NewRegexGraph(logseqPath)
 	handler := UpdateHandler{
 		bot: bot,
 		g:   graph,
-		llm: llm.New(pool, graph, g, conn),
+		llm: llm.New(pool, graph, g, conn, r),
 	}
 
 	u := tgbotapi.NewUpdate(0)
@@ -68,13 +69,22 @@ func Start(ctx context.Context, token string, logseqPath string, g *genkit.Genki
 
 type UpdateHandler struct {
 	bot *tgbotapi.BotAPI
-	g   logseq.RegexGraph
+	g   *logseq.IndexedGraph
 	llm llm.LLM
 }
 
 func (h UpdateHandler) handleMessage(ctx context.Context, m *tgbotapi.Message) error {
 	slog.Info("new message", "chatId", m.Chat.ID, "text", m.Text)
 
+	if m.From != nil {
+		ctx = agent.WithUser(ctx, agent.User{
+			ID:        m.From.ID,
+			FirstName: m.From.FirstName,
+			LastName:  m.From.LastName,
+			UserName:  m.From.UserName,
+		})
+	}
+
 	// Set bot typing status
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
@@ -92,7 +102,17 @@ func (h UpdateHandler) handleMessage(ctx context.Context, m *tgbotapi.Message) e
 	}()
 
 	// Generate LLM answer
-	result, err := h.llm.Answer(ctx, m.Chat.ID, m.Text)
+	var result agent.Response
+	var err error
+	agents := h.llm.Agents()
+	switch name, query := route(m, agents); {
+	case name != "":
+		result, err = h.llm.AnswerWith(ctx, m.Chat.ID, name, query)
+	case query != "":
+		result, err = h.llm.Answer(ctx, m.Chat.ID, query)
+	default:
+		result = agent.NewResponse(agent.DataText{Text: helpText(agents)}, nil)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get answer from LLM with %w", err)
 	}
@@ -105,35 +125,25 @@ func (h UpdateHandler) handleMessage(ctx context.Context, m *tgbotapi.Message) e
 			return fmt.Errorf("failed to send LLM response with %w", err)
 		}
 	case agent.DataFile:
-		slog.Info("got LLM file answer", "size", len(data.Blob))
-		// TODO: Send as file
-		tmpfile, err := os.CreateTemp("", fmt.Sprintf("*-%s", data.Name))
-		if err != nil {
-			return fmt.Errorf("failed to create temporary file with %w", err)
-		}
-		defer os.Remove(tmpfile.Name())
-
-		if _, err := tmpfile.Write(data.Blob); err != nil {
-			return fmt.Errorf("failed to write to temporary file with %w", err)
-		}
-		if err := tmpfile.Close(); err != nil {
-			return fmt.Errorf("failed to close temporary file with %w", err)
-		}
-
-		tmpfile, err = os.Open(tmpfile.Name())
-		if err != nil {
-			return fmt.Errorf("failed to open temporary file after write with %w", err)
-		}
-
-		f := tgbotapi.FileReader{
-			Name:   tmpfile.Name(),
-			Reader: tmpfile,
-		}
-		req := tgbotapi.NewDocument(m.Chat.ID, f)
-		_, err = h.bot.Send(req)
-		if err != nil {
+		slog.Info("got LLM file answer", "name", data.Name, "size", len(data.Blob))
+		req := tgbotapi.NewDocument(m.Chat.ID, tgbotapi.FileBytes{
+			Name:  data.Name,
+			Bytes: data.Blob,
+		})
+		req.Caption = truncateCaption(data.Caption)
+		if _, err := h.bot.Send(req); err != nil {
 			return fmt.Errorf("failed to send document with %w", err)
 		}
+	case agent.DataImage:
+		slog.Info("got LLM image answer", "size", len(data.Blob))
+		req := tgbotapi.NewPhoto(m.Chat.ID, tgbotapi.FileBytes{
+			Name:  "image.png",
+			Bytes: data.Blob,
+		})
+		req.Caption = truncateCaption(data.Caption)
+		if _, err := h.bot.Send(req); err != nil {
+			return fmt.Errorf("failed to send photo with %w", err)
+		}
 	default:
 		return fmt.Errorf("unexpected answer type '%#v'", data)
 	}
@@ -141,6 +151,30 @@ func (h UpdateHandler) handleMessage(ctx context.Context, m *tgbotapi.Message) e
 	return nil
 }
 
+// route picks the agent named by the command e.g. "/summary --format pdf last week",
+// empty name leaves the choice to the router and empty query asks for help
+func route(m *tgbotapi.Message, agents []string) (name, query string) {
+	if !m.IsCommand() {
+		return "", m.Text
+	}
+	// Telegram doesn't allow dashes in commands
+	name = strings.ReplaceAll(m.Command(), "_", "-")
+	if slices.Contains(agents, name) {
+		return name, m.CommandArguments()
+	}
+	// Unknown commands like /start or /help
+	return "", m.CommandArguments()
+}
+
+// helpText lists commands of the agents
+func helpText(agents []string) string {
+	commands := make([]string, len(agents))
+	for i, name := range agents {
+		commands[i] = "/" + strings.ReplaceAll(name, "-", "_")
+	}
+	return fmt.Sprintf("Ask a question in plain text or run an agent directly with one of the commands: %s", strings.Join(commands, ", "))
+}
+
 // sendLongMessage splits text into chunks and may send several messages
 // to prevent error of exceeding Telegram's limit
 func sendLongMessage(bot *tgbotapi.BotAPI, chatID int64, text string) error {
@@ -167,6 +201,16 @@ func sendLongMessage(bot *tgbotapi.BotAPI, chatID int64, text string) error {
 	return sendShortMessage(bot, chatID, strings.Join(buf, "\n"))
 }
 
+// truncateCaption fits text into Telegram's limit of the document and photo captions
+func truncateCaption(text string) string {
+	const limit = 1024
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
 func sendShortMessage(bot *tgbotapi.BotAPI, chatID int64, text string) error {
 	text = tgmd.Telegramify(text)
 
@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// command builds message as Telegram sends it with the command entity
+func command(text string) *tgbotapi.Message {
+	length := strings.IndexByte(text, ' ')
+	if length == -1 {
+		length = len(text)
+	}
+	return &tgbotapi.Message{
+		Text:     text,
+		Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: length}},
+	}
+}
+
+func TestRoute(t *testing.T) {
+	agents := []string{"logseq-query", "summary"}
+	cases := []struct {
+		m     *tgbotapi.Message
+		name  string
+		query string
+	}{
+		{command("/summary last week"), "summary", "last week"},
+		{command("/logseq_query (page-tags [[species]])"), "logseq-query", "(page-tags [[species]])"},
+		{&tgbotapi.Message{Text: "what's new?"}, "", "what's new?"},
+		// Unregistered commands are routed by their arguments or answered with help
+		{command("/summry last week"), "", "last week"},
+		{command("/start"), "", ""},
+		{command("/help"), "", ""},
+	}
+	for _, c := range cases {
+		name, query := route(c.m, agents)
+		if name != c.name || query != c.query {
+			t.Errorf("expected '%s' routed to '%s' with '%s', got '%s' with '%s'", c.m.Text, c.name, c.query, name, query)
+		}
+	}
+}
+
+func TestHelpText(t *testing.T) {
+	text := helpText([]string{"logseq-query", "summary"})
+	if !strings.Contains(text, "/logseq_query, /summary") {
+		t.Errorf("unexpected help text '%s'", text)
+	}
+}
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"strings"
 
 	"github.com/firebase/genkit/go/ai"
 )
@@ -13,6 +14,15 @@ type DataText struct {
 type DataFile struct {
 	Blob []byte
 	Name string
+	// Optional text sent along with the file
+	Caption string
+}
+
+// DataImage is a picture sent as a photo e.g. a rendered chart
+type DataImage struct {
+	Blob []byte
+	// Optional text sent along with the image
+	Caption string
 }
 
 type Response struct {
@@ -21,7 +31,7 @@ type Response struct {
 }
 
 type DataType interface {
-	DataText | DataFile
+	DataText | DataFile | DataImage
 }
 
 func NewResponse[T DataType](data T, raw *ai.ModelResponse) Response {
@@ -40,3 +50,33 @@ type Info struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 }
+
+// User is the chat user who sent the query
+type User struct {
+	ID        int64
+	FirstName string
+	LastName  string
+	UserName  string
+}
+
+// FullName joins user's names falling back to the username
+func (u User) FullName() string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name == "" {
+		return u.UserName
+	}
+	return name
+}
+
+type userKey struct{}
+
+// WithUser attaches query's author to the context
+func WithUser(ctx context.Context, u User) context.Context {
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+// UserFrom returns query's author if it's known
+func UserFrom(ctx context.Context) (User, bool) {
+	u, ok := ctx.Value(userKey{}).(User)
+	return u, ok
+}
@@ -0,0 +1,289 @@
+package chart
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"mimi/internal/bot/llm/agent/chart/plot"
+	"mimi/internal/persist"
+	"mimi/internal/provider/github/db"
+)
+
+type grouping string
+
+const (
+	groupChat     grouping = "chat"
+	groupStatus   grouping = "status"
+	groupAssignee grouping = "assignee"
+	groupDay      grouping = "day"
+	groupWeek     grouping = "week"
+	groupMonth    grouping = "month"
+)
+
+const (
+	// Smaller categories are merged into "Other" to keep the chart readable
+	maxCategories = 15
+	maxSeries     = 6
+	// Time groupings producing more buckets are replaced with the coarser ones
+	maxBuckets = 60
+	otherName  = "Other"
+)
+
+func (g grouping) isTime() bool {
+	return g == groupDay || g == groupWeek || g == groupMonth
+}
+
+// dataset is a chart's data without presentation details
+type dataset struct {
+	labels []string
+	series []plot.Series
+}
+
+// truncate returns the start of the bucket containing `t`, weeks start on Monday
+func truncate(t time.Time, g grouping) time.Time {
+	y, m, d := t.Date()
+	switch g {
+	case groupWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case groupMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+func next(t time.Time, g grouping) time.Time {
+	switch g {
+	case groupWeek:
+		return t.AddDate(0, 0, 7)
+	case groupMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// buckets lists starts of all buckets intersecting [since, until)
+func buckets(since, until time.Time, g grouping) (res []time.Time) {
+	for t := truncate(since, g); t.Before(until); t = next(t, g) {
+		res = append(res, t)
+	}
+	return res
+}
+
+// coarsen replaces time grouping with the coarser one until the buckets fit into the limit
+func coarsen(since, until time.Time, g grouping) grouping {
+	for _, coarser := range []grouping{groupWeek, groupMonth} {
+		if !g.isTime() || len(buckets(since, until, g)) <= maxBuckets {
+			break
+		}
+		g = coarser
+	}
+	return g
+}
+
+func bucketLabel(t time.Time, g grouping) string {
+	if g == groupMonth {
+		return t.Format("Jan 2006")
+	}
+	return t.Format("Jan 2")
+}
+
+// timeline distributes values of the named series over time buckets
+type timeline struct {
+	starts []time.Time
+	index  map[int64]int
+	values map[string][]float64
+	g      grouping
+	loc    *time.Location
+}
+
+func newTimeline(since, until time.Time, g grouping) *timeline {
+	tl := &timeline{
+		starts: buckets(since, until, g),
+		index:  make(map[int64]int),
+		values: make(map[string][]float64),
+		g:      g,
+		loc:    since.Location(),
+	}
+	for i, t := range tl.starts {
+		tl.index[t.Unix()] = i
+	}
+	return tl
+}
+
+// add puts value into the bucket containing `t`, values outside of the period are ignored
+func (tl *timeline) add(series string, t time.Time, value float64) {
+	i, ok := tl.index[truncate(t.In(tl.loc), tl.g).Unix()]
+	if !ok {
+		return
+	}
+	if _, ok := tl.values[series]; !ok {
+		tl.values[series] = make([]float64, len(tl.starts))
+	}
+	tl.values[series][i] += value
+}
+
+// dataset keeps the largest series merging the rest into "Other"
+func (tl *timeline) dataset() dataset {
+	var ds dataset
+	for _, t := range tl.starts {
+		ds.labels = append(ds.labels, bucketLabel(t, tl.g))
+	}
+	totals := make(map[string]float64, len(tl.values))
+	for name, values := range tl.values {
+		for _, v := range values {
+			totals[name] += v
+		}
+	}
+	for i, name := range byTotal(totals) {
+		if i < maxSeries {
+			ds.series = append(ds.series, plot.Series{Name: name, Values: tl.values[name]})
+			continue
+		}
+		if i == maxSeries {
+			ds.series = append(ds.series, plot.Series{Name: otherName, Values: make([]float64, len(tl.starts))})
+		}
+		other := ds.series[maxSeries].Values
+		for j, v := range tl.values[name] {
+			other[j] += v
+		}
+	}
+	return ds
+}
+
+// categories builds single series dataset from totals ordered by value
+func categories(name string, totals map[string]float64) dataset {
+	ds := dataset{series: []plot.Series{{Name: name}}}
+	var other float64
+	for i, category := range byTotal(totals) {
+		if i >= maxCategories {
+			other += totals[category]
+			continue
+		}
+		ds.labels = append(ds.labels, category)
+		ds.series[0].Values = append(ds.series[0].Values, totals[category])
+	}
+	if other > 0 {
+		ds.labels = append(ds.labels, otherName)
+		ds.series[0].Values = append(ds.series[0].Values, other)
+	}
+	return ds
+}
+
+func byTotal(totals map[string]float64) []string {
+	names := make([]string, 0, len(totals))
+	for name := range totals {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(lhs, rhs string) int {
+		return cmp.Or(cmp.Compare(totals[rhs], totals[lhs]), cmp.Compare(lhs, rhs))
+	})
+	return names
+}
+
+// messagesDataset aggregates message counts per chat or over time with a series per chat
+func messagesDataset(rows []persist.CountTelegramMessagesRow, since, until time.Time, g grouping) (dataset, error) {
+	switch {
+	case g == groupChat:
+		totals := make(map[string]float64)
+		for _, row := range rows {
+			totals[row.ChatName] += float64(row.Messages)
+		}
+		return categories("Messages", totals), nil
+	case g.isTime():
+		tl := newTimeline(since, until, g)
+		for _, row := range rows {
+			tl.add(row.ChatName, row.Period.Time, float64(row.Messages))
+		}
+		return tl.dataset(), nil
+	default:
+		return dataset{}, fmt.Errorf("messages can't be grouped by %s", g)
+	}
+}
+
+type issueMetric string
+
+const (
+	metricCreated issueMetric = "created"
+	metricAdded   issueMetric = "added"
+	metricClosed  issueMetric = "closed"
+)
+
+var issueMetrics = []issueMetric{metricCreated, metricAdded, metricClosed}
+
+// parseIssueMetric validates the planned metric, issues creation is counted by default
+func parseIssueMetric(s string) (issueMetric, error) {
+	if s == "" {
+		return metricCreated, nil
+	}
+	m := issueMetric(strings.ToLower(strings.TrimSpace(s)))
+	if !slices.Contains(issueMetrics, m) {
+		return m, fmt.Errorf("unknown project items metric '%s', expected one of %v", s, issueMetrics)
+	}
+	return m, nil
+}
+
+// issueTime returns when the metric's event happened to the issue
+func issueTime(issue db.Issue, m issueMetric) (time.Time, bool) {
+	switch m {
+	case metricClosed:
+		if issue.ClosedAt == nil {
+			return time.Time{}, false
+		}
+		return *issue.ClosedAt, true
+	case metricAdded:
+		return issue.AddedAt, !issue.AddedAt.IsZero()
+	default:
+		return issue.CreatedAt, !issue.CreatedAt.IsZero()
+	}
+}
+
+// issuesDataset counts project items created, added to the project or closed within the period
+func issuesDataset(issues []db.Issue, m issueMetric, since, until time.Time, g grouping) (dataset, error) {
+	name := fmt.Sprintf("Items %s", m)
+	var matched []db.Issue
+	var times []time.Time
+	for _, issue := range issues {
+		t, ok := issueTime(issue, m)
+		if !ok || t.Before(since) || !t.Before(until) {
+			continue
+		}
+		matched = append(matched, issue)
+		times = append(times, t)
+	}
+
+	switch {
+	case g.isTime():
+		tl := newTimeline(since, until, g)
+		// Keep empty series when nothing happened
+		tl.values[name] = make([]float64, len(tl.starts))
+		for _, t := range times {
+			tl.add(name, t, 1)
+		}
+		return tl.dataset(), nil
+	case g == groupStatus:
+		totals := make(map[string]float64)
+		for _, issue := range matched {
+			totals[cmp.Or(issue.Status, "No status")]++
+		}
+		return categories(name, totals), nil
+	case g == groupAssignee:
+		totals := make(map[string]float64)
+		for _, issue := range matched {
+			if len(issue.Assignees) == 0 {
+				totals["Unassigned"]++
+			}
+			for _, login := range issue.Assignees {
+				totals[login]++
+			}
+		}
+		return categories(name, totals), nil
+	default:
+		return dataset{}, fmt.Errorf("project items can't be grouped by %s", g)
+	}
+}
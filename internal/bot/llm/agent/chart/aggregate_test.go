@@ -0,0 +1,160 @@
+package chart
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"mimi/internal/persist"
+	"mimi/internal/provider/github/db"
+)
+
+var loc = time.FixedZone("WITA", 8*60*60)
+
+func date(day, hour int) time.Time {
+	return time.Date(2025, 9, day, hour, 0, 0, 0, loc)
+}
+
+func TestBuckets(t *testing.T) {
+	// Thursday to Thursday
+	since, until := date(4, 15), date(18, 15)
+	weeks := buckets(since, until, groupWeek)
+	expected := []time.Time{date(1, 0), date(8, 0), date(15, 0)}
+	if !slices.EqualFunc(weeks, expected, time.Time.Equal) {
+		t.Fatalf("expected %v, got %v", expected, weeks)
+	}
+	if got := len(buckets(since, until, groupDay)); got != 15 {
+		t.Fatalf("expected 15 days, got %d", got)
+	}
+	if got := coarsen(date(1, 0), date(1, 0).AddDate(0, 3, 0), groupDay); got != groupWeek {
+		t.Fatalf("expected week grouping, got %s", got)
+	}
+	if got := coarsen(date(1, 0), date(1, 0).AddDate(3, 0, 0), groupDay); got != groupMonth {
+		t.Fatalf("expected month grouping, got %s", got)
+	}
+}
+
+func TestMessagesDataset(t *testing.T) {
+	ts := func(t time.Time) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: t.UTC(), Valid: true}
+	}
+	rows := []persist.CountTelegramMessagesRow{
+		{ChatName: "devops", Period: ts(date(8, 0)), Messages: 3},
+		{ChatName: "garden", Period: ts(date(8, 0)), Messages: 5},
+		{ChatName: "devops", Period: ts(date(15, 0)), Messages: 4},
+	}
+	since, until := date(4, 15), date(18, 15)
+
+	ds, err := messagesDataset(rows, since, until, groupWeek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ds.labels, []string{"Sep 1", "Sep 8", "Sep 15"}) {
+		t.Fatalf("unexpected labels %v", ds.labels)
+	}
+	if len(ds.series) != 2 || ds.series[0].Name != "devops" || !slices.Equal(ds.series[0].Values, []float64{0, 3, 4}) {
+		t.Fatalf("unexpected series %+v", ds.series)
+	}
+
+	ds, err = messagesDataset(rows, since, until, groupChat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ds.labels, []string{"devops", "garden"}) || !slices.Equal(ds.series[0].Values, []float64{7, 5}) {
+		t.Fatalf("unexpected dataset %+v", ds)
+	}
+
+	if _, err := messagesDataset(rows, since, until, groupStatus); err == nil {
+		t.Fatal("expected error for status grouping")
+	}
+}
+
+func TestIssuesDataset(t *testing.T) {
+	closed := func(day int) *time.Time {
+		t := date(day, 12)
+		return &t
+	}
+	issues := []db.Issue{
+		{Status: "Done", Assignees: []string{"alice"}, CreatedAt: date(1, 0), ClosedAt: closed(9)},
+		{Status: "Done", Assignees: []string{"alice", "bob"}, CreatedAt: date(2, 0), ClosedAt: closed(16)},
+		{Status: "Todo", CreatedAt: date(1, 0), AddedAt: date(10, 0)},
+		// Closed before the period
+		{Status: "Done", CreatedAt: date(1, 0), ClosedAt: closed(2)},
+	}
+	since, until := date(4, 15), date(18, 15)
+
+	ds, err := issuesDataset(issues, metricClosed, since, until, groupWeek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds.series) != 1 || !slices.Equal(ds.series[0].Values, []float64{0, 1, 1}) {
+		t.Fatalf("unexpected series %+v", ds.series)
+	}
+
+	ds, err = issuesDataset(issues, metricClosed, since, until, groupAssignee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ds.labels, []string{"alice", "bob"}) || !slices.Equal(ds.series[0].Values, []float64{2, 1}) {
+		t.Fatalf("unexpected dataset %+v", ds)
+	}
+
+	ds, err = issuesDataset(issues, metricAdded, since, until, groupStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ds.labels, []string{"Todo"}) {
+		t.Fatalf("unexpected labels %v", ds.labels)
+	}
+
+	// Issues created before the period don't count even if added to the project within it
+	ds, err = issuesDataset(issues, metricCreated, since, until, groupStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds.labels) != 0 {
+		t.Fatalf("unexpected labels %v", ds.labels)
+	}
+}
+
+func TestParseIssueMetric(t *testing.T) {
+	for s, expected := range map[string]issueMetric{"": metricCreated, "Closed": metricClosed, "added": metricAdded} {
+		m, err := parseIssueMetric(s)
+		if err != nil || m != expected {
+			t.Errorf("expected %s, got %s, %v for '%s'", expected, m, err, s)
+		}
+	}
+	if _, err := parseIssueMetric("merged"); err == nil || !strings.Contains(err.Error(), "created added closed") {
+		t.Errorf("expected error listing valid metrics, got %v", err)
+	}
+}
+
+func TestCategories(t *testing.T) {
+	totals := make(map[string]float64)
+	for i := range maxCategories + 3 {
+		totals[string(rune('a'+i))] = float64(100 - i)
+	}
+	ds := categories("Messages", totals)
+	if len(ds.labels) != maxCategories+1 || ds.labels[maxCategories] != otherName {
+		t.Fatalf("unexpected labels %v", ds.labels)
+	}
+	if got := ds.series[0].Values[maxCategories]; got != 85+84+83 {
+		t.Fatalf("unexpected other total %v", got)
+	}
+}
+
+func TestFindProject(t *testing.T) {
+	projects := []db.ProjectInfo{{Id: 1, Title: "DevOps Force Backlog"}, {Id: 2, Title: "DevOps Force"}}
+	if p, ok := findProject(projects, "devops force"); !ok || p.Id != 2 {
+		t.Fatalf("expected exact match, got %+v", p)
+	}
+	if p, ok := findProject(projects, "backlog"); !ok || p.Id != 1 {
+		t.Fatalf("expected substring match, got %+v", p)
+	}
+	if _, ok := findProject(projects, ""); ok {
+		t.Fatal("expected no match for empty title")
+	}
+}
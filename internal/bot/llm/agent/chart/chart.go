@@ -0,0 +1,220 @@
+package chart
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mimi/internal/bot/llm/agent"
+	"mimi/internal/bot/llm/agent/chart/plot"
+	"mimi/internal/persist"
+	"mimi/internal/provider/github/db"
+)
+
+const (
+	planPrompt = "chart-plan"
+	dateLayout = "02.01.2006"
+)
+
+type ChartAgent struct {
+	planPrompt *ai.Prompt
+	q          *persist.Queries
+	ghClient   *db.Client
+	ghOrg      string
+	loc        *time.Location
+}
+
+// New creates the agent, `loc` is used to resolve dates and buckets
+func New(g *genkit.Genkit, pgPool *pgxpool.Pool, ghOrg string, loc *time.Location) ChartAgent {
+	// Fail fast if prompt wasn't found
+	plan := genkit.LookupPrompt(g, planPrompt)
+	if plan == nil {
+		log.Fatalf("no prompt named '%s' found", planPrompt)
+	}
+
+	return ChartAgent{
+		planPrompt: plan,
+		q:          persist.New(pgPool),
+		ghClient:   db.New("https://api.github.com/graphql"),
+		ghOrg:      ghOrg,
+		loc:        loc,
+	}
+}
+
+func (a ChartAgent) GetInfo() agent.Info {
+	return agent.Info{
+		Name: "chart",
+		Description: `Draws bar or line charts with statistics over Telegram messages
+		(e.g. "message volume per chat over the last month") and GitHub project boards
+		(e.g. "issues closed per week in devops force"). Use it when the user asks for a chart, graph, plot or dynamics`,
+	}
+}
+
+func (a ChartAgent) Run(ctx context.Context, query string, msgs ...*ai.Message) (agent.Response, error) {
+	var result agent.Response
+	now := time.Now().In(a.loc)
+	resp, err := a.planPrompt.Execute(
+		ctx,
+		ai.WithMessages(msgs...),
+		ai.WithInput(map[string]any{
+			"query":    query,
+			"now":      now.Format(time.RFC3339),
+			"timezone": a.loc.String(),
+		}),
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to plan chart with %w", err)
+	}
+	var p plan
+	if err := resp.Output(&p); err != nil {
+		return result, fmt.Errorf("failed to parse chart plan '%s' with %w", resp.Text(), err)
+	}
+	since, until, err := p.period(now)
+	if err != nil {
+		return result, err
+	}
+	g := coarsen(since, until, grouping(p.GroupBy))
+	slog.Info("planned chart", "source", p.Source, "groupBy", g, "metric", p.Metric, "project", p.Project, "since", since, "until", until)
+
+	var ds dataset
+	switch p.Source {
+	case "telegram":
+		// Buckets are summed up when grouped by chat
+		bucket := groupDay
+		if g.isTime() {
+			bucket = g
+		}
+		rows, err := a.q.CountTelegramMessages(ctx, persist.CountTelegramMessagesParams{
+			Bucket:   string(bucket),
+			Timezone: a.loc.String(),
+			Since:    pgtype.Timestamptz{Time: since, Valid: true},
+			Until:    pgtype.Timestamptz{Time: until, Valid: true},
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to count Telegram messages with %w", err)
+		}
+		ds, err = messagesDataset(rows, since, until, g)
+		if err != nil {
+			return result, err
+		}
+	case "github":
+		m, err := parseIssueMetric(p.Metric)
+		if err != nil {
+			return result, err
+		}
+		issues, err := a.projectIssues(ctx, p.Project, since)
+		if err != nil {
+			return result, err
+		}
+		ds, err = issuesDataset(issues, m, since, until, g)
+		if err != nil {
+			return result, err
+		}
+	default:
+		return result, fmt.Errorf("unknown chart source '%s'", p.Source)
+	}
+
+	kind := plot.Kind(p.Kind)
+	if kind != plot.KindLine || !g.isTime() {
+		// Lines between categories don't make sense
+		kind = plot.KindBar
+	}
+	blob, err := plot.Render(plot.Chart{
+		Title:  p.Title,
+		Kind:   kind,
+		Labels: ds.labels,
+		Series: ds.series,
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to render chart with %w", err)
+	}
+
+	caption := fmt.Sprintf(
+		"%s\n%s – %s (%s)",
+		p.Title,
+		since.Format(dateLayout),
+		until.Add(-time.Nanosecond).Format(dateLayout),
+		a.loc,
+	)
+	result = agent.NewResponse(agent.DataImage{Blob: blob, Caption: caption}, resp)
+	return result, nil
+}
+
+// projectIssues finds the organization's project by its title and loads items updated within the period
+func (a ChartAgent) projectIssues(ctx context.Context, title string, since time.Time) ([]db.Issue, error) {
+	projects, err := a.ghClient.ListProjects(ctx, a.ghOrg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list GitHub projects with %w", err)
+	}
+	project, ok := findProject(projects, title)
+	if !ok {
+		return nil, fmt.Errorf("GitHub project '%s' not found", title)
+	}
+	// Items closed within the period were updated after its start as well
+	issues, err := a.ghClient.GetOrgProject(ctx, a.ghOrg, project.Id, since, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load GitHub project '%s' with %w", project.Title, err)
+	}
+	return issues, nil
+}
+
+// findProject prefers the exact title match falling back to the substring one
+func findProject(projects []db.ProjectInfo, title string) (db.ProjectInfo, bool) {
+	title = strings.ToLower(strings.TrimSpace(title))
+	if title == "" {
+		return db.ProjectInfo{}, false
+	}
+	for _, p := range projects {
+		if strings.ToLower(p.Title) == title {
+			return p, true
+		}
+	}
+	for _, p := range projects {
+		if strings.Contains(strings.ToLower(p.Title), title) {
+			return p, true
+		}
+	}
+	return db.ProjectInfo{}, false
+}
+
+// plan is a structured output of the chart planning prompt
+type plan struct {
+	Source  string `json:"source"`
+	GroupBy string `json:"groupBy"`
+	Metric  string `json:"metric"`
+	Project string `json:"project"`
+	Kind    string `json:"kind"`
+	Title   string `json:"title"`
+	Since   string `json:"since"`
+	Until   string `json:"until"`
+}
+
+// period parses planned timestamps ensuring the period doesn't end in the future
+func (p plan) period(now time.Time) (since, until time.Time, err error) {
+	since, err = time.ParseInLocation(time.RFC3339, p.Since, now.Location())
+	if err != nil {
+		return since, until, fmt.Errorf("failed to parse period start with %w", err)
+	}
+	until = now
+	if p.Until != "" {
+		until, err = time.ParseInLocation(time.RFC3339, p.Until, now.Location())
+		if err != nil {
+			return since, until, fmt.Errorf("failed to parse period end with %w", err)
+		}
+	}
+	if until.After(now) {
+		until = now
+	}
+	if !since.Before(until) {
+		return since, until, fmt.Errorf("period start %s should be before its end %s", since, until)
+	}
+	return since.In(now.Location()), until.In(now.Location()), nil
+}
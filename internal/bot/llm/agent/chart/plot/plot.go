@@ -0,0 +1,315 @@
+// Package plot renders simple bar and line charts into PNG images
+package plot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+type Kind string
+
+const (
+	KindBar  Kind = "bar"
+	KindLine Kind = "line"
+)
+
+const (
+	width  = 1000
+	height = 600
+	// Space around the plot area excluding axis labels
+	margin = 20
+	// Desired number of horizontal grid lines
+	tickCount = 5
+	// Longer category labels are cut with ellipsis
+	maxLabelWidth = 140
+)
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	foreground = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	grid       = color.RGBA{0xe5, 0xe5, 0xe5, 0xff}
+	palette    = []color.RGBA{
+		{0x1f, 0x77, 0xb4, 0xff},
+		{0xff, 0x7f, 0x0e, 0xff},
+		{0x2c, 0xa0, 0x2c, 0xff},
+		{0xd6, 0x27, 0x28, 0xff},
+		{0x94, 0x67, 0xbd, 0xff},
+		{0x8c, 0x56, 0x4b, 0xff},
+		{0xe3, 0x77, 0xc2, 0xff},
+		{0x7f, 0x7f, 0x7f, 0xff},
+	}
+)
+
+type Series struct {
+	Name   string
+	Values []float64
+}
+
+// Chart is a set of series sharing the same categories or time buckets
+type Chart struct {
+	Title  string
+	Kind   Kind
+	Labels []string
+	Series []Series
+}
+
+// Render draws the chart into PNG image
+func Render(c Chart) ([]byte, error) {
+	if c.Kind != KindBar && c.Kind != KindLine {
+		return nil, fmt.Errorf("unsupported chart kind '%s'", c.Kind)
+	}
+	if len(c.Labels) == 0 || len(c.Series) == 0 {
+		return nil, fmt.Errorf("nothing to plot")
+	}
+	for _, s := range c.Series {
+		if len(s.Values) != len(c.Labels) {
+			return nil, fmt.Errorf("series '%s' has %d values for %d labels", s.Name, len(s.Values), len(c.Labels))
+		}
+	}
+	faces, err := loadFaces()
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	cv := canvas{img: img, faces: faces}
+
+	// Header with the title and the legend of multiple series
+	top := margin
+	if c.Title != "" {
+		top += cv.text(faces.title, c.Title, margin, top, foreground)
+		top += 10
+	}
+	if len(c.Series) > 1 {
+		top += cv.legend(c.Series, margin, top)
+		top += 10
+	}
+
+	// Vertical scale
+	var maxValue float64
+	for _, s := range c.Series {
+		for _, v := range s.Values {
+			maxValue = max(maxValue, v)
+		}
+	}
+	step, ceiling := niceScale(maxValue, tickCount)
+	var tickLabels []string
+	var tickWidth int
+	for v := 0.0; v <= ceiling+step/2; v += step {
+		label := formatTick(v, step)
+		tickLabels = append(tickLabels, label)
+		tickWidth = max(tickWidth, font.MeasureString(faces.regular, label).Ceil())
+	}
+
+	area := image.Rect(margin+tickWidth+8, top+8, width-margin, height-margin-faces.lineHeight-8)
+	y := func(v float64) float64 {
+		return float64(area.Max.Y) - v/ceiling*float64(area.Dy())
+	}
+	for i, label := range tickLabels {
+		ty := int(math.Round(y(float64(i) * step)))
+		cv.hline(area.Min.X, area.Max.X, ty, grid)
+		lw := font.MeasureString(faces.regular, label).Ceil()
+		cv.text(faces.regular, label, area.Min.X-8-lw, ty-faces.lineHeight/2, foreground)
+	}
+
+	// Categories
+	slot := float64(area.Dx()) / float64(len(c.Labels))
+	labels := make([]string, len(c.Labels))
+	var labelWidth int
+	for i, l := range c.Labels {
+		labels[i] = truncate(faces.regular, l, maxLabelWidth)
+		labelWidth = max(labelWidth, font.MeasureString(faces.regular, labels[i]).Ceil())
+	}
+	// Skip labels which would overlap
+	every := max(1, int(math.Ceil(float64(labelWidth+12)/slot)))
+	for i, l := range labels {
+		if i%every != 0 {
+			continue
+		}
+		center := float64(area.Min.X) + slot*(float64(i)+0.5)
+		lw := font.MeasureString(faces.regular, l).Ceil()
+		cv.text(faces.regular, l, int(center)-lw/2, area.Max.Y+8, foreground)
+	}
+
+	switch c.Kind {
+	case KindBar:
+		group := slot * 0.8
+		bar := group / float64(len(c.Series))
+		for si, s := range c.Series {
+			col := palette[si%len(palette)]
+			for i, v := range s.Values {
+				x0 := float64(area.Min.X) + slot*float64(i) + (slot-group)/2 + bar*float64(si)
+				rect := image.Rect(int(math.Round(x0)), int(math.Round(y(v))), int(math.Round(x0+bar)), area.Max.Y)
+				draw.Draw(img, rect, image.NewUniform(col), image.Point{}, draw.Src)
+			}
+		}
+	case KindLine:
+		for si, s := range c.Series {
+			// Markers are rasterized separately, overlapping shapes of
+			// the opposite winding would cancel each other out
+			lines := vector.NewRasterizer(width, height)
+			markers := vector.NewRasterizer(width, height)
+			var prevX, prevY float64
+			for i, v := range s.Values {
+				x := float64(area.Min.X) + slot*(float64(i)+0.5)
+				if i > 0 {
+					segment(lines, prevX, prevY, x, y(v), 2.5)
+				}
+				dot(markers, x, y(v), 3.5)
+				prevX, prevY = x, y(v)
+			}
+			col := image.NewUniform(palette[si%len(palette)])
+			lines.Draw(img, img.Bounds(), col, image.Point{})
+			markers.Draw(img, img.Bounds(), col, image.Point{})
+		}
+	}
+	cv.hline(area.Min.X, area.Max.X, area.Max.Y, foreground)
+
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart with %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type faces struct {
+	regular    font.Face
+	title      font.Face
+	lineHeight int
+}
+
+var loadFaces = sync.OnceValues(func() (faces, error) {
+	var f faces
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return f, fmt.Errorf("failed to parse regular font with %w", err)
+	}
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return f, fmt.Errorf("failed to parse bold font with %w", err)
+	}
+	f.regular, err = opentype.NewFace(regular, &opentype.FaceOptions{Size: 13, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return f, fmt.Errorf("failed to create regular face with %w", err)
+	}
+	f.title, err = opentype.NewFace(bold, &opentype.FaceOptions{Size: 18, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return f, fmt.Errorf("failed to create title face with %w", err)
+	}
+	f.lineHeight = f.regular.Metrics().Height.Ceil()
+	return f, nil
+})
+
+type canvas struct {
+	img   *image.RGBA
+	faces faces
+}
+
+// text draws text with its top left corner at (x, y) and returns line height
+func (c canvas) text(face font.Face, s string, x, y int, col color.Color) int {
+	m := face.Metrics()
+	d := font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(col),
+		Face: face,
+		Dot:  fixed.P(x, y+m.Ascent.Ceil()),
+	}
+	d.DrawString(s)
+	return m.Height.Ceil()
+}
+
+func (c canvas) hline(x0, x1, y int, col color.Color) {
+	draw.Draw(c.img, image.Rect(x0, y, x1, y+1), image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// legend draws series names in a row and returns its height
+func (c canvas) legend(series []Series, x, y int) int {
+	box := c.faces.lineHeight - 4
+	for i, s := range series {
+		col := palette[i%len(palette)]
+		draw.Draw(c.img, image.Rect(x, y+2, x+box, y+2+box), image.NewUniform(col), image.Point{}, draw.Src)
+		name := truncate(c.faces.regular, s.Name, maxLabelWidth)
+		c.text(c.faces.regular, name, x+box+6, y, foreground)
+		x += box + 6 + font.MeasureString(c.faces.regular, name).Ceil() + 16
+	}
+	return c.faces.lineHeight
+}
+
+// segment adds a line of the given thickness to the rasterizer
+func segment(r *vector.Rasterizer, x0, y0, x1, y1, thickness float64) {
+	length := math.Hypot(x1-x0, y1-y0)
+	if length == 0 {
+		return
+	}
+	// Normal scaled to the half of thickness
+	nx, ny := -(y1-y0)/length*thickness/2, (x1-x0)/length*thickness/2
+	r.MoveTo(float32(x0+nx), float32(y0+ny))
+	r.LineTo(float32(x1+nx), float32(y1+ny))
+	r.LineTo(float32(x1-nx), float32(y1-ny))
+	r.LineTo(float32(x0-nx), float32(y0-ny))
+	r.ClosePath()
+}
+
+// dot adds a filled circle approximated with a polygon
+func dot(r *vector.Rasterizer, x, y, radius float64) {
+	const sides = 16
+	r.MoveTo(float32(x+radius), float32(y))
+	for i := 1; i < sides; i++ {
+		a := 2 * math.Pi * float64(i) / sides
+		r.LineTo(float32(x+radius*math.Cos(a)), float32(y+radius*math.Sin(a)))
+	}
+	r.ClosePath()
+}
+
+// niceScale picks a round tick step and the axis maximum covering `maxValue`
+func niceScale(maxValue float64, ticks int) (step, ceiling float64) {
+	if maxValue <= 0 {
+		return 1, 1
+	}
+	raw := maxValue / float64(ticks)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step = 10 * magnitude
+	for _, m := range []float64{1, 2, 5} {
+		if m*magnitude >= raw {
+			step = m * magnitude
+			break
+		}
+	}
+	return step, math.Ceil(maxValue/step) * step
+}
+
+func formatTick(v, step float64) string {
+	decimals := max(0, int(-math.Floor(math.Log10(step))))
+	return strconv.FormatFloat(v, 'f', decimals, 64)
+}
+
+// truncate cuts text to fit into the width adding ellipsis
+func truncate(face font.Face, s string, maxWidth int) string {
+	if font.MeasureString(face, s).Ceil() <= maxWidth {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		t := string(runes) + "…"
+		if font.MeasureString(face, t).Ceil() <= maxWidth {
+			return t
+		}
+	}
+	return "…"
+}
@@ -0,0 +1,65 @@
+package plot
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	for _, kind := range []Kind{KindBar, KindLine} {
+		blob, err := Render(Chart{
+			Title:  "Messages per week",
+			Kind:   kind,
+			Labels: []string{"Sep 1", "Sep 8", "Sep 15", "A very long label which does not fit under the bar"},
+			Series: []Series{
+				{Name: "Cyber Valley", Values: []float64{10, 25, 3, 0}},
+				{Name: "Devops", Values: []float64{1, 2, 30, 4}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(bytes.NewReader(blob))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != width || b.Dy() != height {
+			t.Fatalf("unexpected image size %v", b)
+		}
+	}
+}
+
+func TestRender_Invalid(t *testing.T) {
+	charts := []Chart{
+		{Kind: "pie", Labels: []string{"a"}, Series: []Series{{Values: []float64{1}}}},
+		{Kind: KindBar},
+		{Kind: KindBar, Labels: []string{"a", "b"}, Series: []Series{{Name: "s", Values: []float64{1}}}},
+	}
+	for _, c := range charts {
+		if _, err := Render(c); err == nil {
+			t.Fatalf("expected error for %+v", c)
+		}
+	}
+}
+
+func TestNiceScale(t *testing.T) {
+	cases := []struct {
+		max, step, ceiling float64
+	}{
+		{0, 1, 1},
+		{3, 1, 3},
+		{47, 10, 50},
+		{120, 50, 150},
+		{0.7, 0.2, 0.8},
+	}
+	for _, c := range cases {
+		step, ceiling := niceScale(c.max, tickCount)
+		if step != c.step || ceiling != c.ceiling {
+			t.Fatalf("expected %v/%v for %v, got %v/%v", c.step, c.ceiling, c.max, step, ceiling)
+		}
+	}
+	if got := formatTick(0.6000000001, 0.2); got != "0.6" {
+		t.Fatalf("unexpected tick %s", got)
+	}
+}
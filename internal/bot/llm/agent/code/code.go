@@ -0,0 +1,165 @@
+package code
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"mimi/internal/bot/llm/agent"
+	"mimi/internal/provider/git/tree"
+)
+
+const (
+	codePrompt = "code"
+	// Directory with repositories cloned by the GitHub scraper
+	reposPathEnv = "GITHUB_REPOSITORY_BASE_PATH"
+	// Upper bound of tool calling round trips for a single question
+	maxTurns = 15
+)
+
+type CodeAgent struct {
+	tree       tree.Tree
+	codePrompt *ai.Prompt
+}
+
+func New(g *genkit.Genkit) CodeAgent {
+	reposPath := os.Getenv(reposPathEnv)
+	if reposPath == "" {
+		log.Fatalf("missing %s env variable", reposPathEnv)
+	}
+	t := tree.New(reposPath)
+	defineTools(g, t)
+
+	// Fail fast if prompt wasn't found
+	code := genkit.LookupPrompt(g, codePrompt)
+	if code == nil {
+		log.Fatalf("no prompt named '%s' found", codePrompt)
+	}
+
+	return CodeAgent{
+		tree:       t,
+		codePrompt: code,
+	}
+}
+
+func (a CodeAgent) GetInfo() agent.Info {
+	return agent.Info{
+		Name: "code",
+		Description: `Answers questions about the source code of Cyber Valley GitHub repositories:
+		how something is implemented, where it's defined, who changed it and when`,
+	}
+}
+
+func (a CodeAgent) Run(ctx context.Context, query string, msgs ...*ai.Message) (agent.Response, error) {
+	var result agent.Response
+	repos, err := a.tree.Repos()
+	if err != nil {
+		return result, fmt.Errorf("failed to list cloned repositories with %w", err)
+	}
+	if len(repos) == 0 {
+		return result, fmt.Errorf("no cloned repositories found")
+	}
+
+	resp, err := a.codePrompt.Execute(
+		ctx,
+		ai.WithMessages(msgs...),
+		ai.WithMaxTurns(maxTurns),
+		ai.WithInput(map[string]any{
+			"query":        query,
+			"repositories": strings.Join(repos, ", "),
+		}),
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to answer code question with %w", err)
+	}
+	result = agent.NewResponse(agent.DataText{Text: resp.Text()}, resp)
+	return result, nil
+}
+
+type listFilesInput struct {
+	Repo string `json:"repo" jsonschema_description:"Repository as owner/name"`
+	Dir  string `json:"dir,omitempty" jsonschema_description:"Directory relative to the repository root, empty for the whole repository"`
+}
+
+type readFileInput struct {
+	Repo string `json:"repo" jsonschema_description:"Repository as owner/name"`
+	Path string `json:"path" jsonschema_description:"File path relative to the repository root"`
+	From int    `json:"from,omitempty" jsonschema_description:"First line to read starting from 1"`
+}
+
+type grepInput struct {
+	Repo    string `json:"repo" jsonschema_description:"Repository as owner/name"`
+	Pattern string `json:"pattern" jsonschema_description:"Extended regular expression"`
+	Dir     string `json:"dir,omitempty" jsonschema_description:"Directory or file to search in, empty for the whole repository"`
+}
+
+type logInput struct {
+	Repo  string `json:"repo" jsonschema_description:"Repository as owner/name"`
+	Path  string `json:"path,omitempty" jsonschema_description:"File or directory, empty for the whole repository"`
+	Limit int    `json:"limit,omitempty" jsonschema_description:"Number of the latest commits"`
+}
+
+type blameInput struct {
+	Repo string `json:"repo" jsonschema_description:"Repository as owner/name"`
+	Path string `json:"path" jsonschema_description:"File path relative to the repository root"`
+	From int    `json:"from" jsonschema_description:"First line starting from 1"`
+	To   int    `json:"to" jsonschema_description:"Last line, inclusive"`
+}
+
+// defineTools registers read-only repository tools referenced by the prompt
+func defineTools(g *genkit.Genkit, t tree.Tree) {
+	genkit.DefineTool(
+		g, "listRepositories", "Lists cloned repositories as owner/name",
+		func(ctx *ai.ToolContext, _ struct{}) (string, error) {
+			repos, err := t.Repos()
+			if err != nil {
+				return "", err
+			}
+			return strings.Join(repos, "\n"), nil
+		})
+	genkit.DefineTool(
+		g, "listFiles", "Lists tracked files of the repository directory",
+		func(ctx *ai.ToolContext, input listFilesInput) (string, error) {
+			slog.Info("listing repository files", "repo", input.Repo, "dir", input.Dir)
+			return toolResult(t.ListFiles(input.Repo, input.Dir))
+		})
+	genkit.DefineTool(
+		g, "readFile", "Reads file with numbered lines",
+		func(ctx *ai.ToolContext, input readFileInput) (string, error) {
+			slog.Info("reading repository file", "repo", input.Repo, "path", input.Path, "from", input.From)
+			return toolResult(t.ReadFile(input.Repo, input.Path, input.From))
+		})
+	genkit.DefineTool(
+		g, "grepCode", "Searches tracked files with git grep and returns matching lines with their numbers",
+		func(ctx *ai.ToolContext, input grepInput) (string, error) {
+			slog.Info("searching repository", "repo", input.Repo, "pattern", input.Pattern, "dir", input.Dir)
+			return toolResult(t.Grep(input.Repo, input.Pattern, input.Dir))
+		})
+	genkit.DefineTool(
+		g, "gitLog", "Lists the latest commits touching the path with their authors and messages",
+		func(ctx *ai.ToolContext, input logInput) (string, error) {
+			slog.Info("reading repository log", "repo", input.Repo, "path", input.Path)
+			return toolResult(t.Log(input.Repo, input.Path, input.Limit))
+		})
+	genkit.DefineTool(
+		g, "gitBlame", "Shows who and when last changed each line of the file range",
+		func(ctx *ai.ToolContext, input blameInput) (string, error) {
+			slog.Info("blaming repository file", "repo", input.Repo, "path", input.Path, "from", input.From, "to", input.To)
+			return toolResult(t.Blame(input.Repo, input.Path, input.From, input.To))
+		})
+}
+
+// toolResult passes operation errors to the model, so it could correct the request
+func toolResult(out string, err error) (string, error) {
+	if err != nil {
+		slog.Warn("code tool failed", "with", err)
+		return fmt.Sprintf("error: %s", err), nil
+	}
+	return out, nil
+}
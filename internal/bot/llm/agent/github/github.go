@@ -52,7 +52,7 @@ func New(g *genkit.Genkit, org string) GitHubAgent {
 func (a GitHubAgent) GetInfo() agent.Info {
 	return agent.Info{
 		Name:        "github",
-		Description: `Capabled of answering about supply tasks state`,
+		Description: `Capabled of answering about supply tasks state, project board fields (amounts, due dates, iterations, assignees) and issue comments`,
 	}
 }
 
@@ -88,7 +88,7 @@ func (a GitHubAgent) Run(ctx context.Context, query string, msgs ...*ai.Message)
 	// Fetch GitHub board state
 	issues := make(map[string][]db.Issue)
 	for _, info := range targetProjects.Projects {
-		tmp, err := a.c.GetOrgProject(ctx, a.org, info.Id, time.Now().AddDate(-1, 0, 0))
+		tmp, err := a.c.GetOrgProject(ctx, a.org, info.Id, time.Now().AddDate(-1, 0, 0), time.Time{})
 		if err != nil {
 			return result, fmt.Errorf("failed to fetch supply board state with %w", err)
 		}
@@ -99,7 +99,13 @@ func (a GitHubAgent) Run(ctx context.Context, query string, msgs ...*ai.Message)
 	var docs []*ai.Document
 	for title, issues := range issues {
 		for _, issue := range issues {
-			docs = append(docs, ai.DocumentFromText(issue.Body, map[string]any{
+			// Custom fields are kept for amounts, due dates, etc.
+			blob, err := json.Marshal(issue.Brief())
+			if err != nil {
+				return result, fmt.Errorf("failed to serialize issue '%s' with %w", issue.URL, err)
+			}
+			docs = append(docs, ai.DocumentFromText(string(blob), map[string]any{
+				"type":         issue.Type,
 				"title":        issue.Title,
 				"url":          issue.URL,
 				"state":        issue.State,
@@ -113,7 +119,10 @@ func (a GitHubAgent) Run(ctx context.Context, query string, msgs ...*ai.Message)
 		ctx,
 		ai.WithDocs(docs...),
 		ai.WithMessages(msgs...),
-		ai.WithInput(map[string]any{"query": query}),
+		ai.WithInput(map[string]any{
+			"query": query,
+			"now":   time.Now().Format(time.DateOnly),
+		}),
 	)
 	if err != nil {
 		return result, fmt.Errorf("failed to evaluate final step with %w", err)
@@ -2,7 +2,6 @@ package logseq
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log"
 	"log/slog"
@@ -11,37 +10,32 @@ import (
 	"github.com/firebase/genkit/go/genkit"
 
 	"mimi/internal/bot/llm/agent"
-	"mimi/internal/provider/logseq/db"
+	"mimi/internal/provider/logseq/retriever"
 )
 
 const (
-	retrievePrompt = "logseq-retrieve"
-	evalPrompt     = "logseq-eval"
+	evalPrompt = "logseq-eval"
+	// Token budget of the retrieved pages passed to the eval prompt
+	contextBudget = 24_000
 )
 
 type LogseqAgent struct {
-	g              *genkit.Genkit
-	q              *db.Queries
-	retrievePrompt *ai.Prompt
-	evalPrompt     *ai.Prompt
+	g          *genkit.Genkit
+	r          *retriever.Retriever
+	evalPrompt *ai.Prompt
 }
 
-func New(g *genkit.Genkit, q *db.Queries) LogseqAgent {
+func New(g *genkit.Genkit, r *retriever.Retriever) LogseqAgent {
 	// Fail fast if prompt wasn't found
-	retrieve := genkit.LookupPrompt(g, retrievePrompt)
-	if retrieve == nil {
-		log.Fatalf("no prompt named '%s' found", retrievePrompt)
-	}
 	eval := genkit.LookupPrompt(g, evalPrompt)
 	if eval == nil {
 		log.Fatalf("no prompt named '%s' found", evalPrompt)
 	}
 
 	return LogseqAgent{
-		g:              g,
-		q:              q,
-		retrievePrompt: retrieve,
-		evalPrompt:     eval,
+		g:          g,
+		r:          r,
+		evalPrompt: eval,
 	}
 }
 
@@ -55,60 +49,25 @@ func (a LogseqAgent) GetInfo() agent.Info {
 
 func (a LogseqAgent) Run(ctx context.Context, query string, msgs ...*ai.Message) (agent.Response, error) {
 	var result agent.Response
-	// Find relative pages
-	titles, err := a.q.FindTitles()
+	// Find relevant pages
+	pages, err := a.r.Retrieve(ctx, query, contextBudget)
 	if err != nil {
-		return result, fmt.Errorf("failed to answer to query with %w", err)
+		return result, fmt.Errorf("failed to retrieve relevant pages with %w", err)
 	}
-	titleDocs := make([]*ai.Document, len(titles))
-	for i, t := range titles {
-		titleDocs[i] = ai.DocumentFromText(t, map[string]any{})
-	}
-
-	// Ask LLM to filter only relevant pages
-	resp, err := a.retrievePrompt.Execute(
-		ctx,
-		ai.WithDocs(titleDocs...),
-		ai.WithMessages(msgs...),
-		ai.WithInput(map[string]any{"query": query}),
-	)
-	if err != nil {
-		return result, fmt.Errorf("LLM request failed with %w", err)
-	}
-	var relevantPages map[string][]string
-	if err := resp.Output(&relevantPages); err != nil {
-		return result, fmt.Errorf("failed to parse LLM output with %w", err)
-	}
-	slog.Info("relevant pages", "titles", relevantPages["titles"])
-
-	// Fetch relevant docs
-	var errs []error
-	var docs []*ai.Document
-	for _, t := range relevantPages["titles"] {
-		rels, err := a.q.FindRelatives(t, 5)
-		if err != nil {
-			errs = append(errs, err)
-			continue
-		}
-	outer:
-		for _, rel := range rels {
-			for _, doc := range docs {
-				if doc.Metadata["title"].(string) == rel.Title {
-					continue outer
-				}
-			}
-			docs = append(docs, ai.DocumentFromText(rel.Content, map[string]any{"title": rel.Title}))
-		}
-	}
-	if len(errs) > 0 {
-		return result, fmt.Errorf("failed to fetch relevant pages with %w", errors.Join(errs...))
+	docs := make([]*ai.Document, len(pages))
+	for i, p := range pages {
+		docs[i] = ai.DocumentFromText(
+			fmt.Sprintf("Page: %s\n\n%s", p.Title, p.Content),
+			map[string]any{"title": p.Title, "score": p.Score},
+		)
 	}
 	slog.Info("relevant documents", "length", len(docs))
 
 	// Evaluate final prompt
-	resp, err = a.evalPrompt.Execute(
+	resp, err := a.evalPrompt.Execute(
 		ctx,
 		ai.WithDocs(docs...),
+		ai.WithMessages(msgs...),
 		ai.WithInput(map[string]any{"query": query}),
 	)
 	if err != nil {
@@ -4,22 +4,46 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"log"
 	"log/slog"
+	"strings"
 
 	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
 
 	"mimi/internal/bot/llm/agent"
 	"mimi/internal/provider/logseq"
+	"mimi/internal/provider/logseq/db"
 	"mimi/internal/provider/logseq/query"
 )
 
+const (
+	generatePrompt = "logseq-query-generate"
+	// Upper bound of the query generation attempts including repairs
+	maxGenerateAttempts = 3
+)
+
 type LogseqQueryAgent struct {
-	graph logseq.RegexGraph
+	graph *logseq.IndexedGraph
+	// Synced pages queried with Datalog before scanning the graph
+	q              *db.Queries
+	generatePrompt *ai.Prompt
 }
 
-func New(graph logseq.RegexGraph) LogseqQueryAgent {
-	return LogseqQueryAgent{graph: graph}
+func New(g *genkit.Genkit, graph *logseq.IndexedGraph, q *db.Queries) LogseqQueryAgent {
+	// Fail fast if prompt wasn't found
+	generate := genkit.LookupPrompt(g, generatePrompt)
+	if generate == nil {
+		log.Fatalf("no prompt named '%s' found", generatePrompt)
+	}
+
+	return LogseqQueryAgent{
+		graph:          graph,
+		q:              q,
+		generatePrompt: generate,
+	}
 }
 
 func (a LogseqQueryAgent) GetInfo() agent.Info {
@@ -30,14 +54,26 @@ func (a LogseqQueryAgent) GetInfo() agent.Info {
   query-sort-by:: page
 	query-sort-desc:: true'
 
+		Also translates plain questions listing pages by tags and properties
+		(e.g. "all species tagged psycho which should be supplied next month") into such queries.
 		As a result it returns a CSV file with results`,
 	}
 }
 
 func (a LogseqQueryAgent) Run(ctx context.Context, queryS string, msgs ...*ai.Message) (agent.Response, error) {
 	var result agent.Response
+	var caption string
+	if !strings.Contains(queryS, "{{query") {
+		generated, err := a.generate(ctx, queryS, msgs...)
+		if err != nil {
+			return result, err
+		}
+		queryS = generated
+		caption = generated
+	}
+
 	slog.Info("trying to eval logseq query")
-	out, err := query.Eval(ctx, a.graph, queryS)
+	out, err := a.eval(ctx, queryS)
 	if err != nil {
 		slog.Warn("failed to evaluate logseq query", "with", err)
 		return result, fmt.Errorf("failed to evaluate query with %w", err)
@@ -53,8 +89,88 @@ func (a LogseqQueryAgent) Run(ctx context.Context, queryS string, msgs ...*ai.Me
 	}
 
 	result = agent.NewResponse(agent.DataFile{
-		Blob: buf.Bytes(),
-		Name: "query-result.csv",
+		Blob:    buf.Bytes(),
+		Name:    "query-result.csv",
+		Caption: caption,
 	}, nil)
 	return result, nil
 }
+
+// eval prefers Datalog over the synced pages falling back to scanning the graph
+func (a LogseqQueryAgent) eval(ctx context.Context, queryS string) (query.Result, error) {
+	out, err := query.EvalDatalog(ctx, a.q, a.graph, queryS)
+	if err == nil {
+		return out, nil
+	}
+	slog.Warn("falling back to scanning LogSeq graph", "with", err)
+	return query.Eval(ctx, a.graph, queryS)
+}
+
+// generate translates the question into a query repairing it on validation errors
+func (a LogseqQueryAgent) generate(ctx context.Context, question string, msgs ...*ai.Message) (string, error) {
+	vocabulary, err := json.Marshal(query.FindVocabulary(a.graph))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal graph vocabulary with %w", err)
+	}
+	doc := ai.DocumentFromText(string(vocabulary), map[string]any{})
+
+	input := map[string]any{"query": question}
+	for attempt := 1; ; attempt++ {
+		resp, err := a.generatePrompt.Execute(
+			ctx,
+			ai.WithDocs(doc),
+			ai.WithMessages(msgs...),
+			ai.WithInput(input),
+		)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate LogSeq query with %w", err)
+		}
+		var out generatedQuery
+		if err := resp.Output(&out); err != nil {
+			return "", fmt.Errorf("failed to parse generated query '%s' with %w", resp.Text(), err)
+		}
+
+		text := out.String()
+		err = query.Validate(a.graph.GetConfig(), text)
+		if err == nil {
+			slog.Info("generated LogSeq query", "query", text, "attempt", attempt)
+			return text, nil
+		}
+		slog.Warn("generated invalid LogSeq query", "query", text, "attempt", attempt, "with", err)
+		if attempt == maxGenerateAttempts {
+			return "", fmt.Errorf("failed to generate valid query in %d attempts, the last one '%s' failed with %w", attempt, text, err)
+		}
+		input["previous"] = text
+		input["error"] = err.Error()
+	}
+}
+
+// generatedQuery is a structured output of the query generation prompt
+type generatedQuery struct {
+	Filter     string   `json:"filter"`
+	Properties []string `json:"properties"`
+	SortBy     string   `json:"sortBy"`
+	SortDesc   bool     `json:"sortDesc"`
+}
+
+// String formats query as it's written in LogSeq
+func (q generatedQuery) String() string {
+	var b strings.Builder
+	filter := strings.TrimSpace(q.Filter)
+	// Tolerate the wrapper copied from the user's examples
+	filter = strings.TrimSuffix(strings.TrimPrefix(filter, "{{query"), "}}")
+	fmt.Fprintf(&b, "{{query %s}}", strings.TrimSpace(filter))
+
+	if len(q.Properties) > 0 {
+		props := make([]string, len(q.Properties))
+		for i, prop := range q.Properties {
+			props[i] = ":" + strings.TrimPrefix(strings.TrimSpace(prop), ":")
+		}
+		fmt.Fprintf(&b, "\nquery-properties:: [%s]", strings.Join(props, " "))
+	}
+	if q.SortBy != "" {
+		fmt.Fprintf(&b, "\nquery-sort-by:: %s", strings.TrimPrefix(q.SortBy, ":"))
+		fmt.Fprintf(&b, "\nquery-sort-desc:: %t", q.SortDesc)
+	}
+	return b.String()
+}
@@ -0,0 +1,29 @@
+package logseqquery
+
+import (
+	"testing"
+
+	"mimi/internal/provider/logseq"
+	"mimi/internal/provider/logseq/query"
+)
+
+func TestGeneratedQuery_String(t *testing.T) {
+	cases := map[string]generatedQuery{
+		`{{query (page-tags [[psycho]])}}`: {Filter: " (page-tags [[psycho]]) "},
+		`{{query [[fern]]}}`:               {Filter: "{{query [[fern]]}}"},
+		"{{query (property :supply \"next-month\")}}\nquery-properties:: [:page :supply]\nquery-sort-by:: supply\nquery-sort-desc:: false": {
+			Filter:     `(property :supply "next-month")`,
+			Properties: []string{"page", ":supply"},
+			SortBy:     "supply",
+		},
+	}
+	for expected, q := range cases {
+		got := q.String()
+		if got != expected {
+			t.Fatalf("expected %q, got %q", expected, got)
+		}
+		if err := query.Validate(logseq.DefaultConfig(), got); err != nil {
+			t.Fatalf("expected '%s' to be valid, got %s", got, err)
+		}
+	}
+}
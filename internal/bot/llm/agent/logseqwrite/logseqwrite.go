@@ -0,0 +1,169 @@
+package logseqwrite
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+
+	"mimi/internal/bot/llm/agent"
+	"mimi/internal/provider/git"
+	"mimi/internal/provider/logseq"
+	"mimi/internal/provider/logseq/writer"
+)
+
+const writePrompt = "logseq-write"
+
+// Identity of the bot in the graph's history, users are added as co-authors
+var committer = writer.Author{Name: "mimi", Email: "mimi@cyber-valley.invalid"}
+
+type LogseqWriteAgent struct {
+	writePrompt *ai.Prompt
+	writer      *writer.Writer
+	repoPath    string
+	loc         *time.Location
+	// Reindexed with the written pages so queries see them before the next pull
+	graph *logseq.IndexedGraph
+}
+
+// New creates the agent, `loc` is used to resolve today's journal
+func New(g *genkit.Genkit, graph *logseq.IndexedGraph, loc *time.Location) LogseqWriteAgent {
+	repoPath := graph.Source().Path
+	// Fail fast if prompt wasn't found
+	write := genkit.LookupPrompt(g, writePrompt)
+	if write == nil {
+		log.Fatalf("no prompt named '%s' found", writePrompt)
+	}
+
+	return LogseqWriteAgent{
+		writePrompt: write,
+		writer:      writer.New(repoPath, committer, writer.WithAuthToken(os.Getenv("GITHUB_TOKEN"))),
+		repoPath:    repoPath,
+		loc:         loc,
+		graph:       graph,
+	}
+}
+
+func (a LogseqWriteAgent) GetInfo() agent.Info {
+	return agent.Info{
+		Name: "logseq-write",
+		Description: `Records information into Cyber Valley's LogSeq graph: appends notes to today's journal,
+		creates pages (optionally from templates) and sets page properties.
+		Use it only when the user explicitly asks to note, record, save or change something in LogSeq`,
+	}
+}
+
+func (a LogseqWriteAgent) Run(ctx context.Context, query string, msgs ...*ai.Message) (agent.Response, error) {
+	var result agent.Response
+	now := time.Now().In(a.loc)
+	resp, err := a.writePrompt.Execute(
+		ctx,
+		ai.WithMessages(msgs...),
+		ai.WithInput(map[string]any{
+			"query": query,
+			"today": now.Format("Monday, 2006-01-02"),
+		}),
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to plan LogSeq edit with %w", err)
+	}
+	var out writeOutput
+	if err := resp.Output(&out); err != nil {
+		return result, fmt.Errorf("failed to parse LogSeq edit '%s' with %w", resp.Text(), err)
+	}
+	slog.Info("planned LogSeq edit", "action", out.Action, "title", out.Title)
+
+	coAuthor := coAuthorFrom(ctx)
+	var commit writer.Commit
+	switch out.Action {
+	case "journal":
+		if len(out.Blocks) == 0 {
+			return result, fmt.Errorf("no blocks to add to the journal")
+		}
+		commit, err = a.writer.AppendToJournal(ctx, now, out.Blocks, coAuthor)
+	case "page":
+		commit, err = a.writer.CreatePage(ctx, out.Title, out.Template, out.properties(), coAuthor)
+	case "properties":
+		if len(out.Properties) == 0 {
+			return result, fmt.Errorf("no properties to set for '%s'", out.Title)
+		}
+		commit, err = a.writer.SetPageProperties(ctx, out.Title, out.properties(), coAuthor)
+	default:
+		return result, fmt.Errorf("unknown LogSeq edit action '%s'", out.Action)
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to edit LogSeq graph with %w", err)
+	}
+	paths := make([]string, len(commit.Paths))
+	for i, path := range commit.Paths {
+		paths[i] = filepath.Join(a.repoPath, path)
+	}
+	a.graph.RefreshFiles(paths)
+
+	result = agent.NewResponse(agent.DataText{Text: a.describe(commit)}, resp)
+	return result, nil
+}
+
+// describe lists changed files with a link to the published commit
+func (a LogseqWriteAgent) describe(c writer.Commit) string {
+	var b strings.Builder
+	b.WriteString("Updated LogSeq graph:\n")
+	for _, path := range c.Paths {
+		fmt.Fprintf(&b, "- %s\n", path)
+	}
+	url, err := git.WebURL(a.repoPath)
+	if err != nil {
+		slog.Warn("failed to get graph repository URL", "with", err)
+		fmt.Fprintf(&b, "Commit %s", c.Hash)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%s/commit/%s", url, c.Hash)
+	return b.String()
+}
+
+// coAuthorFrom credits the Telegram user who requested the change
+func coAuthorFrom(ctx context.Context) *writer.Author {
+	u, ok := agent.UserFrom(ctx)
+	if !ok {
+		return nil
+	}
+	name := u.FullName()
+	if u.UserName != "" && name != u.UserName {
+		name = fmt.Sprintf("%s (@%s)", name, u.UserName)
+	}
+	return &writer.Author{
+		Name:  strings.TrimSpace(name),
+		Email: fmt.Sprintf("%d@telegram.invalid", u.ID),
+	}
+}
+
+// writeOutput is a structured output of the write prompt
+type writeOutput struct {
+	Action     string   `json:"action"`
+	Title      string   `json:"title"`
+	Template   string   `json:"template"`
+	Blocks     []string `json:"blocks"`
+	Properties []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"properties"`
+}
+
+func (o writeOutput) properties() []writer.Property {
+	props := make([]writer.Property, 0, len(o.Properties))
+	for _, p := range o.Properties {
+		name := strings.Trim(strings.TrimSpace(p.Name), ":")
+		if name == "" {
+			continue
+		}
+		props = append(props, writer.Property{Name: name, Value: strings.TrimSpace(p.Value)})
+	}
+	return props
+}
@@ -0,0 +1,43 @@
+package logseqwrite
+
+import (
+	"context"
+	"testing"
+
+	"mimi/internal/bot/llm/agent"
+)
+
+func TestCoAuthorFrom(t *testing.T) {
+	if got := coAuthorFrom(context.Background()); got != nil {
+		t.Fatalf("expected no co-author, got %v", got)
+	}
+	cases := map[agent.User]string{
+		{ID: 1, FirstName: "Alice", LastName: "Liddell", UserName: "alice"}: "Alice Liddell (@alice) <1@telegram.invalid>",
+		{ID: 2, UserName: "bob"}:    "bob <2@telegram.invalid>",
+		{ID: 3, FirstName: "Carol"}: "Carol <3@telegram.invalid>",
+	}
+	for u, expected := range cases {
+		got := coAuthorFrom(agent.WithUser(context.Background(), u))
+		if got == nil || got.String() != expected {
+			t.Fatalf("expected %s, got %v", expected, got)
+		}
+	}
+}
+
+func TestProperties(t *testing.T) {
+	var out writeOutput
+	out.Properties = append(out.Properties,
+		struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		}{"tags::", " species "},
+		struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		}{" ", "ignored"},
+	)
+	props := out.properties()
+	if len(props) != 1 || props[0].Name != "tags" || props[0].Value != "species" {
+		t.Fatalf("unexpected properties %v", props)
+	}
+}
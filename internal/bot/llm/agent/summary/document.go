@@ -0,0 +1,83 @@
+package summary
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"mimi/internal/bot/llm/agent/summary/export"
+)
+
+const documentTitle = "Саммари событий Cyber Valley"
+
+// Matches "--format pdf", "--format=html" and "-f md" flags of the query
+var formatFlagRe = regexp.MustCompile(`(?i)(?:^|\s)(?:--format|-f)(?:=|\s+)(\S+)`)
+
+// cutFormatFlag extracts explicit output format from the query
+func cutFormatFlag(query string) (format string, rest string, found bool) {
+	m := formatFlagRe.FindStringSubmatchIndex(query)
+	if m == nil {
+		return "", query, false
+	}
+	format = query[m[2]:m[3]]
+	rest = strings.Join(strings.Fields(query[:m[0]]+" "+query[m[1]:]), " ")
+	return format, rest, true
+}
+
+// newDocument groups partial summaries into report sections
+func newDocument(period Period, overview string, partials []partialSummary) export.Document {
+	projects := export.Section{Title: "Проекты GitHub"}
+	supply := export.Section{Title: "Снабжение"}
+	logseq := export.Section{Title: "Изменения LogSeq"}
+	telegram := export.Section{Title: "Темы Telegram"}
+	posts := export.Section{Title: "Посты X"}
+	for _, p := range partials {
+		entry := export.Entry{Title: p.title, URL: p.url, Text: p.text}
+		switch {
+		case p.role == roleSupply || p.role == roleInventory:
+			supply.Entries = append(supply.Entries, entry)
+		case p.kind == chunkGitHub:
+			projects.Entries = append(projects.Entries, entry)
+		case p.kind == chunkLogseq:
+			logseq.Entries = append(logseq.Entries, entry)
+		case p.kind == chunkTelegram:
+			telegram.Entries = append(telegram.Entries, entry)
+		case p.kind == chunkX:
+			posts.Entries = append(posts.Entries, entry)
+		}
+	}
+	return export.Document{
+		Title:    documentTitle,
+		Period:   period.String(),
+		Overview: overview,
+		Sections: []export.Section{projects, supply, logseq, telegram, posts},
+	}
+}
+
+// documentName names exported file after the summarized period
+func documentName(period Period, format export.Format) string {
+	return fmt.Sprintf(
+		"summary-%s-%s.%s",
+		period.Since.Format("2006-01-02"),
+		period.Until.Format("2006-01-02"),
+		format.Extension(),
+	)
+}
+
+// telegramURL links to the message in the Telegram channel or supergroup
+func telegramURL(peerID int64, messageID int32) string {
+	return fmt.Sprintf("https://t.me/c/%d/%d", peerID, messageID)
+}
+
+// logseqURL links to the page file in the LogSeq repository
+func logseqURL(repoURL, path string) string {
+	if repoURL == "" {
+		return ""
+	}
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return fmt.Sprintf("%s/blob/HEAD/%s", repoURL, strings.Join(segments, "/"))
+}
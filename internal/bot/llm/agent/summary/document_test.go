@@ -0,0 +1,60 @@
+package summary
+
+import (
+	"testing"
+	"time"
+
+	"mimi/internal/bot/llm/agent/summary/export"
+)
+
+func TestCutFormatFlag(t *testing.T) {
+	cases := []struct {
+		query, format, rest string
+		found               bool
+	}{
+		{"--format pdf last week", "pdf", "last week", true},
+		{"summary for September --format=html", "html", "summary for September", true},
+		{"yesterday -f md please", "md", "yesterday please", true},
+		{"last week as pdf", "", "last week as pdf", false},
+	}
+	for _, c := range cases {
+		format, rest, found := cutFormatFlag(c.query)
+		if format != c.format || rest != c.rest || found != c.found {
+			t.Fatalf("unexpected result for '%s': %q, %q, %v", c.query, format, rest, found)
+		}
+	}
+}
+
+func TestNewDocument(t *testing.T) {
+	period := Period{
+		Since: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+		Until: time.Date(2025, 9, 16, 0, 0, 0, 0, time.UTC),
+	}
+	doc := newDocument(period, "overview", []partialSummary{
+		{kind: chunkGitHub, title: "rockets", role: roleTasks, text: "a"},
+		{kind: chunkGitHub, title: "supply", role: roleSupply, text: "b"},
+		{kind: chunkLogseq, title: "pages/foo.md", text: "c"},
+		{kind: chunkTelegram, title: "rockets / general", text: "d"},
+		{kind: chunkX, title: "@mastercyb", text: "e"},
+	})
+	for i, expected := range []string{"rockets", "supply", "pages/foo.md", "rockets / general", "@mastercyb"} {
+		entries := doc.Sections[i].Entries
+		if len(entries) != 1 || entries[0].Title != expected {
+			t.Fatalf("expected section '%s' to contain only '%s', got %#v", doc.Sections[i].Title, expected, entries)
+		}
+	}
+	if name := documentName(period, export.FormatPDF); name != "summary-2025-09-01-2025-09-16.pdf" {
+		t.Fatalf("unexpected document name %s", name)
+	}
+}
+
+func TestLogseqURL(t *testing.T) {
+	got := logseqURL("https://github.com/cyber-valley/cvland", "pages/новая страница.md")
+	expected := "https://github.com/cyber-valley/cvland/blob/HEAD/pages/%D0%BD%D0%BE%D0%B2%D0%B0%D1%8F%20%D1%81%D1%82%D1%80%D0%B0%D0%BD%D0%B8%D1%86%D0%B0.md"
+	if got != expected {
+		t.Fatalf("expected %s, got %s", expected, got)
+	}
+	if logseqURL("", "pages/foo.md") != "" {
+		t.Fatal("expected empty URL without repository")
+	}
+}
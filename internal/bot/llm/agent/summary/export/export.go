@@ -0,0 +1,88 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Format string
+
+const (
+	// Plain Telegram message without any attachment
+	FormatText     Format = "text"
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+	FormatPDF      Format = "pdf"
+)
+
+var formats = []Format{FormatText, FormatMarkdown, FormatHTML, FormatPDF}
+
+// ParseFormat resolves format by its name or file extension
+func ParseFormat(s string) (Format, error) {
+	s = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "."))
+	switch s {
+	case "", "txt", "message":
+		return FormatText, nil
+	case "md":
+		return FormatMarkdown, nil
+	case "htm":
+		return FormatHTML, nil
+	}
+	for _, f := range formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown format '%s', expected one of %v", s, formats)
+}
+
+// Extension returns file extension of the format without leading dot
+func (f Format) Extension() string {
+	switch f {
+	case FormatMarkdown:
+		return "md"
+	case FormatHTML:
+		return "html"
+	case FormatPDF:
+		return "pdf"
+	default:
+		return "txt"
+	}
+}
+
+// Document is a summary report independent of the output format
+type Document struct {
+	Title  string
+	Period string
+	// Final summary in Markdown
+	Overview string
+	Sections []Section
+}
+
+type Section struct {
+	Title   string
+	Entries []Entry
+}
+
+// Entry is a summary of a single data source item e.g. project board or chat topic
+type Entry struct {
+	Title string
+	// Link to the original data, may be empty
+	URL string
+	// Summary in Markdown
+	Text string
+}
+
+// Render encodes document in the given format
+func Render(doc Document, format Format) ([]byte, error) {
+	switch format {
+	case FormatMarkdown:
+		return Markdown(doc), nil
+	case FormatHTML:
+		return HTML(doc)
+	case FormatPDF:
+		return PDF(doc)
+	default:
+		return nil, fmt.Errorf("format '%s' can't be rendered as a document", format)
+	}
+}
@@ -0,0 +1,112 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+var doc = Document{
+	Title:    "📅 Саммари событий Cyber Valley",
+	Period:   "01.09.2025 – 15.09.2025 (WITA)",
+	Overview: "🚀 **Статус** проектов\n\n- [Fix pump](https://github.com/cyber-valley/cvland/issues/1): done\n  - nested <script>alert(1)</script>",
+	Sections: []Section{
+		{
+			Title: "Проекты GitHub",
+			Entries: []Entry{{
+				Title: "rockets",
+				URL:   "https://github.com/orgs/cyber-valley/projects/2",
+				Text:  "## Done\n\n* task one\n* task two https://example.com",
+			}},
+		},
+		{Title: "Снабжение"},
+		{
+			Title:   "Темы Telegram",
+			Entries: []Entry{{Title: "rockets / general", Text: "Discussed the launch"}},
+		},
+	},
+}
+
+func TestParseFormat(t *testing.T) {
+	cases := map[string]Format{
+		"":      FormatText,
+		"PDF":   FormatPDF,
+		".md":   FormatMarkdown,
+		"html":  FormatHTML,
+		" htm ": FormatHTML,
+	}
+	for input, expected := range cases {
+		got, err := ParseFormat(input)
+		if err != nil {
+			t.Fatalf("failed to parse '%s' with %s", input, err)
+		}
+		if got != expected {
+			t.Fatalf("expected %s for '%s', got %s", expected, input, got)
+		}
+	}
+	if _, err := ParseFormat("docx"); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	got := string(Markdown(doc))
+	for _, expected := range []string{
+		"# 📅 Саммари событий Cyber Valley\n",
+		"## Проекты GitHub\n",
+		"### [rockets](https://github.com/orgs/cyber-valley/projects/2)\n",
+		"### rockets / general\n",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected markdown to contain %q, got:\n%s", expected, got)
+		}
+	}
+	if strings.Contains(got, "Снабжение") {
+		t.Fatalf("expected empty section to be skipped, got:\n%s", got)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	blob, err := HTML(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(blob)
+	for _, expected := range []string{
+		`<a href="https://github.com/orgs/cyber-valley/projects/2" target="_blank">rockets</a>`,
+		`<a href="https://github.com/cyber-valley/cvland/issues/1" target="_blank">Fix pump</a>`,
+		"<h2>Темы Telegram</h2>",
+		"<strong>Статус</strong>",
+	} {
+		if !strings.Contains(got, expected) {
+			t.Fatalf("expected HTML to contain %q, got:\n%s", expected, got)
+		}
+	}
+	if strings.Contains(got, "<script>") {
+		t.Fatalf("expected raw HTML to be dropped, got:\n%s", got)
+	}
+}
+
+func TestPDF(t *testing.T) {
+	blob, err := PDF(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(blob, []byte("%PDF-")) {
+		t.Fatalf("expected PDF header, got %q", blob[:min(len(blob), 16)])
+	}
+	if !bytes.Contains(blob, []byte("https://github.com/orgs/cyber-valley/projects/2")) {
+		t.Fatal("expected PDF to contain project link")
+	}
+}
+
+func TestGlyphFilter(t *testing.T) {
+	font, err := regularFont()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := glyphFilter(font)("📅 Период • 1–2")
+	if got != " Период • 1–2" {
+		t.Fatalf("unexpected filtered text %q", got)
+	}
+}
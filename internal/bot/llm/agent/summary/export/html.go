@@ -0,0 +1,67 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+
+	"github.com/gomarkdown/markdown"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+// Styles are inlined to keep the report self-contained
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"markdown": markdownToHTML,
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; max-width: 52rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; color: #1f2328; }
+h1 { margin-bottom: 0; }
+h2 { border-bottom: 1px solid #d0d7de; padding-bottom: .3rem; margin-top: 2.5rem; }
+h3 { margin-bottom: .3rem; }
+a { color: #0969da; }
+nav ul { padding-left: 1.2rem; }
+.period { color: #59636e; margin-top: .2rem; }
+.entry { margin-bottom: 1.5rem; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Period}}<p class="period">{{.}}</p>{{end}}
+<nav><ul>
+{{range $i, $s := .Sections}}{{if $s.Entries}}<li><a href="#section-{{$i}}">{{$s.Title}}</a></li>
+{{end}}{{end}}</ul></nav>
+{{with .Overview}}<section>{{markdown .}}</section>{{end}}
+{{range $i, $s := .Sections}}{{if $s.Entries}}<section id="section-{{$i}}">
+<h2>{{$s.Title}}</h2>
+{{range $s.Entries}}<div class="entry">
+<h3>{{if .URL}}<a href="{{.URL}}" target="_blank">{{.Title}}</a>{{else}}{{.Title}}{{end}}</h3>
+{{markdown .Text}}
+</div>
+{{end}}</section>
+{{end}}{{end}}</body>
+</html>
+`))
+
+// HTML renders document as a self-contained HTML page
+func HTML(doc Document) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, doc); err != nil {
+		return nil, fmt.Errorf("failed to execute HTML template with %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// markdownToHTML converts LLM output to HTML dropping any raw HTML in it
+func markdownToHTML(md string) template.HTML {
+	p := parser.NewWithExtensions(parser.CommonExtensions | parser.AutoHeadingIDs)
+	r := mdhtml.NewRenderer(mdhtml.RendererOptions{
+		Flags: mdhtml.CommonFlags | mdhtml.HrefTargetBlank | mdhtml.SkipHTML,
+	})
+	return template.HTML(markdown.ToHTML([]byte(md), p, r))
+}
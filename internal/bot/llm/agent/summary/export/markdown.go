@@ -0,0 +1,33 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Markdown renders document as a single Markdown file
+func Markdown(doc Document) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", doc.Title)
+	if doc.Period != "" {
+		fmt.Fprintf(&b, "_%s_\n\n", doc.Period)
+	}
+	if overview := strings.TrimSpace(doc.Overview); overview != "" {
+		fmt.Fprintf(&b, "%s\n\n", overview)
+	}
+	for _, s := range doc.Sections {
+		if len(s.Entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "## %s\n\n", s.Title)
+		for _, e := range s.Entries {
+			if e.URL != "" {
+				fmt.Fprintf(&b, "### [%s](%s)\n\n", e.Title, e.URL)
+			} else {
+				fmt.Fprintf(&b, "### %s\n\n", e.Title)
+			}
+			fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(e.Text))
+		}
+	}
+	return []byte(strings.TrimRight(b.String(), "\n") + "\n")
+}
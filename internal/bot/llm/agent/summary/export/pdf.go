@@ -0,0 +1,192 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+)
+
+const (
+	pdfFont       = "Go"
+	pdfFontSize   = 11
+	pdfLineHeight = 5.5
+	pdfMargin     = 15
+	pdfIndent     = 5
+)
+
+var (
+	listItemRe = regexp.MustCompile(`^(\s*)(?:[-*+•]|\d+[.)])\s+(.*)$`)
+	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	// Bold text, Markdown links and bare URLs
+	inlineRe = regexp.MustCompile(`\*\*(.+?)\*\*|\[([^\]]+)\]\(([^)\s]+)\)|(https?://[^\s)]+)`)
+)
+
+// Parsed font used to check which glyphs can be rendered
+var regularFont = sync.OnceValues(func() (*sfnt.Font, error) {
+	return sfnt.Parse(goregular.TTF)
+})
+
+// PDF renders document with embedded Go fonts which cover Latin and Cyrillic scripts
+func PDF(doc Document) ([]byte, error) {
+	font, err := regularFont()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PDF font with %w", err)
+	}
+	w := pdfWriter{
+		pdf:   fpdf.New("P", "mm", "A4", ""),
+		clean: glyphFilter(font),
+	}
+	pdf := w.pdf
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.AddUTF8FontFromBytes(pdfFont, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", gobold.TTF)
+	pdf.SetTitle(strings.TrimSpace(w.clean(doc.Title)), true)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfMargin + 5)
+		pdf.SetFont(pdfFont, "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%d / {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	w.heading(doc.Title, 18)
+	if doc.Period != "" {
+		pdf.SetFont(pdfFont, "", pdfFontSize)
+		pdf.SetTextColor(90, 90, 90)
+		pdf.Write(pdfLineHeight, w.clean(doc.Period))
+		pdf.Ln(pdfLineHeight * 2)
+	}
+	w.markdown(doc.Overview)
+
+	for _, s := range doc.Sections {
+		if len(s.Entries) == 0 {
+			continue
+		}
+		pdf.Ln(pdfLineHeight)
+		w.heading(s.Title, 15)
+		for _, e := range s.Entries {
+			pdf.SetFont(pdfFont, "B", 12.5)
+			if e.URL != "" {
+				pdf.SetTextColor(9, 105, 218)
+				pdf.WriteLinkString(pdfLineHeight+1, strings.TrimSpace(w.clean(e.Title)), e.URL)
+			} else {
+				pdf.SetTextColor(0, 0, 0)
+				pdf.Write(pdfLineHeight+1, strings.TrimSpace(w.clean(e.Title)))
+			}
+			pdf.Ln(pdfLineHeight + 2)
+			w.markdown(e.Text)
+			pdf.Ln(pdfLineHeight / 2)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render PDF with %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type pdfWriter struct {
+	pdf   *fpdf.Fpdf
+	clean func(string) string
+}
+
+func (w pdfWriter) heading(text string, size float64) {
+	w.pdf.SetFont(pdfFont, "B", size)
+	w.pdf.SetTextColor(0, 0, 0)
+	w.pdf.MultiCell(0, size/2, strings.TrimSpace(w.clean(text)), "", "L", false)
+	w.pdf.Ln(pdfLineHeight / 2)
+}
+
+// markdown renders the subset of Markdown used by LLM: headings, lists, bold text and links
+func (w pdfWriter) markdown(text string) {
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			w.pdf.Ln(pdfLineHeight / 2)
+			continue
+		}
+		if m := headingRe.FindStringSubmatch(line); m != nil {
+			w.heading(m[2], 14-float64(len(m[1])))
+			continue
+		}
+
+		indent := 0.0
+		if m := listItemRe.FindStringSubmatch(line); m != nil {
+			indent = pdfIndent * float64(1+len(strings.ReplaceAll(m[1], "\t", "  "))/2)
+			w.pdf.SetFont(pdfFont, "", pdfFontSize)
+			w.pdf.SetTextColor(0, 0, 0)
+			w.pdf.SetX(pdfMargin + indent - pdfIndent + 1)
+			w.pdf.Write(pdfLineHeight, "•")
+			line = m[2]
+		}
+		// Wrapped lines of list items are aligned with the item text
+		w.pdf.SetLeftMargin(pdfMargin + indent)
+		if indent > 0 {
+			w.pdf.SetX(pdfMargin + indent)
+		}
+		w.inline(line)
+		w.pdf.SetLeftMargin(pdfMargin)
+		w.pdf.Ln(pdfLineHeight)
+	}
+}
+
+func (w pdfWriter) inline(line string) {
+	line = strings.ReplaceAll(line, "`", "")
+	last := 0
+	for _, m := range inlineRe.FindAllStringSubmatchIndex(line, -1) {
+		w.text(line[last:m[0]], "")
+		switch {
+		case m[2] >= 0:
+			w.text(line[m[2]:m[3]], "B")
+		case m[4] >= 0:
+			w.link(line[m[4]:m[5]], line[m[6]:m[7]])
+		default:
+			w.link(line[m[8]:m[9]], line[m[8]:m[9]])
+		}
+		last = m[1]
+	}
+	w.text(line[last:], "")
+}
+
+func (w pdfWriter) text(s, style string) {
+	if s == "" {
+		return
+	}
+	w.pdf.SetFont(pdfFont, style, pdfFontSize)
+	w.pdf.SetTextColor(0, 0, 0)
+	w.pdf.Write(pdfLineHeight, w.clean(s))
+}
+
+func (w pdfWriter) link(text, url string) {
+	w.pdf.SetFont(pdfFont, "", pdfFontSize)
+	w.pdf.SetTextColor(9, 105, 218)
+	w.pdf.WriteLinkString(pdfLineHeight, w.clean(text), url)
+}
+
+// glyphFilter drops characters absent in the font e.g. emoji
+// which would be rendered as empty boxes otherwise
+func glyphFilter(font *sfnt.Font) func(string) string {
+	var buf sfnt.Buffer
+	return func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if r == '\t' {
+				return ' '
+			}
+			idx, err := font.GlyphIndex(&buf, r)
+			if err != nil || idx == 0 {
+				return -1
+			}
+			return r
+		}, s)
+	}
+}
@@ -0,0 +1,251 @@
+package summary
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/jackc/pgx/v5"
+
+	"mimi/internal/persist"
+)
+
+type chunkKind string
+
+const (
+	chunkGitHub   chunkKind = "github"
+	chunkTelegram chunkKind = "telegram"
+	chunkLogseq   chunkKind = "logseq"
+	chunkX        chunkKind = "x"
+)
+
+// Token budgets of a single partial summarization call for each kind of data
+var tokenBudgets = map[chunkKind]int{
+	chunkGitHub:   16_000,
+	chunkTelegram: 24_000,
+	chunkLogseq:   8_000,
+	chunkX:        8_000,
+}
+
+// What partial summaries should focus on for each kind of data
+var focuses = map[chunkKind]string{
+	chunkGitHub: `completed and current tasks of the GitHub project board with their statuses, assignees,
+amounts and due dates from the custom fields. Keep issue titles and URLs`,
+	chunkTelegram: `discussed topics and accepted decisions with their consequences.
+Do not quote the messages, keep only facts, names and numbers`,
+	chunkLogseq: `what was added, changed or removed on the LogSeq page`,
+	chunkX: `topics, announcements and opinions of the author's X posts.
+Keep links to the most important posts`,
+}
+
+const (
+	// Rough estimation, Cyrillic texts are tokenized denser than English ones
+	charsPerToken = 3
+	// Upper bound of simultaneous LLM calls during the map step
+	mapConcurrency = 4
+	// Upper bound of reduce steps for a single chunk
+	maxReduceLevels = 3
+)
+
+// chunk is a piece of data which is summarized independently on the map step
+type chunk struct {
+	kind  chunkKind
+	title string
+	// Role of the GitHub project, empty for other kinds
+	role projectRole
+	// Link to the original data, may be empty
+	url   string
+	items []string
+	// Identifiers of the summarized items mapped to their states
+	ids map[string]string
+}
+
+type partialSummary struct {
+	kind  chunkKind
+	title string
+	role  projectRole
+	url   string
+	text  string
+}
+
+// document formats partial summary for the final summary prompt
+func (s partialSummary) document() *ai.Document {
+	header := fmt.Sprintf("[%s] %s", s.kind, s.title)
+	if s.role != "" {
+		header = fmt.Sprintf("%s (%s)", header, s.role)
+	}
+	return ai.DocumentFromText(
+		fmt.Sprintf("%s\n\n%s", header, s.text),
+		map[string]any{"kind": string(s.kind), "title": s.title, "role": string(s.role)},
+	)
+}
+
+// summarizeChunks runs map step over all chunks concurrently
+func (a SummaryAgent) summarizeChunks(ctx context.Context, chunks []chunk) ([]partialSummary, error) {
+	summaries := make([]partialSummary, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, mapConcurrency)
+
+	var wg sync.WaitGroup
+	for i, c := range chunks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			text, err := a.summarizeChunk(ctx, c)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to summarize %s '%s' with %w", c.kind, c.title, err)
+				return
+			}
+			summaries[i] = partialSummary{kind: c.kind, title: c.title, role: c.role, url: c.url, text: text}
+		}()
+	}
+	wg.Wait()
+
+	return summaries, errors.Join(errs...)
+}
+
+// summarizeChunk summarizes chunk's items in batches fitting the token budget
+// and then summarizes summaries of the batches until the single one left
+func (a SummaryAgent) summarizeChunk(ctx context.Context, c chunk) (string, error) {
+	if len(c.items) == 0 {
+		return "", fmt.Errorf("got empty chunk")
+	}
+	budget := tokenBudgets[c.kind]
+	items := c.items
+	for level := 0; ; level++ {
+		batches := planBatches(items, budget, level)
+
+		summaries := make([]string, len(batches))
+		for i, batch := range batches {
+			summary, err := a.summarizeBatch(ctx, c, batch)
+			if err != nil {
+				return "", err
+			}
+			summaries[i] = summary
+		}
+		slog.Info("summarized chunk", "kind", c.kind, "title", c.title, "level", level, "batches", len(batches))
+
+		if len(summaries) == 1 {
+			return summaries[0], nil
+		}
+		items = summaries
+	}
+}
+
+// summarizeBatch calls LLM to summarize the batch or returns cached summary
+func (a SummaryAgent) summarizeBatch(ctx context.Context, c chunk, batch []string) (string, error) {
+	q := persist.New(a.pgPool)
+	key := cacheKey(c.kind, c.title, batch)
+	cached, err := q.FindSummaryCache(ctx, key)
+	switch err {
+	case nil:
+		slog.Debug("partial summary cache hit", "kind", c.kind, "title", c.title)
+		return cached, nil
+	case pgx.ErrNoRows:
+		break
+	default:
+		return "", fmt.Errorf("failed to find cached summary with %w", err)
+	}
+
+	docs := make([]*ai.Document, len(batch))
+	for i, item := range batch {
+		docs[i] = ai.DocumentFromText(item, map[string]any{})
+	}
+	resp, err := a.partialPrompt.Execute(
+		ctx,
+		ai.WithDocs(docs...),
+		ai.WithInput(map[string]any{
+			"kind":  string(c.kind),
+			"title": c.title,
+			"focus": focuses[c.kind],
+		}),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to call partial summary prompt with %w", err)
+	}
+
+	err = q.SaveSummaryCache(ctx, persist.SaveSummaryCacheParams{
+		Key:     key,
+		Summary: resp.Text(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to cache partial summary with %w", err)
+	}
+	return resp.Text(), nil
+}
+
+// planBatches splits items of the reduce level into batches, once summaries don't shrink
+// anymore all of them are squeezed into the single batch instead of dropping the rest
+func planBatches(items []string, budget, level int) [][]string {
+	batches := splitBatches(items, budget)
+	if level == 0 || len(batches) <= 1 {
+		return batches
+	}
+	if len(batches) < len(items) && level < maxReduceLevels {
+		return batches
+	}
+	return [][]string{squeeze(items, budget)}
+}
+
+// squeeze truncates every item to the equal share of the budget
+func squeeze(items []string, budget int) []string {
+	share := max(budget/len(items), 1)
+	squeezed := make([]string, len(items))
+	for i, item := range items {
+		squeezed[i] = truncateTokens(item, share)
+	}
+	return squeezed
+}
+
+// splitBatches groups items into batches which fit the token budget,
+// items exceeding the budget on their own are truncated
+func splitBatches(items []string, budget int) (batches [][]string) {
+	var batch []string
+	var batchTokens int
+	for _, item := range items {
+		item = truncateTokens(item, budget)
+		tokens := estimateTokens(item)
+		if len(batch) > 0 && batchTokens+tokens > budget {
+			batches = append(batches, batch)
+			batch = nil
+			batchTokens = 0
+		}
+		batch = append(batch, item)
+		batchTokens += tokens
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+func estimateTokens(s string) int {
+	return utf8.RuneCountInString(s)/charsPerToken + 1
+}
+
+func truncateTokens(s string, budget int) string {
+	if estimateTokens(s) <= budget {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:(budget-1)*charsPerToken])
+}
+
+// cacheKey identifies partial summary by its input
+func cacheKey(kind chunkKind, title string, items []string) string {
+	h := sha256.New()
+	for _, part := range append([]string{string(kind), title, focuses[kind]}, items...) {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,84 @@
+package summary
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitBatches(t *testing.T) {
+	item := strings.Repeat("a", 3*charsPerToken)
+	items := []string{item, item, item, strings.Repeat("b", 100*charsPerToken)}
+
+	batches := splitBatches(items, 10)
+	if len(batches) != 3 {
+		t.Fatalf("expected 3 batches, got %d", len(batches))
+	}
+	for _, batch := range batches {
+		var tokens int
+		for _, item := range batch {
+			tokens += estimateTokens(item)
+		}
+		if tokens > 10 {
+			t.Errorf("batch %#v exceeds the budget with %d tokens", batch, tokens)
+		}
+	}
+	if len(batches[0]) != 2 {
+		t.Errorf("expected the first batch to contain 2 items, got %d", len(batches[0]))
+	}
+}
+
+func TestPlanBatches(t *testing.T) {
+	if batches := planBatches(nil, 10, 1); len(batches) != 0 {
+		t.Errorf("expected no batches for empty items, got %#v", batches)
+	}
+
+	// Summaries which don't shrink are squeezed keeping every one of them
+	item := strings.Repeat("a", 8*charsPerToken)
+	items := []string{item, item, item}
+	if batches := planBatches(items, 10, 0); len(batches) != 3 {
+		t.Errorf("expected 3 batches on the map level, got %d", len(batches))
+	}
+	for _, level := range []int{1, maxReduceLevels} {
+		batches := planBatches(items, 10, level)
+		if len(batches) != 1 || len(batches[0]) != len(items) {
+			t.Fatalf("expected single batch of %d items on level %d, got %#v", len(items), level, batches)
+		}
+		var tokens int
+		for _, item := range batches[0] {
+			tokens += estimateTokens(item)
+		}
+		if tokens > 10 {
+			t.Errorf("squeezed batch exceeds the budget with %d tokens", tokens)
+		}
+	}
+
+	// Shrinking summaries are reduced further until the last level
+	small := strings.Repeat("a", 3*charsPerToken)
+	items = []string{small, small, small, small}
+	if batches := planBatches(items, 10, 1); len(batches) != 2 {
+		t.Errorf("expected 2 batches, got %d", len(batches))
+	}
+	if batches := planBatches(items, 10, maxReduceLevels); len(batches) != 1 || len(batches[0]) != 4 {
+		t.Errorf("expected single batch of 4 items on the last level, got %#v", batches)
+	}
+}
+
+func TestSummarizeChunk_Empty(t *testing.T) {
+	var a SummaryAgent
+	if _, err := a.summarizeChunk(t.Context(), chunk{kind: chunkGitHub, title: "empty"}); err == nil {
+		t.Error("expected empty chunk to fail")
+	}
+}
+
+func TestSplitHunks(t *testing.T) {
+	diff := "diff --git a/foo.md b/foo.md\n--- a/foo.md\n+++ b/foo.md\n@@ -1 +1 @@\n-a\n+b\n@@ -10 +10 @@\n-c\n+d\n"
+	expected := []string{
+		"diff --git a/foo.md b/foo.md\n--- a/foo.md\n+++ b/foo.md",
+		"@@ -1 +1 @@\n-a\n+b",
+		"@@ -10 +10 @@\n-c\n+d\n",
+	}
+	if hunks := splitHunks(diff); !slices.Equal(hunks, expected) {
+		t.Errorf("expected %#v, got %#v", expected, hunks)
+	}
+}
@@ -0,0 +1,89 @@
+package summary
+
+import (
+	"fmt"
+	"time"
+)
+
+// Period is a half-open time range [Since, Until)
+type Period struct {
+	Since time.Time
+	Until time.Time
+}
+
+// String formats period with exact dates for the report header.
+// Day aligned periods are printed as inclusive dates ranges
+func (p Period) String() string {
+	loc := p.Since.Location()
+	since, until := p.Since, p.Until.In(loc)
+	if isMidnight(since) && isMidnight(until) {
+		// Show the last included day instead of the next one
+		until = until.Add(-time.Nanosecond)
+		if since.YearDay() == until.YearDay() && since.Year() == until.Year() {
+			return fmt.Sprintf("%s (%s)", since.Format(dateLayout), loc)
+		}
+		return fmt.Sprintf("%s – %s (%s)", since.Format(dateLayout), until.Format(dateLayout), loc)
+	}
+	return fmt.Sprintf("%s – %s (%s)", since.Format(dateTimeLayout), until.Format(dateTimeLayout), loc)
+}
+
+const (
+	dateLayout     = "02.01.2006"
+	dateTimeLayout = "02.01.2006 15:04"
+)
+
+func isMidnight(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+// periodOutput is a structured output of the period extractor prompt
+type periodOutput struct {
+	Since  string `json:"since"`
+	Until  string `json:"until"`
+	Format string `json:"format"`
+}
+
+// resolve parses extracted timestamps in the `now`'s location
+// and ensures the period doesn't end in the future
+func (o periodOutput) resolve(now time.Time) (p Period, err error) {
+	p.Since, err = parseTimestamp(o.Since, now.Location())
+	if err != nil {
+		return p, fmt.Errorf("failed to parse period start with %w", err)
+	}
+	if o.Until == "" {
+		p.Until = now
+	} else {
+		p.Until, err = parseTimestamp(o.Until, now.Location())
+		if err != nil {
+			return p, fmt.Errorf("failed to parse period end with %w", err)
+		}
+	}
+
+	if p.Until.After(now) {
+		p.Until = now
+	}
+	if !p.Since.Before(p.Until) {
+		return p, fmt.Errorf("period start %s should be before its end %s", p.Since, p.Until)
+	}
+	p.Since = p.Since.In(now.Location())
+	p.Until = p.Until.In(now.Location())
+	return p, nil
+}
+
+var timestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	time.DateTime,
+	time.DateOnly,
+}
+
+func parseTimestamp(s string, loc *time.Location) (time.Time, error) {
+	for _, layout := range timestampLayouts {
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unexpected timestamp format '%s'", s)
+}
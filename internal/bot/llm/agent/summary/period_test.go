@@ -0,0 +1,80 @@
+package summary
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeriodOutput_Resolve(t *testing.T) {
+	loc := time.FixedZone("WITA", 8*60*60)
+	now := time.Date(2025, 9, 18, 15, 30, 0, 0, loc)
+
+	cases := []struct {
+		output   periodOutput
+		expected Period
+		header   string
+	}{
+		{
+			output: periodOutput{Since: "2025-09-17T00:00:00+08:00", Until: "2025-09-18T00:00:00+08:00"},
+			expected: Period{
+				Since: time.Date(2025, 9, 17, 0, 0, 0, 0, loc),
+				Until: time.Date(2025, 9, 18, 0, 0, 0, 0, loc),
+			},
+			header: "17.09.2025 (WITA)",
+		},
+		{
+			output: periodOutput{Since: "2025-09-01", Until: "2025-09-16"},
+			expected: Period{
+				Since: time.Date(2025, 9, 1, 0, 0, 0, 0, loc),
+				Until: time.Date(2025, 9, 16, 0, 0, 0, 0, loc),
+			},
+			header: "01.09.2025 – 15.09.2025 (WITA)",
+		},
+		{
+			// Future end is clamped to the current time
+			output: periodOutput{Since: "2025-09-15T00:00:00+08:00", Until: "2025-09-22T00:00:00+08:00"},
+			expected: Period{
+				Since: time.Date(2025, 9, 15, 0, 0, 0, 0, loc),
+				Until: now,
+			},
+			header: "15.09.2025 00:00 – 18.09.2025 15:30 (WITA)",
+		},
+		{
+			// UTC timestamps are converted into the local timezone
+			output: periodOutput{Since: "2025-09-15T07:30:00Z"},
+			expected: Period{
+				Since: time.Date(2025, 9, 15, 15, 30, 0, 0, loc),
+				Until: now,
+			},
+			header: "15.09.2025 15:30 – 18.09.2025 15:30 (WITA)",
+		},
+	}
+
+	for _, c := range cases {
+		got, err := c.output.resolve(now)
+		if err != nil {
+			t.Errorf("failed to resolve %#v with %s", c.output, err)
+			continue
+		}
+		if !got.Since.Equal(c.expected.Since) || !got.Until.Equal(c.expected.Until) {
+			t.Errorf("expected %s, got %s for %#v", c.expected, got, c.output)
+		}
+		if header := got.String(); header != c.header {
+			t.Errorf("expected header '%s', got '%s'", c.header, header)
+		}
+	}
+}
+
+func TestPeriodOutput_ResolveFails(t *testing.T) {
+	now := time.Date(2025, 9, 18, 15, 30, 0, 0, time.UTC)
+	outputs := []periodOutput{
+		{Since: "last week"},
+		{Since: "2025-09-18", Until: "2025-09-17"},
+		{Since: "2025-09-19"},
+	}
+	for _, output := range outputs {
+		if p, err := output.resolve(now); err == nil {
+			t.Errorf("expected %#v to fail, got %s", output, p)
+		}
+	}
+}
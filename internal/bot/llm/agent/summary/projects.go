@@ -0,0 +1,64 @@
+package summary
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"mimi/internal/persist"
+	"mimi/internal/provider/github/db"
+)
+
+// projectRole decides which section of the summary the project fills
+type projectRole string
+
+const (
+	roleTasks     projectRole = "tasks"
+	roleSupply    projectRole = "supply"
+	roleInventory projectRole = "inventory"
+)
+
+type summaryProject struct {
+	db.ProjectInfo
+	role projectRole
+}
+
+// discoverProjects lists organization's projects and selects ones
+// matching the configured rules
+func (a SummaryAgent) discoverProjects(ctx context.Context) ([]summaryProject, error) {
+	rules, err := persist.New(a.pgPool).FindGitHubProjectRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find GitHub project rules with %w", err)
+	}
+	projects, err := a.ghClient.ListProjects(ctx, a.ghOrg)
+	if err != nil {
+		return nil, err
+	}
+	return selectProjects(projects, rules)
+}
+
+// selectProjects applies the first matching rule to each open project,
+// projects without matching rules are skipped
+func selectProjects(projects []db.ProjectInfo, rules []persist.GithubProjectRule) (selected []summaryProject, _ error) {
+	for _, p := range projects {
+		if p.Closed {
+			continue
+		}
+		title := strings.ToLower(strings.TrimSpace(p.Title))
+		for _, rule := range rules {
+			ok, err := path.Match(strings.ToLower(rule.Pattern), title)
+			if err != nil {
+				return nil, fmt.Errorf("failed to match GitHub project rule %d with %w", rule.Position, err)
+			}
+			if !ok {
+				continue
+			}
+			if rule.Include {
+				selected = append(selected, summaryProject{ProjectInfo: p, role: projectRole(rule.Role)})
+			}
+			break
+		}
+	}
+	return selected, nil
+}
@@ -0,0 +1,43 @@
+package summary
+
+import (
+	"testing"
+
+	"mimi/internal/persist"
+	"mimi/internal/provider/github/db"
+)
+
+func TestSelectProjects(t *testing.T) {
+	projects := []db.ProjectInfo{
+		{Id: 2, Title: "Rockets"},
+		{Id: 3, Title: "Supply"},
+		{Id: 24, Title: "inventory"},
+		{Id: 33, Title: "devops force"},
+		{Id: 40, Title: "Archive 2024"},
+		{Id: 41, Title: "old board", Closed: true},
+	}
+	rules := []persist.GithubProjectRule{
+		{Position: 10, Pattern: "supply", Include: true, Role: "supply"},
+		{Position: 20, Pattern: "inventory", Include: true, Role: "inventory"},
+		{Position: 25, Pattern: "archive*", Include: false, Role: "tasks"},
+		{Position: 30, Pattern: "*", Include: true, Role: "tasks"},
+	}
+	selected, err := selectProjects(projects, rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[int]projectRole{2: roleTasks, 3: roleSupply, 24: roleInventory, 33: roleTasks}
+	if len(selected) != len(expected) {
+		t.Fatalf("expected %d projects, got %#v", len(expected), selected)
+	}
+	for _, p := range selected {
+		if expected[p.Id] != p.role {
+			t.Fatalf("expected role '%s' for %s, got '%s'", expected[p.Id], p.Title, p.role)
+		}
+	}
+
+	_, err = selectProjects(projects, []persist.GithubProjectRule{{Pattern: "[", Include: true}})
+	if err == nil {
+		t.Fatal("expected error for malformed pattern")
+	}
+}
@@ -0,0 +1,110 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"mimi/internal/persist"
+)
+
+// ReportItems contains identifiers of the summarized items
+// with their states grouped by the data source
+type ReportItems map[string]map[string]string
+
+// ItemsDiff describes how summarized items changed between two reports
+type ItemsDiff struct {
+	// Items which are absent in the previous report
+	Added map[string][]string `json:"added,omitempty"`
+	// Items which are absent in the current report
+	Removed map[string][]string `json:"removed,omitempty"`
+	// Items which states differ between reports
+	Changed map[string][]StateChange `json:"changed,omitempty"`
+}
+
+type StateChange struct {
+	ID   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Diff compares items of the current report with the `prev` one
+func (i ReportItems) Diff(prev ReportItems) ItemsDiff {
+	diff := ItemsDiff{
+		Added:   make(map[string][]string),
+		Removed: make(map[string][]string),
+		Changed: make(map[string][]StateChange),
+	}
+	sources := slices.Sorted(maps.Keys(i))
+	for source := range maps.Keys(prev) {
+		if _, ok := i[source]; !ok {
+			sources = append(sources, source)
+		}
+	}
+
+	for _, source := range sources {
+		cur, old := i[source], prev[source]
+		for _, id := range slices.Sorted(maps.Keys(cur)) {
+			oldState, ok := old[id]
+			switch {
+			case !ok:
+				diff.Added[source] = append(diff.Added[source], id)
+			case oldState != cur[id]:
+				diff.Changed[source] = append(diff.Changed[source], StateChange{
+					ID:   id,
+					From: oldState,
+					To:   cur[id],
+				})
+			}
+		}
+		for _, id := range slices.Sorted(maps.Keys(old)) {
+			if _, ok := cur[id]; !ok {
+				diff.Removed[source] = append(diff.Removed[source], id)
+			}
+		}
+	}
+	return diff
+}
+
+// reportItems groups identifiers of the chunks by the data source,
+// sources without any data are skipped
+func reportItems(chunks []chunk) ReportItems {
+	items := make(ReportItems)
+	for _, c := range chunks {
+		if len(c.items) == 0 {
+			continue
+		}
+		source := string(c.kind)
+		if _, ok := items[source]; !ok {
+			items[source] = make(map[string]string)
+		}
+		maps.Copy(items[source], c.ids)
+	}
+	return items
+}
+
+// saveReport persists generated summary to the archive
+func (a SummaryAgent) saveReport(ctx context.Context, period Period, chunks []chunk, report string) (int32, error) {
+	items := reportItems(chunks)
+	blob, err := json.Marshal(items)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal report items with %w", err)
+	}
+
+	q := persist.New(a.pgPool)
+	id, err := q.SaveSummaryReport(ctx, persist.SaveSummaryReportParams{
+		Since:   pgtype.Timestamptz{Time: period.Since, Valid: true},
+		Until:   pgtype.Timestamptz{Time: period.Until, Valid: true},
+		Sources: slices.Sorted(maps.Keys(items)),
+		Items:   blob,
+		Report:  report,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to save summary report with %w", err)
+	}
+	return id, nil
+}
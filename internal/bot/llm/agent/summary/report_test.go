@@ -0,0 +1,59 @@
+package summary
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReportItems_Diff(t *testing.T) {
+	prev := ReportItems{
+		"github": {
+			"https://github.com/cyber-valley/cvland/issues/1": "OPEN: In Progress",
+			"https://github.com/cyber-valley/cvland/issues/2": "OPEN: Todo",
+		},
+		"logseq": {"pages/foo.md": "changed"},
+	}
+	cur := ReportItems{
+		"github": {
+			"https://github.com/cyber-valley/cvland/issues/1": "CLOSED: Done",
+			"https://github.com/cyber-valley/cvland/issues/3": "OPEN: Todo",
+		},
+		"telegram": {"-1001:42": "Cyber Valley / rockets"},
+	}
+
+	expected := ItemsDiff{
+		Added: map[string][]string{
+			"github":   {"https://github.com/cyber-valley/cvland/issues/3"},
+			"telegram": {"-1001:42"},
+		},
+		Removed: map[string][]string{
+			"github": {"https://github.com/cyber-valley/cvland/issues/2"},
+			"logseq": {"pages/foo.md"},
+		},
+		Changed: map[string][]StateChange{
+			"github": {{
+				ID:   "https://github.com/cyber-valley/cvland/issues/1",
+				From: "OPEN: In Progress",
+				To:   "CLOSED: Done",
+			}},
+		},
+	}
+	if diff := cur.Diff(prev); !reflect.DeepEqual(diff, expected) {
+		t.Errorf("expected %#v, got %#v", expected, diff)
+	}
+}
+
+func TestReportItems(t *testing.T) {
+	chunks := []chunk{
+		{kind: chunkGitHub, title: "empty", ids: map[string]string{}},
+		{kind: chunkLogseq, title: "pages/foo.md", items: []string{"@@ -1 +1 @@"}, ids: map[string]string{"pages/foo.md": "changed"}},
+		{kind: chunkTelegram, title: "rockets", items: []string{"a", "b"}, ids: map[string]string{"-1001:1": "rockets", "-1001:2": "rockets"}},
+	}
+	expected := ReportItems{
+		"logseq":   {"pages/foo.md": "changed"},
+		"telegram": {"-1001:1": "rockets", "-1001:2": "rockets"},
+	}
+	if items := reportItems(chunks); !reflect.DeepEqual(items, expected) {
+		t.Errorf("expected %#v, got %#v", expected, items)
+	}
+}
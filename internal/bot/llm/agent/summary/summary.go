@@ -1,12 +1,15 @@
 package summary
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"log/slog"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -17,33 +20,35 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"mimi/internal/bot/llm/agent"
+	"mimi/internal/bot/llm/agent/summary/export"
+	"mimi/internal/config"
 	"mimi/internal/persist"
 	"mimi/internal/provider/git"
 	"mimi/internal/provider/github/db"
+	"mimi/internal/provider/x"
 )
 
 const (
-	evalPrompt   = "summary"
-	periodPrompt = "period-extractor"
+	evalPrompt    = "summary"
+	periodPrompt  = "period-extractor"
+	partialPrompt = "summary-partial"
 )
 
-var githubProjects = map[string]int{
-	"rockets":      2,
-	"supply":       3,
-	"inventory":    24,
-	"devops force": 33,
-}
-
 type SummaryAgent struct {
 	evalPrompt      *ai.Prompt
 	periodExtractor *ai.Prompt
+	partialPrompt   *ai.Prompt
 	ghClient        *db.Client
 	ghOrg           string
 	pgPool          *pgxpool.Pool
 	logseqRepoPath  string
+	loc             *time.Location
+	// Summarized X accounts, other authors' tweets are only quoted or retweeted by them
+	xAccounts []string
 }
 
-func New(g *genkit.Genkit, pgPool *pgxpool.Pool, ghOrg, logseqRepoPath string) SummaryAgent {
+// New creates the agent, `loc` is used to resolve dates in user's queries
+func New(g *genkit.Genkit, pgPool *pgxpool.Pool, ghOrg, logseqRepoPath string, loc *time.Location) SummaryAgent {
 	// Fail fast if prompt wasn't found
 	eval := genkit.LookupPrompt(g, evalPrompt)
 	if eval == nil {
@@ -55,127 +60,258 @@ func New(g *genkit.Genkit, pgPool *pgxpool.Pool, ghOrg, logseqRepoPath string) S
 		log.Fatalf("no prompt named '%s' found", periodPrompt)
 	}
 
+	partial := genkit.LookupPrompt(g, partialPrompt)
+	if partial == nil {
+		log.Fatalf("no prompt named '%s' found", partialPrompt)
+	}
+
+	xAccounts := config.XAccounts()
+	if len(xAccounts) == 0 {
+		slog.Warn("X posts won't be summarized without accounts", "env", config.XAccountsEnv)
+	}
+
 	return SummaryAgent{
 		pgPool:          pgPool,
 		ghClient:        db.New("https://api.github.com/graphql"),
 		ghOrg:           ghOrg,
 		evalPrompt:      eval,
 		periodExtractor: periodExtractor,
+		partialPrompt:   partial,
 		logseqRepoPath:  logseqRepoPath,
+		loc:             loc,
+		xAccounts:       xAccounts,
 	}
 }
 
 func (a SummaryAgent) GetInfo() agent.Info {
 	return agent.Info{
 		Name:        "summary",
-		Description: `Provides overall summary across all available resources`,
+		Description: `Provides overall summary across all available resources, optionally as PDF, HTML or Markdown document`,
 	}
 }
 
 func (a SummaryAgent) Run(ctx context.Context, query string, msgs ...*ai.Message) (agent.Response, error) {
 	var result agent.Response
-	resp, err := a.periodExtractor.Execute(ctx, ai.WithInput(map[string]any{"query": query}))
+	now := time.Now().In(a.loc)
+	flagFormat, query, hasFlag := cutFormatFlag(query)
+	resp, err := a.periodExtractor.Execute(ctx, ai.WithInput(map[string]any{
+		"query":    query,
+		"now":      now.Format(time.RFC3339),
+		"timezone": a.loc.String(),
+	}))
 	if err != nil {
 		return result, fmt.Errorf("failed to extract period from query '%s' with %w", query, err)
 	}
-	period := strings.TrimSuffix(resp.Text(), "\n")
-	slog.Info("generating summary", "period", period)
-
-	since := time.Now()
-	switch period {
-	default:
-		return result, fmt.Errorf("unexpected period '%s'", period)
-	case "month":
-		since = since.AddDate(0, 0, -30)
-	case "week":
-		since = since.AddDate(0, 0, -7)
-	case "day":
-		since = since.AddDate(0, 0, -1)
+	var extracted periodOutput
+	if err := resp.Output(&extracted); err != nil {
+		return result, fmt.Errorf("failed to parse extracted period '%s' with %w", resp.Text(), err)
 	}
+	period, err := extracted.resolve(now)
+	if err != nil {
+		return result, fmt.Errorf("failed to resolve extracted period %#v with %w", extracted, err)
+	}
+	if hasFlag {
+		// Explicit flag takes precedence over the extracted format
+		extracted.Format = flagFormat
+	}
+	format, err := export.ParseFormat(extracted.Format)
+	if err != nil {
+		return result, err
+	}
+	slog.Info("generating summary", "since", period.Since, "until", period.Until, "format", format)
 
-	docChan := make(chan *ai.Document, 3)
-	errChan := make(chan error, 3)
+	chunkChan := make(chan []chunk, 4)
+	errChan := make(chan error, 4)
 	var wg sync.WaitGroup
-	wg.Add(3)
+	wg.Add(4)
 	startT := time.Now()
 
 	// Retrieve GitHub projects statuses
 	go func() {
 		defer wg.Done()
-		type msg struct {
-			project string
-			issues  []db.Issue
+
+		projects, err := a.discoverProjects(ctx)
+		if err != nil {
+			errChan <- fmt.Errorf("failed to discover GitHub projects with %w", err)
+			return
 		}
+		slog.Info("discovered GitHub projects", "length", len(projects))
 
 		var projWg sync.WaitGroup
-		issueChan := make(chan msg, len(githubProjects))
+		projChan := make(chan chunk, len(projects))
+		projErrChan := make(chan error, len(projects))
 
 		// Fetch issues for each project
-		for title, projID := range githubProjects {
+		for _, p := range projects {
 			projWg.Add(1)
 			go func() {
 				defer projWg.Done()
-				tmp, err := a.ghClient.GetOrgProject(ctx, a.ghOrg, projID, since)
+				tmp, err := a.ghClient.GetOrgProject(ctx, a.ghOrg, p.Id, period.Since, period.Until)
 				if err != nil {
-					errChan <- fmt.Errorf("failed to fetch supply board state with %w", err)
+					projErrChan <- fmt.Errorf("failed to fetch '%s' board state with %w", p.Title, err)
 					return
 				}
-				slog.Info("fetched GitHub issues", "project", title, "lenght", len(tmp), "value", tmp)
-				issueChan <- msg{project: title, issues: tmp}
+				slog.Info("fetched GitHub issues", "project", p.Title, "role", p.role, "lenght", len(tmp))
+
+				c := chunk{
+					kind:  chunkGitHub,
+					title: p.Title,
+					role:  p.role,
+					url:   p.URL,
+					ids:   make(map[string]string),
+				}
+				for _, issue := range tmp {
+					blob, err := json.Marshal(issue.Brief())
+					if err != nil {
+						projErrChan <- fmt.Errorf("failed to marshal GitHub issue '%s' with %w", issue.URL, err)
+						return
+					}
+					c.items = append(c.items, string(blob))
+					c.ids[issueID(issue)] = fmt.Sprintf("%s: %s", issue.State, issue.Status)
+				}
+				projChan <- c
 			}()
 		}
 
 		// Wait for the fetched issues
 		projWg.Wait()
-		close(issueChan)
+		close(projChan)
+		close(projErrChan)
 
-		// Send retrieved project issues
-		issues := make(map[string][]db.Issue)
-		for msg := range issueChan {
-			issues[msg.project] = msg.issues
+		var errs []error
+		for err := range projErrChan {
+			errs = append(errs, err)
 		}
-		blob, err := json.Marshal(issues)
-		if err != nil {
-			errChan <- fmt.Errorf("failed to marshal GitHub projects info with %w", err)
+		if len(errs) > 0 {
+			errChan <- errors.Join(errs...)
 			return
 		}
-		docChan <- ai.DocumentFromText(string(blob), map[string]any{"info": "GitHub projects issues"})
+
+		var chunks []chunk
+		for c := range projChan {
+			chunks = append(chunks, c)
+		}
+		chunkChan <- chunks
 	}()
 
 	// Retrieve Telegram info
 	go func() {
 		defer wg.Done()
 		q := persist.New(a.pgPool)
-		messages, err := q.FindTelegramMessages(ctx, pgtype.Timestamptz{Time: since, Valid: true})
+		messages, err := q.FindTelegramMessages(ctx, persist.FindTelegramMessagesParams{
+			Since: pgtype.Timestamptz{Time: period.Since, Valid: true},
+			Until: pgtype.Timestamptz{Time: period.Until, Valid: true},
+		})
 		if err != nil {
 			errChan <- fmt.Errorf("failed to retrieve Telegram message from DB with %w", err)
 			return
 		}
 		slog.Info("retrieved Telegram messages", "length", len(messages))
-		blob, err := json.Marshal(messages)
-		if err != nil {
-			errChan <- fmt.Errorf("failed to marshal Telegram messages with %w", err)
-			return
+
+		// Group messages by chat and topic
+		var chunks []chunk
+		chunkIdx := make(map[string]int)
+		for _, m := range messages {
+			title := m.ChatName
+			if m.TopicTitle.Valid {
+				title = fmt.Sprintf("%s / %s", m.ChatName, m.TopicTitle.String)
+			}
+			idx, ok := chunkIdx[title]
+			if !ok {
+				idx = len(chunks)
+				chunkIdx[title] = idx
+				chunks = append(chunks, chunk{
+					kind:  chunkTelegram,
+					title: title,
+					// Messages are ordered, so link the first one in the period
+					url: telegramURL(m.PeerID, m.ID),
+					ids: make(map[string]string),
+				})
+			}
+			text := fmt.Sprintf("[%s] %s", m.CreatedAt.Time.In(a.loc).Format(time.DateTime), m.Message)
+			chunks[idx].items = append(chunks[idx].items, text)
+			chunks[idx].ids[fmt.Sprintf("%d:%d", m.PeerID, m.ID)] = title
 		}
-		docChan <- ai.DocumentFromText(string(blob), map[string]any{"info": "Related telegram messages"})
+		chunkChan <- chunks
 	}()
 
 	// Retrieve LogSeq diff
 	go func() {
 		defer wg.Done()
-		diff, err := git.DiffInterval(a.logseqRepoPath, since)
+		diff, err := git.DiffInterval(a.logseqRepoPath, period.Since, period.Until)
 		if err != nil {
 			errChan <- err
 			return
 		}
 		slog.Info("retrieved LogSeq diff", "length", len(diff))
-		docChan <- ai.DocumentFromText(diff, map[string]any{"info": "LogSeq git diff"})
+
+		repoURL, err := git.WebURL(a.logseqRepoPath)
+		if err != nil {
+			// Links are optional, the summary is still useful without them
+			slog.Warn("failed to get LogSeq repository URL", "with", err)
+		}
+
+		// Summarize each changed page separately
+		var chunks []chunk
+		for _, file := range git.SplitDiff(diff) {
+			chunks = append(chunks, chunk{
+				kind:  chunkLogseq,
+				title: file.Path,
+				url:   logseqURL(repoURL, file.Path),
+				items: splitHunks(file.Diff),
+				ids:   map[string]string{file.Path: "changed"},
+			})
+		}
+		chunkChan <- chunks
+	}()
+
+	// Retrieve X posts
+	go func() {
+		defer wg.Done()
+		q := persist.New(a.pgPool)
+		var tweets []persist.XTweet
+		for _, account := range a.xAccounts {
+			found, err := q.FindXTweets(ctx, persist.FindXTweetsParams{
+				Author: pgtype.Text{String: account, Valid: true},
+				Since:  pgtype.Timestamptz{Time: period.Since, Valid: true},
+				Until:  pgtype.Timestamptz{Time: period.Until, Valid: true},
+			})
+			if err != nil {
+				errChan <- fmt.Errorf("failed to retrieve @%s X posts from DB with %w", account, err)
+				return
+			}
+			tweets = append(tweets, found...)
+		}
+		slog.Info("retrieved X posts", "accounts", a.xAccounts, "length", len(tweets))
+
+		// Group posts by author
+		var chunks []chunk
+		chunkIdx := make(map[string]int)
+		for _, t := range tweets {
+			title := "@" + t.AuthorScreenName
+			idx, ok := chunkIdx[title]
+			if !ok {
+				idx = len(chunks)
+				chunkIdx[title] = idx
+				chunks = append(chunks, chunk{
+					kind:  chunkX,
+					title: title,
+					url:   "https://x.com/" + t.AuthorScreenName,
+					ids:   make(map[string]string),
+				})
+			}
+			url := x.TweetURL(t.AuthorScreenName, t.ID)
+			text := fmt.Sprintf("[%s] %s\n%s", t.CreatedAt.Time.In(a.loc).Format(time.DateTime), url, t.Text)
+			chunks[idx].items = append(chunks[idx].items, text)
+			chunks[idx].ids[strconv.FormatInt(t.ID, 10)] = title
+		}
+		chunkChan <- chunks
 	}()
 
 	wg.Wait()
 	slog.Info("summary data retrieved", "elapsed", time.Since(startT))
 	close(errChan)
-	close(docChan)
+	close(chunkChan)
 
 	// Process errors
 	var errs []error
@@ -186,17 +322,69 @@ func (a SummaryAgent) Run(ctx context.Context, query string, msgs ...*ai.Message
 		return result, fmt.Errorf("failed to retrieve data for summary with %w", errors.Join(errs...))
 	}
 
-	// Call LLM
-	var docs []*ai.Document
-	for doc := range docChan {
-		docs = append(docs, doc)
+	// Map step
+	var chunks []chunk
+	for c := range chunkChan {
+		chunks = append(chunks, c...)
 	}
+	slices.SortFunc(chunks, func(lhs, rhs chunk) int {
+		return cmp.Or(cmp.Compare(lhs.kind, rhs.kind), cmp.Compare(lhs.title, rhs.title))
+	})
+	startT = time.Now()
+	nonEmpty := slices.DeleteFunc(slices.Clone(chunks), func(c chunk) bool {
+		return len(c.items) == 0
+	})
+	partials, err := a.summarizeChunks(ctx, nonEmpty)
+	if err != nil {
+		return result, fmt.Errorf("failed to summarize data for summary with %w", err)
+	}
+	slog.Info("partial summaries generated", "length", len(partials), "elapsed", time.Since(startT))
 
-	resp, err = a.evalPrompt.Execute(ctx, ai.WithDocs(docs...), ai.WithInput(map[string]any{"period": period}))
+	// Reduce step
+	docs := make([]*ai.Document, len(partials))
+	for i, partial := range partials {
+		docs[i] = partial.document()
+	}
+
+	resp, err = a.evalPrompt.Execute(ctx, ai.WithDocs(docs...), ai.WithInput(map[string]any{"period": period.String()}))
 	if err != nil {
 		return result, err
 	}
 	slog.Info("generated summary", "text", resp.Text())
-	result = agent.NewResponse(agent.DataText{Text: resp.Text()}, resp)
-	return result, nil
+
+	// Generated summary is returned even if it wasn't archived
+	if id, err := a.saveReport(ctx, period, chunks, resp.Text()); err != nil {
+		slog.Error("failed to archive summary report", "with", err)
+	} else {
+		slog.Info("summary report archived", "id", id)
+	}
+
+	if format == export.FormatText {
+		return agent.NewResponse(agent.DataText{Text: resp.Text()}, resp), nil
+	}
+	blob, err := export.Render(newDocument(period, resp.Text(), partials), format)
+	if err != nil {
+		return result, fmt.Errorf("failed to export summary as %s with %w", format, err)
+	}
+	file := agent.DataFile{Blob: blob, Name: documentName(period, format)}
+	return agent.NewResponse(file, resp), nil
+}
+
+// splitHunks splits single file diff into hunks keeping the file header in the first one
+func splitHunks(diff string) []string {
+	hunks := strings.SplitAfter(diff, "\n@@")
+	for i := 0; i < len(hunks)-1; i++ {
+		hunks[i] = strings.TrimSuffix(hunks[i], "\n@@")
+		hunks[i+1] = "@@" + hunks[i+1]
+	}
+	return hunks
+}
+
+// issueID identifies project item in the summary report
+func issueID(issue db.Issue) string {
+	if issue.URL == "" {
+		// Draft issues have no URL
+		return fmt.Sprintf("%s: %s", issue.Type, issue.Title)
+	}
+	return issue.URL
 }
@@ -0,0 +1,176 @@
+package summaryarchive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mimi/internal/bot/llm/agent"
+	"mimi/internal/bot/llm/agent/summary"
+	"mimi/internal/persist"
+)
+
+const (
+	selectPrompt  = "summary-archive-select"
+	comparePrompt = "summary-archive-compare"
+	// Amount of the latest reports to choose from
+	reportsLimit = 50
+)
+
+type SummaryArchiveAgent struct {
+	pgPool        *pgxpool.Pool
+	selectPrompt  *ai.Prompt
+	comparePrompt *ai.Prompt
+}
+
+func New(g *genkit.Genkit, pgPool *pgxpool.Pool) SummaryArchiveAgent {
+	// Fail fast if prompt wasn't found
+	selectReports := genkit.LookupPrompt(g, selectPrompt)
+	if selectReports == nil {
+		log.Fatalf("no prompt named '%s' found", selectPrompt)
+	}
+	compare := genkit.LookupPrompt(g, comparePrompt)
+	if compare == nil {
+		log.Fatalf("no prompt named '%s' found", comparePrompt)
+	}
+
+	return SummaryArchiveAgent{
+		pgPool:        pgPool,
+		selectPrompt:  selectReports,
+		comparePrompt: compare,
+	}
+}
+
+func (a SummaryArchiveAgent) GetInfo() agent.Info {
+	return agent.Info{
+		Name: "summary-archive",
+		Description: `Has access to previously generated summary reports.
+		Shows old reports (e.g. "show last week's report") and describes what changed between them
+		(e.g. "what changed compared with the previous weekly summary")`,
+	}
+}
+
+func (a SummaryArchiveAgent) Run(ctx context.Context, query string, msgs ...*ai.Message) (agent.Response, error) {
+	var result agent.Response
+	q := persist.New(a.pgPool)
+
+	// List available reports
+	reports, err := q.FindSummaryReports(ctx, reportsLimit)
+	if err != nil {
+		return result, fmt.Errorf("failed to find summary reports with %w", err)
+	}
+	if len(reports) == 0 {
+		return agent.NewResponse(agent.DataText{Text: "There are no archived summary reports yet"}, nil), nil
+	}
+	docs := make([]*ai.Document, len(reports))
+	for i, report := range reports {
+		blob, err := json.Marshal(reportInfo{
+			ID:        report.ID,
+			Since:     report.Since.Time,
+			Until:     report.Until.Time,
+			Sources:   report.Sources,
+			CreatedAt: report.CreatedAt.Time,
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to marshal report info with %w", err)
+		}
+		docs[i] = ai.DocumentFromText(string(blob), map[string]any{})
+	}
+
+	// Find out requested reports
+	resp, err := a.selectPrompt.Execute(
+		ctx,
+		ai.WithDocs(docs...),
+		ai.WithMessages(msgs...),
+		ai.WithInput(map[string]any{
+			"query": query,
+			"now":   time.Now().Format(time.RFC3339),
+		}),
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to select summary reports with %w", err)
+	}
+	var selected selectOutput
+	if err := resp.Output(&selected); err != nil {
+		return result, fmt.Errorf("failed to parse selected reports '%s' with %w", resp.Text(), err)
+	}
+	slog.Info("selected summary reports", "value", selected)
+
+	current, err := findReport(ctx, q, selected.ReportID)
+	if err != nil {
+		return result, err
+	}
+	if selected.CompareWithID == 0 {
+		// Just show the stored report
+		return agent.NewResponse(agent.DataText{Text: current.Report}, resp), nil
+	}
+	prev, err := findReport(ctx, q, selected.CompareWithID)
+	if err != nil {
+		return result, err
+	}
+	if prev.CreatedAt.Time.After(current.CreatedAt.Time) {
+		current, prev = prev, current
+	}
+
+	// Compare summarized items
+	var currentItems, prevItems summary.ReportItems
+	if err := json.Unmarshal(current.Items, &currentItems); err != nil {
+		return result, fmt.Errorf("failed to unmarshal items of report %d with %w", current.ID, err)
+	}
+	if err := json.Unmarshal(prev.Items, &prevItems); err != nil {
+		return result, fmt.Errorf("failed to unmarshal items of report %d with %w", prev.ID, err)
+	}
+	diff, err := json.Marshal(currentItems.Diff(prevItems))
+	if err != nil {
+		return result, fmt.Errorf("failed to marshal reports diff with %w", err)
+	}
+
+	resp, err = a.comparePrompt.Execute(
+		ctx,
+		ai.WithDocs(
+			ai.DocumentFromText(prev.Report, map[string]any{"report": "previous"}),
+			ai.DocumentFromText(current.Report, map[string]any{"report": "current"}),
+			ai.DocumentFromText(string(diff), map[string]any{"info": "summarized items diff"}),
+		),
+		ai.WithMessages(msgs...),
+		ai.WithInput(map[string]any{"query": query}),
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to compare summary reports with %w", err)
+	}
+	result = agent.NewResponse(agent.DataText{Text: resp.Text()}, resp)
+	return result, nil
+}
+
+func findReport(ctx context.Context, q *persist.Queries, id int32) (persist.SummaryReport, error) {
+	report, err := q.FindSummaryReport(ctx, id)
+	switch err {
+	case nil:
+		return report, nil
+	case pgx.ErrNoRows:
+		return report, fmt.Errorf("summary report %d not found", id)
+	default:
+		return report, fmt.Errorf("failed to find summary report %d with %w", id, err)
+	}
+}
+
+type reportInfo struct {
+	ID        int32     `json:"id"`
+	Since     time.Time `json:"since"`
+	Until     time.Time `json:"until"`
+	Sources   []string  `json:"sources"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type selectOutput struct {
+	ReportID      int32 `json:"reportId"`
+	CompareWithID int32 `json:"compareWithId"`
+}
@@ -0,0 +1,220 @@
+package x
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/genkit"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"mimi/internal/bot/llm/agent"
+	"mimi/internal/persist"
+	xprovider "mimi/internal/provider/x"
+)
+
+const (
+	searchPrompt = "x-search"
+	evalPrompt   = "x-eval"
+	// Upper bound of tweets passed to the answering prompt
+	maxTweets = 100
+)
+
+type XAgent struct {
+	pgPool       *pgxpool.Pool
+	searchPrompt *ai.Prompt
+	evalPrompt   *ai.Prompt
+	loc          *time.Location
+}
+
+// New creates the agent, `loc` is used to resolve dates in user's queries
+func New(g *genkit.Genkit, pgPool *pgxpool.Pool, loc *time.Location) XAgent {
+	// Fail fast if prompt wasn't found
+	search := genkit.LookupPrompt(g, searchPrompt)
+	if search == nil {
+		log.Fatalf("no prompt named '%s' found", searchPrompt)
+	}
+	eval := genkit.LookupPrompt(g, evalPrompt)
+	if eval == nil {
+		log.Fatalf("no prompt named '%s' found", evalPrompt)
+	}
+
+	return XAgent{
+		pgPool:       pgPool,
+		searchPrompt: search,
+		evalPrompt:   eval,
+		loc:          loc,
+	}
+}
+
+func (a XAgent) GetInfo() agent.Info {
+	return agent.Info{
+		Name:        "x",
+		Description: `Has access to imported X (Twitter) posts of Cyber Valley people like mastercyb and answers what they have posted about some topic`,
+	}
+}
+
+func (a XAgent) Run(ctx context.Context, query string, msgs ...*ai.Message) (agent.Response, error) {
+	var result agent.Response
+	q := persist.New(a.pgPool)
+	authors, err := q.FindXAuthors(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to find X authors with %w", err)
+	}
+	var known []string
+	for _, author := range authors {
+		known = append(known, fmt.Sprintf("@%s (%s, %d)", author.AuthorScreenName, author.AuthorName, author.Tweets))
+	}
+
+	resp, err := a.searchPrompt.Execute(
+		ctx,
+		ai.WithMessages(msgs...),
+		ai.WithInput(map[string]any{
+			"query":   query,
+			"now":     time.Now().In(a.loc).Format(time.RFC3339),
+			"authors": strings.Join(known, ", "),
+		}),
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to extract X search parameters with %w", err)
+	}
+	var params searchParams
+	if err := resp.Output(&params); err != nil {
+		return result, fmt.Errorf("failed to parse X search parameters '%s' with %w", resp.Text(), err)
+	}
+	arg, err := params.query()
+	if err != nil {
+		return result, err
+	}
+
+	tweets, err := q.FindXTweets(ctx, arg)
+	if err != nil {
+		return result, fmt.Errorf("failed to find tweets with %w", err)
+	}
+	tweets = rank(tweets, params.Keywords, maxTweets)
+	slog.Info("found tweets", "author", params.Author, "keywords", params.Keywords, "length", len(tweets))
+
+	docs := make([]*ai.Document, len(tweets))
+	for i, t := range tweets {
+		docs[i] = ai.DocumentFromText(formatTweet(t), map[string]any{"url": xprovider.TweetURL(t.AuthorScreenName, t.ID)})
+	}
+	resp, err = a.evalPrompt.Execute(
+		ctx,
+		ai.WithMessages(msgs...),
+		ai.WithDocs(docs...),
+		ai.WithInput(map[string]any{"query": query}),
+	)
+	if err != nil {
+		return result, fmt.Errorf("failed to evaluate final step with %w", err)
+	}
+	result = agent.NewResponse(agent.DataText{Text: resp.Text()}, resp)
+	return result, nil
+}
+
+// searchParams is a structured output of the search prompt
+type searchParams struct {
+	Author   string   `json:"author"`
+	Keywords []string `json:"keywords"`
+	Since    string   `json:"since"`
+	Until    string   `json:"until"`
+}
+
+// query converts parameters into the DB query, missing period bounds are unlimited
+func (p searchParams) query() (persist.FindXTweetsParams, error) {
+	arg := persist.FindXTweetsParams{
+		Since: pgtype.Timestamptz{Time: time.Unix(0, 0), Valid: true},
+		Until: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	if author := strings.TrimPrefix(strings.TrimSpace(p.Author), "@"); author != "" {
+		arg.Author = pgtype.Text{String: author, Valid: true}
+	}
+	for _, bound := range []struct {
+		value string
+		dst   *pgtype.Timestamptz
+	}{{p.Since, &arg.Since}, {p.Until, &arg.Until}} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			return arg, fmt.Errorf("failed to parse search period with %w", err)
+		}
+		bound.dst.Time = t
+	}
+	return arg, nil
+}
+
+// rank orders tweets by the number of matched keywords preferring recent ones,
+// tweets without matches are dropped unless there are no keywords at all
+func rank(tweets []persist.XTweet, keywords []string, limit int) []persist.XTweet {
+	phrases := make([][]string, 0, len(keywords))
+	for _, k := range keywords {
+		if words := tokenize(k); len(words) > 0 {
+			phrases = append(phrases, words)
+		}
+	}
+	scores := make(map[int64]int, len(tweets))
+	var matched []persist.XTweet
+	for _, t := range tweets {
+		words := tokenize(t.Text)
+		for _, phrase := range phrases {
+			if containsPhrase(words, phrase) {
+				scores[t.ID]++
+			}
+		}
+		if len(keywords) == 0 || scores[t.ID] > 0 {
+			matched = append(matched, t)
+		}
+	}
+	slices.SortStableFunc(matched, func(lhs, rhs persist.XTweet) int {
+		return cmp.Or(
+			cmp.Compare(scores[rhs.ID], scores[lhs.ID]),
+			rhs.CreatedAt.Time.Compare(lhs.CreatedAt.Time),
+		)
+	})
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return matched
+}
+
+// tokenize splits text into lowercase words dropping punctuation, e.g. hashtag signs
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// containsPhrase checks that words of the phrase follow each other in the text
+func containsPhrase(words, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		if slices.Equal(words[i:i+len(phrase)], phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatTweet(t persist.XTweet) string {
+	var b strings.Builder
+	fmt.Fprintf(
+		&b,
+		"@%s [%s] %s\n",
+		t.AuthorScreenName,
+		t.CreatedAt.Time.UTC().Format(time.DateTime),
+		xprovider.TweetURL(t.AuthorScreenName, t.ID),
+	)
+	if t.InReplyToScreenName.Valid {
+		fmt.Fprintf(&b, "In reply to @%s\n", t.InReplyToScreenName.String)
+	}
+	b.WriteString(t.Text)
+	return b.String()
+}
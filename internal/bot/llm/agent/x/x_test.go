@@ -0,0 +1,77 @@
+package x
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"mimi/internal/persist"
+)
+
+func tweet(id int64, day int, text string) persist.XTweet {
+	return persist.XTweet{
+		ID:        id,
+		Text:      text,
+		CreatedAt: pgtype.Timestamptz{Time: time.Date(2025, 9, day, 0, 0, 0, 0, time.UTC), Valid: true},
+	}
+}
+
+func TestRank(t *testing.T) {
+	tweets := []persist.XTweet{
+		tweet(1, 1, "Ethereum will flip #bitcoin"),
+		tweet(2, 2, "Offline retreat results"),
+		tweet(3, 3, "ETH adoption"),
+		tweet(4, 4, "Bitcoin and Ethereum"),
+	}
+	ids := func(tweets []persist.XTweet) (res []int64) {
+		for _, t := range tweets {
+			res = append(res, t.ID)
+		}
+		return res
+	}
+
+	if got := ids(rank(tweets, []string{"ethereum", "ETH", "bitcoin"}, 10)); !slices.Equal(got, []int64{4, 1, 3}) {
+		t.Fatalf("unexpected ranking %v", got)
+	}
+	if got := ids(rank(tweets, nil, 2)); !slices.Equal(got, []int64{4, 3}) {
+		t.Fatalf("expected the latest tweets, got %v", got)
+	}
+	if got := rank(tweets, []string{"cosmos"}, 10); len(got) != 0 {
+		t.Fatalf("expected no tweets, got %v", got)
+	}
+	// Keywords match whole words and phrases regardless of case and punctuation
+	tweets = append(tweets, tweet(5, 5, "Some method, ETHEREUM!"), tweet(6, 6, "Ретрит в офлайне"))
+	if got := ids(rank(tweets, []string{"eth", "#Bitcoin", "offline retreat"}, 10)); !slices.Equal(got, []int64{4, 3, 2, 1}) {
+		t.Fatalf("unexpected ranking %v", got)
+	}
+	if got := ids(rank(tweets, []string{"ретрит"}, 10)); !slices.Equal(got, []int64{6}) {
+		t.Fatalf("unexpected ranking %v", got)
+	}
+}
+
+func TestSearchParams(t *testing.T) {
+	arg, err := searchParams{Author: "@mastercyb", Since: "2025-09-01T00:00:00+08:00"}.query()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !arg.Author.Valid || arg.Author.String != "mastercyb" {
+		t.Fatalf("unexpected author %v", arg.Author)
+	}
+	if !arg.Since.Time.Equal(time.Date(2025, 8, 31, 16, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected since %v", arg.Since.Time)
+	}
+
+	arg, err = searchParams{}.query()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if arg.Author.Valid || !arg.Since.Valid || !arg.Until.Valid {
+		t.Fatalf("unexpected params %+v", arg)
+	}
+
+	if _, err := (searchParams{Until: "yesterday"}).query(); err == nil {
+		t.Fatal("expected error for invalid period")
+	}
+}
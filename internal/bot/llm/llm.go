@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"maps"
+	"slices"
 
 	"github.com/cozodb/cozo-lib-go"
 	"github.com/firebase/genkit/go/ai"
@@ -14,15 +16,23 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"mimi/internal/bot/llm/agent"
+	"mimi/internal/bot/llm/agent/chart"
+	"mimi/internal/bot/llm/agent/code"
 	"mimi/internal/bot/llm/agent/fallback"
 	"mimi/internal/bot/llm/agent/github"
 	"mimi/internal/bot/llm/agent/logseq"
 	"mimi/internal/bot/llm/agent/logseqquery"
+	"mimi/internal/bot/llm/agent/logseqwrite"
 	"mimi/internal/bot/llm/agent/summary"
+	"mimi/internal/bot/llm/agent/summaryarchive"
 	"mimi/internal/bot/llm/agent/telegram"
+	"mimi/internal/bot/llm/agent/x"
+	"mimi/internal/config"
 	"mimi/internal/persist"
 	logseqscraper "mimi/internal/provider/logseq"
 	"mimi/internal/provider/logseq/db"
+	"mimi/internal/provider/logseq/rag"
+	"mimi/internal/provider/logseq/retriever"
 )
 
 type LLM struct {
@@ -32,17 +42,27 @@ type LLM struct {
 	router *ai.Prompt
 }
 
-func New(pgPool *pgxpool.Pool, graph logseqscraper.RegexGraph, g *genkit.Genkit, conn cozo.CozoDB) LLM {
+// New creates LLM with all agents, nil `r` disables vector search in LogSeq
+func New(pgPool *pgxpool.Pool, graph *logseqscraper.IndexedGraph, g *genkit.Genkit, conn cozo.CozoDB, r *rag.RAG) LLM {
 	q := persist.New(pgPool)
 
 	ghOrg := "cyber-valley"
+	loc, err := config.Location()
+	if err != nil {
+		log.Fatal(err)
+	}
 	agents := []agent.Agent{
-		logseq.New(g, db.New(conn)),
-		logseqquery.New(graph),
+		logseq.New(g, retriever.New(db.New(conn), r)),
+		logseqquery.New(g, graph, db.New(conn)),
+		logseqwrite.New(g, graph, loc),
 		fallback.New(g),
 		github.New(g, ghOrg),
 		telegram.New(g, pgPool),
-		summary.New(g, pgPool, ghOrg, graph.Path),
+		summary.New(g, pgPool, ghOrg, graph.Source().Path, loc),
+		summaryarchive.New(g, pgPool),
+		chart.New(g, pgPool, ghOrg, loc),
+		x.New(g, pgPool, loc),
+		code.New(g),
 	}
 	mapped := make(map[string]agent.Agent, len(agents))
 	for _, agent := range agents {
@@ -78,6 +98,12 @@ func (m LLM) Answer(ctx context.Context, id int64, query string) (agent.Response
 	}
 	slog.Info("router answer", "agent", output.Agent)
 
+	return m.AnswerWith(ctx, id, output.Agent, query)
+}
+
+// AnswerWith runs the named agent bypassing the router
+func (m LLM) AnswerWith(ctx context.Context, id int64, agentName, query string) (agent.Response, error) {
+	var result agent.Response
 	// Retrieve messages history
 	rows, err := m.q.FindChatMessages(ctx, id)
 	var messages []*ai.Message
@@ -94,9 +120,9 @@ func (m LLM) Answer(ctx context.Context, id int64, query string) (agent.Response
 	}
 
 	// Run selected agent
-	a, ok := m.agents[output.Agent]
+	a, ok := m.agents[agentName]
 	if !ok {
-		return result, fmt.Errorf("agent with name '%s' not found", output.Agent)
+		return result, fmt.Errorf("agent with name '%s' not found", agentName)
 	}
 	result, err = a.Run(ctx, query, messages...)
 	if err != nil {
@@ -105,8 +131,17 @@ func (m LLM) Answer(ctx context.Context, id int64, query string) (agent.Response
 
 	// Update message history
 	messages = append(messages, ai.NewTextMessage(ai.RoleUser, query))
-	if text, ok := result.Data.(agent.DataText); ok {
-		messages = append(messages, ai.NewTextMessage(ai.RoleModel, text.Text))
+	switch data := result.Data.(type) {
+	case agent.DataText:
+		messages = append(messages, ai.NewTextMessage(ai.RoleModel, data.Text))
+	case agent.DataFile:
+		if data.Caption != "" {
+			messages = append(messages, ai.NewTextMessage(ai.RoleModel, data.Caption))
+		}
+	case agent.DataImage:
+		if data.Caption != "" {
+			messages = append(messages, ai.NewTextMessage(ai.RoleModel, data.Caption))
+		}
 	}
 	if len(messages) > 20 {
 		messages = messages[:20]
@@ -126,6 +161,11 @@ func (m LLM) Answer(ctx context.Context, id int64, query string) (agent.Response
 	return result, nil
 }
 
+// Agents returns sorted names of the registered agents
+func (m LLM) Agents() []string {
+	return slices.Sorted(maps.Keys(m.agents))
+}
+
 func (m LLM) getAgentsInfo() (info []agent.Info) {
 	for _, agent := range m.agents {
 		info = append(info, agent.GetInfo())
@@ -0,0 +1,38 @@
+// Package config contains settings shared by the bot and the data providers
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// IANA name of the timezone used to resolve dates in user's queries
+	TimezoneEnv = "CHAT_TIMEZONE"
+	// Comma separated screen names of the X accounts which timelines are imported
+	XAccountsEnv = "X_ACCOUNTS"
+)
+
+// Location returns timezone of the chats, it's loaded from the environment once
+var Location = sync.OnceValues(func() (*time.Location, error) {
+	loc, err := time.LoadLocation(os.Getenv(TimezoneEnv))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load timezone from %s env variable with %w", TimezoneEnv, err)
+	}
+	return loc, nil
+})
+
+// XAccounts returns screen names of the followed X accounts without @,
+// exports contain tweets quoted or retweeted by them from other accounts as well
+func XAccounts() []string {
+	var accounts []string
+	for _, account := range strings.Split(os.Getenv(XAccountsEnv), ",") {
+		if account = strings.TrimPrefix(strings.TrimSpace(account), "@"); account != "" {
+			accounts = append(accounts, account)
+		}
+	}
+	return accounts
+}
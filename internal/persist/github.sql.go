@@ -9,6 +9,51 @@ import (
 	"context"
 )
 
+const deleteGitHubProjectRule = `-- name: DeleteGitHubProjectRule :exec
+DELETE FROM github_project_rule
+WHERE
+    position = $1
+`
+
+func (q *Queries) DeleteGitHubProjectRule(ctx context.Context, position int32) error {
+	_, err := q.db.Exec(ctx, deleteGitHubProjectRule, position)
+	return err
+}
+
+const findGitHubProjectRules = `-- name: FindGitHubProjectRules :many
+SELECT
+    position, pattern, include, role
+FROM
+    github_project_rule
+ORDER BY
+    position
+`
+
+func (q *Queries) FindGitHubProjectRules(ctx context.Context) ([]GithubProjectRule, error) {
+	rows, err := q.db.Query(ctx, findGitHubProjectRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GithubProjectRule
+	for rows.Next() {
+		var i GithubProjectRule
+		if err := rows.Scan(
+			&i.Position,
+			&i.Pattern,
+			&i.Include,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const findGitHubRepositories = `-- name: FindGitHubRepositories :many
 SELECT
     owner,
@@ -36,3 +81,32 @@ func (q *Queries) FindGitHubRepositories(ctx context.Context) ([]GithubRepositor
 	}
 	return items, nil
 }
+
+const saveGitHubProjectRule = `-- name: SaveGitHubProjectRule :exec
+INSERT INTO
+    github_project_rule (position, pattern, include, role)
+VALUES
+    ($1, $2, $3, $4)
+ON CONFLICT (position) DO UPDATE
+SET
+    pattern = excluded.pattern,
+    include = excluded.include,
+    role = excluded.role
+`
+
+type SaveGitHubProjectRuleParams struct {
+	Position int32
+	Pattern  string
+	Include  bool
+	Role     string
+}
+
+func (q *Queries) SaveGitHubProjectRule(ctx context.Context, arg SaveGitHubProjectRuleParams) error {
+	_, err := q.db.Exec(ctx, saveGitHubProjectRule,
+		arg.Position,
+		arg.Pattern,
+		arg.Include,
+		arg.Role,
+	)
+	return err
+}
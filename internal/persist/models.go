@@ -8,6 +8,13 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type GithubProjectRule struct {
+	Position int32
+	Pattern  string
+	Include  bool
+	Role     string
+}
+
 type GithubRepository struct {
 	Owner string
 	Name  string
@@ -18,6 +25,22 @@ type LlmChat struct {
 	Messages   []byte
 }
 
+type SummaryCache struct {
+	Key       string
+	Summary   string
+	CreatedAt pgtype.Timestamptz
+}
+
+type SummaryReport struct {
+	ID        int32
+	Since     pgtype.Timestamptz
+	Until     pgtype.Timestamptz
+	Sources   []string
+	Items     []byte
+	Report    string
+	CreatedAt pgtype.Timestamptz
+}
+
 type TelegramMessage struct {
 	ID        int32
 	PeerID    int64
@@ -39,3 +62,24 @@ type TelegramTopic struct {
 	Title       string
 	Description string
 }
+
+type XTweet struct {
+	ID                  int64
+	AuthorID            int64
+	AuthorScreenName    string
+	AuthorName          string
+	Text                string
+	Lang                string
+	ConversationID      int64
+	InReplyToID         pgtype.Int8
+	InReplyToScreenName pgtype.Text
+	QuotedID            pgtype.Int8
+	RetweetedID         pgtype.Int8
+	Media               []byte
+	FavoriteCount       int32
+	RetweetCount        int32
+	ReplyCount          int32
+	QuoteCount          int32
+	CreatedAt           pgtype.Timestamptz
+	ImportedAt          pgtype.Timestamptz
+}
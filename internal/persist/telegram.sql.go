@@ -11,27 +11,100 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countTelegramMessages = `-- name: CountTelegramMessages :many
+SELECT
+    p.chat_name,
+    date_trunc($1::text, m.created_at, $2::text)::timestamptz AS period,
+    count(*) AS messages
+FROM
+    telegram_message m
+    INNER JOIN telegram_peer p ON m.peer_id = p.id
+WHERE
+    p.enabled
+    AND m.created_at >= $3
+    AND m.created_at < $4
+GROUP BY
+    p.chat_name,
+    period
+ORDER BY
+    period,
+    p.chat_name
+`
+
+type CountTelegramMessagesParams struct {
+	Bucket   string
+	Timezone string
+	Since    pgtype.Timestamptz
+	Until    pgtype.Timestamptz
+}
+
+type CountTelegramMessagesRow struct {
+	ChatName string
+	Period   pgtype.Timestamptz
+	Messages int64
+}
+
+func (q *Queries) CountTelegramMessages(ctx context.Context, arg CountTelegramMessagesParams) ([]CountTelegramMessagesRow, error) {
+	rows, err := q.db.Query(ctx, countTelegramMessages,
+		arg.Bucket,
+		arg.Timezone,
+		arg.Since,
+		arg.Until,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CountTelegramMessagesRow
+	for rows.Next() {
+		var i CountTelegramMessagesRow
+		if err := rows.Scan(&i.ChatName, &i.Period, &i.Messages); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const findTelegramMessages = `-- name: FindTelegramMessages :many
 SELECT
+    m.id,
+    m.peer_id,
     m.message,
+    m.created_at,
     p.chat_name AS chat_name,
     t.title AS topic_title
 FROM
     telegram_message m
     INNER JOIN telegram_peer p ON m.peer_id = p.id
-    JOIN telegram_topic t ON m.topic_id = t.id
+    LEFT JOIN telegram_topic t ON m.topic_id = t.id
+    AND m.peer_id = t.peer_id
 WHERE
     m.created_at >= $1
+    AND m.created_at < $2
+ORDER BY
+    m.created_at
 `
 
+type FindTelegramMessagesParams struct {
+	Since pgtype.Timestamptz
+	Until pgtype.Timestamptz
+}
+
 type FindTelegramMessagesRow struct {
+	ID         int32
+	PeerID     int64
 	Message    string
+	CreatedAt  pgtype.Timestamptz
 	ChatName   string
-	TopicTitle string
+	TopicTitle pgtype.Text
 }
 
-func (q *Queries) FindTelegramMessages(ctx context.Context, createdAt pgtype.Timestamptz) ([]FindTelegramMessagesRow, error) {
-	rows, err := q.db.Query(ctx, findTelegramMessages, createdAt)
+func (q *Queries) FindTelegramMessages(ctx context.Context, arg FindTelegramMessagesParams) ([]FindTelegramMessagesRow, error) {
+	rows, err := q.db.Query(ctx, findTelegramMessages, arg.Since, arg.Until)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +112,14 @@ func (q *Queries) FindTelegramMessages(ctx context.Context, createdAt pgtype.Tim
 	var items []FindTelegramMessagesRow
 	for rows.Next() {
 		var i FindTelegramMessagesRow
-		if err := rows.Scan(&i.Message, &i.ChatName, &i.TopicTitle); err != nil {
+		if err := rows.Scan(
+			&i.ID,
+			&i.PeerID,
+			&i.Message,
+			&i.CreatedAt,
+			&i.ChatName,
+			&i.TopicTitle,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -4,12 +4,19 @@ import (
 	"bytes"
 	"fmt"
 	"log/slog"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 )
 
-const gitPath = "/usr/bin/git"
+const (
+	gitPath = "/usr/bin/git"
+	// Lock file inside .git directory which git itself ignores
+	lockFile = "mimi.lock"
+)
 
 func Clone(baseRepoPath, owner, name string) error {
 	_, err := Git(baseRepoPath, "clone", AsUrl(owner, name), AsPath(owner, name))
@@ -18,28 +25,220 @@ func Clone(baseRepoPath, owner, name string) error {
 
 func Pull(baseRepoPath, owner, name string) error {
 	cwd := filepath.Join(baseRepoPath, AsPath(owner, name))
-	_, err := Git(cwd, "pull")
+	unlock, err := Lock(cwd)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	_, err = Git(cwd, "pull")
 	return err
 }
 
-func DiffInterval(repoPath string, since time.Time) (string, error) {
-	sinceStr := since.Format(time.RFC3339)
+// Lock takes an exclusive lock of the repository's working tree shared by
+// all processes changing it, e.g. pulling scraper and LogSeq writer.
+// Returned function releases the lock
+func Lock(repoPath string) (unlock func(), _ error) {
+	path := filepath.Join(repoPath, ".git", lockFile)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file '%s' with %w", path, err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock '%s' with %w", path, err)
+	}
+	return func() {
+		// Closing the file releases the lock
+		if err := f.Close(); err != nil {
+			slog.Warn("failed to release repository lock", "path", path, "with", err)
+		}
+	}, nil
+}
+
+// DiffInterval returns diff of the repository state between `since` and `until`
+func DiffInterval(repoPath string, since, until time.Time) (string, error) {
+	from, err := commitBefore(repoPath, since)
+	if err != nil {
+		return "", err
+	}
+	to, err := commitBefore(repoPath, until)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep non ASCII paths unquoted
+	diffBytes, err := Git(repoPath, "-c", "core.quotePath=false", "diff", from, to)
+	if err != nil {
+		return "", fmt.Errorf("failed to get diff from %s to %s: %w", from, to, err)
+	}
+
+	return string(diffBytes), nil
+}
+
+type FileDiff struct {
+	Path string
+	Diff string
+}
+
+// SplitDiff splits unified diff of several files into per file diffs
+func SplitDiff(diff string) (files []FileDiff) {
+	const header = "diff --git "
+	for _, part := range strings.SplitAfter(diff, "\n"+header) {
+		part = strings.TrimSuffix(part, "\n"+header)
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		if !strings.HasPrefix(part, header) {
+			part = header + part
+		}
+		files = append(files, FileDiff{
+			Path: diffPath(part),
+			Diff: part,
+		})
+	}
+	return files
+}
+
+// diffPath extracts file's path from the single file diff
+// preferring the new path for renamed and created files
+func diffPath(diff string) string {
+	var oldPath string
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ b/"):
+			return strings.TrimPrefix(line, "+++ b/")
+		case strings.HasPrefix(line, "--- a/"):
+			oldPath = strings.TrimPrefix(line, "--- a/")
+		case strings.HasPrefix(line, "@@"):
+			// Headers are over
+			return oldPath
+		}
+	}
+	if oldPath != "" {
+		return oldPath
+	}
+	// Binary or mode only changes, take the path from the "diff --git a/<path> b/<path>" header
+	header, _, _ := strings.Cut(diff, "\n")
+	if idx := strings.LastIndex(header, " b/"); idx != -1 {
+		return header[idx+len(" b/"):]
+	}
+	return header
+}
+
+// HeadCommit returns hash of the checked out commit
+func HeadCommit(repoPath string) (string, error) {
+	hash, err := Git(repoPath, "rev-parse", "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to find HEAD commit with %w", err)
+	}
+	return string(bytes.TrimSpace(hash)), nil
+}
 
-	commitHashBytes, err := Git(repoPath, "log", "--before="+sinceStr, "-1", "--format=%H")
+// Show returns file's content at the given commit, `path` is relative to `repoPath`
+func Show(repoPath, commit, path string) ([]byte, error) {
+	content, err := Git(repoPath, "show", commit+":./"+path)
 	if err != nil {
-		return "", fmt.Errorf("failed to find commit before %s with %w", sinceStr, err)
+		return nil, fmt.Errorf("failed to show '%s' at %s with %w", path, commit, err)
+	}
+	return content, nil
+}
+
+type FileStatus string
+
+const (
+	FileAdded    FileStatus = "A"
+	FileCopied   FileStatus = "C"
+	FileDeleted  FileStatus = "D"
+	FileModified FileStatus = "M"
+	FileRenamed  FileStatus = "R"
+	FileTyped    FileStatus = "T"
+)
+
+type FileChange struct {
+	Status FileStatus
+	Path   string
+	// Source of the renamed or copied file
+	OldPath string
+}
+
+// ChangedFiles lists files changed between the commits detecting renames,
+// only files under `repoPath` are listed relative to it
+func ChangedFiles(repoPath, from, to string) ([]FileChange, error) {
+	out, err := Git(repoPath, "diff", "--name-status", "--relative", "-M", "-z", from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changed files from %s to %s with %w", from, to, err)
+	}
+	return parseNameStatus(string(out))
+}
+
+// parseNameStatus parses NUL separated `git diff --name-status -z` output,
+// renames and copies are followed by the old and the new paths
+func parseNameStatus(out string) (changes []FileChange, _ error) {
+	if out == "" {
+		return nil, nil
+	}
+	fields := strings.Split(strings.TrimSuffix(out, "\x00"), "\x00")
+	for i := 0; i < len(fields); i++ {
+		if fields[i] == "" {
+			return nil, fmt.Errorf("unexpected empty status at %d", i)
+		}
+		// Similarity score follows renames and copies e.g. R087
+		status := FileStatus(fields[i][:1])
+		change := FileChange{Status: status}
+		switch status {
+		case FileRenamed, FileCopied:
+			if i+2 >= len(fields) {
+				return nil, fmt.Errorf("missing paths of '%s' status", fields[i])
+			}
+			change.OldPath, change.Path = fields[i+1], fields[i+2]
+			i += 2
+		default:
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("missing path of '%s' status", fields[i])
+			}
+			change.Path = fields[i+1]
+			i++
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// commitBefore finds the latest commit made before or at `t`
+func commitBefore(repoPath string, t time.Time) (string, error) {
+	tStr := t.Format(time.RFC3339)
+
+	commitHashBytes, err := Git(repoPath, "log", "--before="+tStr, "-1", "--format=%H")
+	if err != nil {
+		return "", fmt.Errorf("failed to find commit before %s with %w", tStr, err)
 	}
 	commitHash := string(bytes.TrimSpace(commitHashBytes))
 	if commitHash == "" {
-		return "", fmt.Errorf("no commit found before or at %s in repository %s", sinceStr, repoPath)
+		return "", fmt.Errorf("no commit found before or at %s in repository %s", tStr, repoPath)
 	}
+	return commitHash, nil
+}
 
-	diffBytes, err := Git(repoPath, "diff", commitHash, "HEAD")
+// WebURL returns browsable URL of the repository's origin remote
+func WebURL(repoPath string) (string, error) {
+	remote, err := Git(repoPath, "remote", "get-url", "origin")
 	if err != nil {
-		return "", fmt.Errorf("failed to get diff from %s to HEAD: %w", commitHash, err)
+		return "", fmt.Errorf("failed to get origin remote with %w", err)
 	}
+	return remoteWebURL(string(bytes.TrimSpace(remote))), nil
+}
 
-	return string(diffBytes), nil
+// remoteWebURL converts SSH and HTTP remotes to the HTTPS URL
+func remoteWebURL(remote string) string {
+	remote = strings.TrimSuffix(remote, ".git")
+	if rest, ok := strings.CutPrefix(remote, "git@"); ok {
+		host, path, _ := strings.Cut(rest, ":")
+		return fmt.Sprintf("https://%s/%s", host, path)
+	}
+	if rest, ok := strings.CutPrefix(remote, "http://"); ok {
+		return "https://" + rest
+	}
+	return remote
 }
 
 func AsUrl(owner, name string) string {
@@ -51,12 +250,26 @@ func AsPath(owner, name string) string {
 }
 
 func Git(cwd string, args ...string) ([]byte, error) {
+	return GitEnv(cwd, nil, args...)
+}
+
+// GitEnv executes git with additional environment variables e.g. credentials
+// which shouldn't appear in the logged arguments
+func GitEnv(cwd string, env []string, args ...string) ([]byte, error) {
 	slog.Info("executing git command", "args", args)
 	cmd := exec.Command(gitPath, args...)
 	cmd.Dir = cwd
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
 	stdout, err := cmd.Output()
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute '%s %s' with %w", gitPath, args, err)
+		return nil, fmt.Errorf(
+			"failed to execute '%s %s' with %w: %s",
+			gitPath, args, err, strings.TrimSpace(stderr.String()),
+		)
 	}
 	return stdout, nil
 }
@@ -0,0 +1,118 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+const diff = `diff --git a/pages/foo.md b/pages/foo.md
+index 1111111..2222222 100644
+--- a/pages/foo.md
++++ b/pages/foo.md
+@@ -1 +1,2 @@
+ - foo
++- bar
+diff --git a/pages/старое.md b/pages/старое.md
+deleted file mode 100644
+index 3333333..0000000
+--- a/pages/старое.md
++++ /dev/null
+@@ -1 +0,0 @@
+-- gone
+diff --git a/assets/image.png b/assets/image.png
+new file mode 100644
+index 0000000..4444444
+Binary files /dev/null and b/assets/image.png differ
+`
+
+func TestSplitDiff(t *testing.T) {
+	files := SplitDiff(diff)
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i] = file.Path
+	}
+	expected := []string{"pages/foo.md", "pages/старое.md", "assets/image.png"}
+	if !slices.Equal(paths, expected) {
+		t.Errorf("expected %#v, got %#v", expected, paths)
+	}
+	for _, file := range files {
+		if file.Diff[:len("diff --git ")] != "diff --git " {
+			t.Errorf("diff of '%s' should start with a header, got '%s'", file.Path, file.Diff)
+		}
+	}
+	if len(SplitDiff("")) != 0 {
+		t.Errorf("empty diff should have no files")
+	}
+}
+
+func TestRemoteWebURL(t *testing.T) {
+	cases := map[string]string{
+		"http://github.com/cyber-valley/cvland":      "https://github.com/cyber-valley/cvland",
+		"https://github.com/cyber-valley/cvland.git": "https://github.com/cyber-valley/cvland",
+		"git@github.com:cyber-valley/cvland.git":     "https://github.com/cyber-valley/cvland",
+	}
+	for remote, expected := range cases {
+		if got := remoteWebURL(remote); got != expected {
+			t.Fatalf("expected %s for %s, got %s", expected, remote, got)
+		}
+	}
+}
+
+func TestParseNameStatus(t *testing.T) {
+	out := "M\x00pages/foo.md\x00R087\x00pages/old.md\x00pages/new.md\x00D\x00journals/2024_01_01.md\x00A\x00pages/страница.org\x00"
+	changes, err := parseNameStatus(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []FileChange{
+		{Status: FileModified, Path: "pages/foo.md"},
+		{Status: FileRenamed, Path: "pages/new.md", OldPath: "pages/old.md"},
+		{Status: FileDeleted, Path: "journals/2024_01_01.md"},
+		{Status: FileAdded, Path: "pages/страница.org"},
+	}
+	if !slices.Equal(changes, expected) {
+		t.Errorf("expected %#v, got %#v", expected, changes)
+	}
+	if changes, err := parseNameStatus(""); err != nil || len(changes) != 0 {
+		t.Errorf("empty output should have no changes, got %#v with %v", changes, err)
+	}
+	if _, err := parseNameStatus("R100\x00pages/old.md\x00"); err == nil {
+		t.Errorf("expected error for the truncated rename")
+	}
+}
+
+func TestLock(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	unlock, err := Lock(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locked := make(chan struct{})
+	go func() {
+		unlock, err := Lock(repo)
+		if err != nil {
+			t.Error(err)
+		} else {
+			unlock()
+		}
+		close(locked)
+	}()
+	select {
+	case <-locked:
+		t.Fatal("expected the second lock to wait for the first one")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("expected the second lock to be taken after unlock")
+	}
+}
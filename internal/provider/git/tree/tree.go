@@ -0,0 +1,298 @@
+// Package tree gives read-only access to the cloned repositories' working trees.
+// Every operation is limited to the known repositories and caps its output
+// to be safely passed to LLMs
+package tree
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"mimi/internal/provider/git"
+)
+
+const (
+	// Output caps of a single operation
+	maxListEntries = 500
+	maxReadLines   = 400
+	maxGrepLines   = 200
+	maxBlameLines  = 200
+	maxLogEntries  = 50
+	maxOutputBytes = 32 * 1024
+	// Minified files have enormous lines
+	maxLineBytes = 500
+	// Prefix of the file checked for NUL bytes
+	binaryProbeBytes = 8000
+)
+
+var (
+	ErrUnknownRepository = errors.New("unknown repository")
+	ErrOutsideRepository = errors.New("path is outside of the repository")
+)
+
+// Tree is a directory with repositories cloned as `owner/name`
+type Tree struct {
+	basePath string
+}
+
+func New(basePath string) Tree {
+	return Tree{basePath: basePath}
+}
+
+// Repos lists cloned repositories as `owner/name`
+func (t Tree) Repos() ([]string, error) {
+	owners, err := os.ReadDir(t.basePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repository owners with %w", err)
+	}
+	var repos []string
+	for _, owner := range owners {
+		if !owner.IsDir() {
+			continue
+		}
+		names, err := os.ReadDir(filepath.Join(t.basePath, owner.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of '%s' with %w", owner.Name(), err)
+		}
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(t.basePath, owner.Name(), name.Name(), ".git")); err == nil {
+				repos = append(repos, owner.Name()+"/"+name.Name())
+			}
+		}
+	}
+	slices.Sort(repos)
+	return repos, nil
+}
+
+// ListFiles lists tracked files under the directory, empty `dir` lists the whole repository
+func (t Tree) ListFiles(repo, dir string) (string, error) {
+	root, rel, err := t.resolve(repo, dir)
+	if err != nil {
+		return "", err
+	}
+	out, err := git.Git(root, "ls-files", "-z", "--", pathspec(rel))
+	if err != nil {
+		return "", err
+	}
+	files := strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+	if len(files) == 1 && files[0] == "" {
+		return "", fmt.Errorf("no tracked files under '%s'", dir)
+	}
+	return capLines(strings.Join(files, "\n"), maxListEntries), nil
+}
+
+// ReadFile returns numbered lines of the file starting from `from` line (1-based),
+// the file is streamed to keep only the returned lines in memory
+func (t Tree) ReadFile(repo, path string, from int) (string, error) {
+	root, rel, err := t.resolve(repo, path)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(filepath.Join(root, rel))
+	if err != nil {
+		return "", fmt.Errorf("failed to read '%s' with %w", path, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to stat '%s' with %w", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("'%s' isn't a regular file", path)
+	}
+
+	r := bufio.NewReader(f)
+	// Binary files are detected by the NUL byte in the beginning as git does
+	if head, _ := r.Peek(binaryProbeBytes); bytes.IndexByte(head, 0) != -1 {
+		return "", fmt.Errorf("'%s' is a binary file", path)
+	}
+	from = max(from, 1)
+	var b strings.Builder
+	total := 0
+	for {
+		line, err := readLine(r, maxLineBytes)
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read '%s' with %w", path, err)
+		}
+		total++
+		if total >= from && total < from+maxReadLines {
+			if !utf8.Valid(line) && len(line) <= maxLineBytes {
+				return "", fmt.Errorf("'%s' is a binary file", path)
+			}
+			fmt.Fprintf(&b, "%d\t%s\n", total, line)
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if from > total {
+		return "", fmt.Errorf("'%s' has only %d lines", path, total)
+	}
+	return capLinesOf(b.String(), maxReadLines, max(total-from+1-maxReadLines, 0)), nil
+}
+
+// readLine reads the line without the newline keeping `limit` bytes of it and the next one
+// to mark the line as long, io.EOF is returned with the last line
+func readLine(r *bufio.Reader, limit int) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if keep := limit + 1 - len(line); keep > 0 {
+			line = append(line, chunk[:min(len(chunk), keep)]...)
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err == nil {
+			line = bytes.TrimSuffix(line, []byte("\n"))
+		}
+		return line, err
+	}
+}
+
+// Grep searches tracked files for the extended regular expression
+func (t Tree) Grep(repo, pattern, dir string) (string, error) {
+	root, rel, err := t.resolve(repo, dir)
+	if err != nil {
+		return "", err
+	}
+	out, err := git.Git(root, "grep", "-n", "-I", "-E", "--no-color", "-e", pattern, "--", pathspec(rel))
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "no matches", nil
+		}
+		return "", err
+	}
+	return capLines(string(out), maxGrepLines), nil
+}
+
+// Log lists the latest commits touching the path, empty `path` lists repository commits
+func (t Tree) Log(repo, path string, limit int) (string, error) {
+	root, rel, err := t.resolve(repo, path)
+	if err != nil {
+		return "", err
+	}
+	limit = min(max(limit, 1), maxLogEntries)
+	out, err := git.Git(
+		root, "log", "-n", strconv.Itoa(limit), "--no-color", "--date=short",
+		"--format=%h %ad %an%n  %s", "--", pathspec(rel),
+	)
+	if err != nil {
+		return "", err
+	}
+	return capLines(string(out), maxLogEntries*2), nil
+}
+
+// Blame annotates file lines in the [from, to] range (1-based, inclusive),
+// zero `to` blames the rest of the file
+func (t Tree) Blame(repo, path string, from, to int) (string, error) {
+	root, rel, err := t.resolve(repo, path)
+	if err != nil {
+		return "", err
+	}
+	from = max(from, 1)
+	if to <= 0 || to-from >= maxBlameLines {
+		to = from + maxBlameLines - 1
+	}
+	if to < from {
+		return "", fmt.Errorf("invalid line range %d-%d", from, to)
+	}
+	out, err := git.Git(root, "blame", "--date=short", "-L", fmt.Sprintf("%d,%d", from, to), "--", rel)
+	if err != nil {
+		// Range exceeding the file length is a common mistake, retry with the rest of the file
+		if !strings.Contains(err.Error(), "has only") {
+			return "", err
+		}
+		out, err = git.Git(root, "blame", "--date=short", "-L", fmt.Sprintf("%d,", from), "--", rel)
+		if err != nil {
+			return "", err
+		}
+	}
+	return capLines(string(out), maxBlameLines), nil
+}
+
+// resolve returns repository root and the path relative to it
+// ensuring neither of them escapes the tree
+func (t Tree) resolve(repo, path string) (root, rel string, err error) {
+	repos, err := t.Repos()
+	if err != nil {
+		return "", "", err
+	}
+	if !slices.Contains(repos, repo) {
+		return "", "", fmt.Errorf("%w '%s', known ones are %s", ErrUnknownRepository, repo, strings.Join(repos, ", "))
+	}
+	root = filepath.Join(t.basePath, filepath.FromSlash(repo))
+
+	// Rooting the path prevents ".." from climbing above the repository
+	rel = strings.TrimPrefix(filepath.Clean("/"+filepath.ToSlash(path)), "/")
+	if rel == ".git" || strings.HasPrefix(rel, ".git/") {
+		return "", "", fmt.Errorf("%w: '%s'", ErrOutsideRepository, path)
+	}
+
+	// Symlinks could point anywhere
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve repository '%s' with %w", repo, err)
+	}
+	real, err := filepath.EvalSymlinks(filepath.Join(root, rel))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", "", fmt.Errorf("'%s' doesn't exist in '%s'", path, repo)
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve '%s' with %w", path, err)
+	}
+	if real != realRoot && !strings.HasPrefix(real, realRoot+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("%w: '%s'", ErrOutsideRepository, path)
+	}
+	return root, rel, nil
+}
+
+// pathspec turns relative path into literal git pathspec matching the whole repository when empty
+func pathspec(rel string) string {
+	if rel == "" {
+		return ":(top)"
+	}
+	return ":(literal)" + rel
+}
+
+// capLines truncates output to the line and byte limits noting how much was cut
+func capLines(s string, maxLines int) string {
+	return capLinesOf(s, maxLines, 0)
+}
+
+// capLinesOf caps lines of `s` followed by `omitted` lines which were already dropped
+func capLinesOf(s string, maxLines, omitted int) string {
+	s = strings.TrimRight(s, "\n")
+	lines := strings.Split(s, "\n")
+	total := len(lines) + omitted
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+	}
+	var b strings.Builder
+	for i, line := range lines {
+		if len(line) > maxLineBytes {
+			line = strings.ToValidUTF8(line[:maxLineBytes], "") + "…"
+		}
+		if b.Len()+len(line)+1 > maxOutputBytes {
+			lines = lines[:i]
+			break
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	if len(lines) < total {
+		fmt.Fprintf(&b, "... %d more lines truncated, narrow the request\n", total-len(lines))
+	}
+	return b.String()
+}
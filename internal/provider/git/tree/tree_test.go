@@ -0,0 +1,193 @@
+package tree
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mimi/internal/provider/git"
+)
+
+var identity = []string{
+	"GIT_AUTHOR_NAME=alice", "GIT_AUTHOR_EMAIL=alice@example.com",
+	"GIT_COMMITTER_NAME=alice", "GIT_COMMITTER_EMAIL=alice@example.com",
+}
+
+// setupTree creates base path with single `cyber-valley/mimi` repository
+func setupTree(t *testing.T) (Tree, string) {
+	t.Helper()
+	base := t.TempDir()
+	repo := filepath.Join(base, "cyber-valley", "mimi")
+	if err := os.MkdirAll(repo, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"main.go":          "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n",
+		"internal/x/x.go":  "package x\n\n// Answer is the answer\nconst Answer = 42\n",
+		"docs/README.md":   "# Docs\n",
+		"internal/long.go": strings.Repeat("// line\n", 1000),
+	}
+	for path, content := range files {
+		full := filepath.Join(repo, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Secret outside of the repository linked from inside
+	secret := filepath.Join(base, "secret.txt")
+	if err := os.WriteFile(secret, []byte("token"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(repo, "secret.txt")); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--initial-branch=main"},
+		{"add", "main.go", "internal", "docs"},
+		{"commit", "-m", "Initial commit"},
+	} {
+		if _, err := git.GitEnv(repo, identity, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return New(base), repo
+}
+
+func TestRepos(t *testing.T) {
+	tr, _ := setupTree(t)
+	repos, err := tr.Repos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0] != "cyber-valley/mimi" {
+		t.Fatalf("unexpected repos %v", repos)
+	}
+}
+
+func TestListFiles(t *testing.T) {
+	tr, _ := setupTree(t)
+	out, err := tr.ListFiles("cyber-valley/mimi", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "docs/README.md\ninternal/long.go\ninternal/x/x.go\nmain.go\n" {
+		t.Fatalf("unexpected listing %q", out)
+	}
+	out, err = tr.ListFiles("cyber-valley/mimi", "internal/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "internal/x/x.go\n" {
+		t.Fatalf("unexpected listing %q", out)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	tr, repo := setupTree(t)
+	out, err := tr.ReadFile("cyber-valley/mimi", "internal/x/x.go", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(out, "3\t// Answer is the answer\n4\tconst Answer = 42\n") {
+		t.Fatalf("unexpected content %q", out)
+	}
+	out, err = tr.ReadFile("cyber-valley/mimi", "internal/long.go", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(out, "\n"); lines != maxReadLines+1 || !strings.Contains(out, "601 more lines truncated") {
+		t.Fatalf("expected capped output, got %d lines", lines)
+	}
+	if _, err := tr.ReadFile("cyber-valley/mimi", "internal/long.go", 1002); err == nil || !strings.Contains(err.Error(), "only 1001 lines") {
+		t.Fatalf("expected error for the line out of the file, got %v", err)
+	}
+
+	// Minified lines are capped while reading
+	minified := strings.Repeat("я", 1<<20) + "\nvar x = 1\n"
+	if err := os.WriteFile(filepath.Join(repo, "min.js"), []byte(minified), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, err = tr.ReadFile("cyber-valley/mimi", "min.js", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) > 2*maxLineBytes || !strings.Contains(out, "…\n2\tvar x = 1\n") {
+		t.Fatalf("expected capped line, got %q", out)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "blob.bin"), []byte("abc\x00def"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.ReadFile("cyber-valley/mimi", "blob.bin", 1); err == nil || !strings.Contains(err.Error(), "binary") {
+		t.Fatalf("expected binary file error, got %v", err)
+	}
+	if _, err := tr.ReadFile("cyber-valley/mimi", "internal", 1); err == nil {
+		t.Fatal("expected directory read to fail")
+	}
+}
+
+func TestGrep(t *testing.T) {
+	tr, _ := setupTree(t)
+	out, err := tr.Grep("cyber-valley/mimi", "Answer = [0-9]+", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "internal/x/x.go:4:const Answer = 42\n" {
+		t.Fatalf("unexpected matches %q", out)
+	}
+	out, err = tr.Grep("cyber-valley/mimi", "Answer", "docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "no matches" {
+		t.Fatalf("expected no matches, got %q", out)
+	}
+}
+
+func TestLogAndBlame(t *testing.T) {
+	tr, _ := setupTree(t)
+	out, err := tr.Log("cyber-valley/mimi", "main.go", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, "alice") || !strings.Contains(out, "Initial commit") {
+		t.Fatalf("unexpected log %q", out)
+	}
+	out, err = tr.Blame("cyber-valley/mimi", "main.go", 3, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(out, "\n"); lines != 3 || !strings.Contains(out, "alice") {
+		t.Fatalf("unexpected blame %q", out)
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	tr, _ := setupTree(t)
+	if _, err := tr.ListFiles("cyber-valley/other", ""); !errors.Is(err, ErrUnknownRepository) {
+		t.Fatalf("expected unknown repository error, got %v", err)
+	}
+	if _, err := tr.ListFiles("../cyber-valley/mimi", ""); !errors.Is(err, ErrUnknownRepository) {
+		t.Fatalf("expected unknown repository error, got %v", err)
+	}
+	if _, err := tr.ReadFile("cyber-valley/mimi", "secret.txt", 1); !errors.Is(err, ErrOutsideRepository) {
+		t.Fatalf("expected outside repository error, got %v", err)
+	}
+	if _, err := tr.ReadFile("cyber-valley/mimi", ".git/config", 1); !errors.Is(err, ErrOutsideRepository) {
+		t.Fatalf("expected outside repository error, got %v", err)
+	}
+	// Climbing up is clamped to the repository root
+	out, err := tr.ReadFile("cyber-valley/mimi", "../../../cyber-valley/mimi/main.go", 1)
+	if err == nil {
+		t.Fatalf("expected missing file error, got %q", out)
+	}
+	out, err = tr.ReadFile("cyber-valley/mimi", "/../main.go", 1)
+	if err != nil || !strings.Contains(out, "package main") {
+		t.Fatalf("expected main.go, got %q, %v", out, err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"time"
+	"unicode/utf8"
 )
 
 //go:embed queries/project.graphql
@@ -50,25 +51,44 @@ type ProjectV2Response struct {
 			Items struct {
 				Nodes []struct {
 					ID          string    `json:"id"`
+					CreatedAt   time.Time `json:"createdAt"`
 					UpdatedAt   time.Time `json:"updatedAt"`
 					FieldValues struct {
-						Nodes []struct {
-							Name  string `json:"name"`
-							Field struct {
-								Name string `json:"name"`
-							} `json:"field"`
-						} `json:"nodes"`
+						Nodes []FieldValueNode `json:"nodes"`
 					} `json:"fieldValues"`
 					Content struct {
-						Title  string `json:"title"`
-						URL    string `json:"url"`
-						State  string `json:"state"`
-						Body   string `json:"body"`
-						Labels struct {
+						Typename  string    `json:"__typename"`
+						Title     string    `json:"title"`
+						URL       string    `json:"url"`
+						State     string    `json:"state"`
+						Body      string    `json:"body"`
+						CreatedAt time.Time `json:"createdAt"`
+						// Empty for open issues and drafts
+						ClosedAt *time.Time `json:"closedAt"`
+						Labels   struct {
 							Nodes []struct {
 								Name string `json:"name"`
 							} `json:"nodes"`
 						} `json:"labels"`
+						Assignees userConnection `json:"assignees"`
+						Comments  struct {
+							Nodes []struct {
+								Author struct {
+									Login string `json:"login"`
+								} `json:"author"`
+								Body      string    `json:"body"`
+								CreatedAt time.Time `json:"createdAt"`
+							} `json:"nodes"`
+						} `json:"comments"`
+						// Pull request specific values
+						PRState        string `json:"prState"`
+						Merged         bool   `json:"merged"`
+						Mergeable      string `json:"mergeable"`
+						IsDraft        bool   `json:"isDraft"`
+						ReviewDecision string `json:"reviewDecision"`
+						ClosingIssues  struct {
+							Nodes []LinkedIssue `json:"nodes"`
+						} `json:"closingIssuesReferences"`
 					} `json:"content"`
 				} `json:"nodes"`
 				PageInfo struct {
@@ -154,6 +174,8 @@ type ProjectInfo struct {
 	Id               int    `json:"number"`
 	Title            string `json:"title"`
 	ShortDescription string `json:"shortDescription"`
+	Closed           bool   `json:"closed"`
+	URL              string `json:"url"`
 }
 
 // ListProjects queries all organization's projects
@@ -169,7 +191,9 @@ func (c *Client) ListProjects(ctx context.Context, org string) (projects []Proje
 }
 
 // GetOrgProject queries organization project board information.
-func (c *Client) GetOrgProject(ctx context.Context, org string, projectNumber int, since time.Time) ([]Issue, error) {
+// Returns only items updated after `since` which were created before `until`,
+// zero `until` disables the upper bound
+func (c *Client) GetOrgProject(ctx context.Context, org string, projectNumber int, since, until time.Time) ([]Issue, error) {
 	var issues []Issue
 	after := ""
 	var times int
@@ -194,13 +218,17 @@ func (c *Client) GetOrgProject(ctx context.Context, org string, projectNumber in
 			if !node.UpdatedAt.IsZero() && node.UpdatedAt.Before(since) {
 				continue
 			}
+			if !until.IsZero() && node.CreatedAt.After(until) {
+				continue
+			}
 
-			var status string
+			fields := make(map[string]FieldValue, len(node.FieldValues.Nodes))
 			for _, fieldValue := range node.FieldValues.Nodes {
-				if fieldValue.Field.Name == "Status" {
-					status = fieldValue.Name
-					break
+				name, value, ok := fieldValue.Parse()
+				if !ok {
+					continue
 				}
+				fields[name] = value
 			}
 
 			var labels []string
@@ -209,14 +237,48 @@ func (c *Client) GetOrgProject(ctx context.Context, org string, projectNumber in
 			}
 
 			content := node.Content
-			issues = append(issues, Issue{
-				Title:  content.Title,
-				URL:    content.URL,
-				State:  content.State,
-				Body:   content.Body,
-				Labels: labels,
-				Status: status,
-			})
+			comments := make([]Comment, len(content.Comments.Nodes))
+			for i, comment := range content.Comments.Nodes {
+				comments[i] = Comment{
+					Author:    comment.Author.Login,
+					Body:      comment.Body,
+					CreatedAt: comment.CreatedAt,
+				}
+			}
+
+			issue := Issue{
+				Type:      ContentType(content.Typename),
+				Title:     content.Title,
+				URL:       content.URL,
+				State:     content.State,
+				Body:      content.Body,
+				Labels:    labels,
+				Status:    fields["Status"].Text,
+				Fields:    fields,
+				Assignees: content.Assignees.logins(),
+				Comments:  comments,
+				AddedAt:   node.CreatedAt,
+				CreatedAt: content.CreatedAt,
+				ClosedAt:  content.ClosedAt,
+			}
+			switch issue.Type {
+			case ContentPullRequest:
+				issue.State = content.PRState
+				issue.PullRequest = &PullRequest{
+					Merged:         content.Merged,
+					Mergeable:      content.Mergeable,
+					IsDraft:        content.IsDraft,
+					ReviewDecision: content.ReviewDecision,
+					LinkedIssues:   content.ClosingIssues.Nodes,
+				}
+			case ContentDraftIssue:
+				issue.State = "DRAFT"
+			case ContentIssue:
+			default:
+				// Content is missing or isn't accessible with the current token
+				issue.Type = ContentRedacted
+			}
+			issues = append(issues, issue)
 		}
 
 		pageInfo := resp.Organization.ProjectV2.Items.PageInfo
@@ -230,11 +292,232 @@ func (c *Client) GetOrgProject(ctx context.Context, org string, projectNumber in
 	return issues, nil
 }
 
+type ContentType string
+
+const (
+	ContentIssue       ContentType = "Issue"
+	ContentPullRequest ContentType = "PullRequest"
+	ContentDraftIssue  ContentType = "DraftIssue"
+	ContentRedacted    ContentType = "Redacted"
+)
+
+// Issue is a project board item which could be
+// an issue, a pull request or a draft issue depending on `Type`
 type Issue struct {
-	Title  string   `json:"title"`
-	URL    string   `json:"url"`
-	State  string   `json:"state"`
-	Body   string   `json:"body"`
-	Labels []string `json:"labels"`
-	Status string   `json:"status"`
+	Type      ContentType           `json:"type"`
+	Title     string                `json:"title"`
+	URL       string                `json:"url"`
+	State     string                `json:"state"`
+	Body      string                `json:"body"`
+	Labels    []string              `json:"labels"`
+	Status    string                `json:"status"`
+	Fields    map[string]FieldValue `json:"fields,omitempty"`
+	Assignees []string              `json:"assignees,omitempty"`
+	Comments  []Comment             `json:"comments,omitempty"`
+	// When the item was added to the project
+	AddedAt time.Time `json:"addedAt"`
+	// When the issue itself was created, zero for redacted items
+	CreatedAt time.Time  `json:"createdAt"`
+	ClosedAt  *time.Time `json:"closedAt,omitempty"`
+	// Set only for the pull requests
+	PullRequest *PullRequest `json:"pullRequest,omitempty"`
+}
+
+type PullRequest struct {
+	Merged bool `json:"merged"`
+	// One of MERGEABLE, CONFLICTING or UNKNOWN
+	Mergeable string `json:"mergeable"`
+	IsDraft   bool   `json:"isDraft"`
+	// One of APPROVED, CHANGES_REQUESTED, REVIEW_REQUIRED or empty
+	ReviewDecision string `json:"reviewDecision,omitempty"`
+	// Issues which will be closed after the merge
+	LinkedIssues []LinkedIssue `json:"linkedIssues,omitempty"`
+}
+
+type LinkedIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	State  string `json:"state"`
+}
+
+const (
+	// Comments and body size kept in the prompt documents
+	briefComments = 3
+	briefRunes    = 2000
+)
+
+// IssueBrief is the part of the issue passed to the prompts
+type IssueBrief struct {
+	Type        ContentType           `json:"type"`
+	Title       string                `json:"title"`
+	URL         string                `json:"url,omitempty"`
+	State       string                `json:"state"`
+	Status      string                `json:"status,omitempty"`
+	Body        string                `json:"body,omitempty"`
+	Labels      []string              `json:"labels,omitempty"`
+	Assignees   []string              `json:"assignees,omitempty"`
+	Fields      map[string]FieldValue `json:"fields,omitempty"`
+	Comments    []Comment             `json:"comments,omitempty"`
+	ClosedAt    *time.Time            `json:"closedAt,omitempty"`
+	PullRequest *PullRequest          `json:"pullRequest,omitempty"`
+}
+
+// Brief keeps the latest comments only truncating long texts
+func (i Issue) Brief() IssueBrief {
+	comments := i.Comments[max(len(i.Comments)-briefComments, 0):]
+	brief := IssueBrief{
+		Type:        i.Type,
+		Title:       i.Title,
+		URL:         i.URL,
+		State:       i.State,
+		Status:      i.Status,
+		Body:        truncate(i.Body, briefRunes),
+		Labels:      i.Labels,
+		Assignees:   i.Assignees,
+		Fields:      i.Fields,
+		Comments:    make([]Comment, len(comments)),
+		ClosedAt:    i.ClosedAt,
+		PullRequest: i.PullRequest,
+	}
+	for j, comment := range comments {
+		comment.Body = truncate(comment.Body, briefRunes)
+		brief.Comments[j] = comment
+	}
+	return brief
+}
+
+func truncate(s string, runes int) string {
+	if utf8.RuneCountInString(s) <= runes {
+		return s
+	}
+	return string([]rune(s)[:runes]) + "…"
+}
+
+type Comment struct {
+	Author    string    `json:"author"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type FieldType string
+
+const (
+	FieldText         FieldType = "text"
+	FieldNumber       FieldType = "number"
+	FieldDate         FieldType = "date"
+	FieldSingleSelect FieldType = "singleSelect"
+	FieldIteration    FieldType = "iteration"
+	FieldUsers        FieldType = "users"
+	FieldMilestone    FieldType = "milestone"
+)
+
+// FieldValue is a typed value of the project's custom field.
+// Only members related to the `Type` are set
+type FieldValue struct {
+	Type      FieldType  `json:"type"`
+	Text      string     `json:"text,omitempty"`
+	Number    *float64   `json:"number,omitempty"`
+	Date      *time.Time `json:"date,omitempty"`
+	Iteration *Iteration `json:"iteration,omitempty"`
+	Users     []string   `json:"users,omitempty"`
+	Milestone *Milestone `json:"milestone,omitempty"`
+}
+
+type Iteration struct {
+	Title     string    `json:"title"`
+	StartDate time.Time `json:"startDate"`
+	// Duration in days
+	Duration int `json:"duration"`
+}
+
+type Milestone struct {
+	Title string     `json:"title"`
+	DueOn *time.Time `json:"dueOn,omitempty"`
+}
+
+// FieldValueNode is a raw union of all supported `ProjectV2ItemFieldValue` types
+type FieldValueNode struct {
+	Typename  string         `json:"__typename"`
+	Name      string         `json:"name"`
+	Text      string         `json:"text"`
+	Number    *float64       `json:"number"`
+	Date      string         `json:"date"`
+	Title     string         `json:"title"`
+	StartDate string         `json:"startDate"`
+	Duration  int            `json:"duration"`
+	Users     userConnection `json:"users"`
+	Milestone *struct {
+		Title string     `json:"title"`
+		DueOn *time.Time `json:"dueOn"`
+	} `json:"milestone"`
+	Field struct {
+		Name string `json:"name"`
+	} `json:"field"`
+}
+
+// Parse converts raw node into the field's name and typed value.
+// Returns false for unsupported field types (labels, repository, etc.)
+func (n FieldValueNode) Parse() (name string, value FieldValue, ok bool) {
+	name = n.Field.Name
+	switch n.Typename {
+	case "ProjectV2ItemFieldSingleSelectValue":
+		value = FieldValue{Type: FieldSingleSelect, Text: n.Name}
+	case "ProjectV2ItemFieldTextValue":
+		value = FieldValue{Type: FieldText, Text: n.Text}
+	case "ProjectV2ItemFieldNumberValue":
+		value = FieldValue{Type: FieldNumber, Number: n.Number}
+	case "ProjectV2ItemFieldDateValue":
+		date, err := time.Parse(time.DateOnly, n.Date)
+		if err != nil {
+			slog.Warn("failed to parse project date field", "field", name, "value", n.Date, "with", err)
+			return name, value, false
+		}
+		value = FieldValue{Type: FieldDate, Text: n.Date, Date: &date}
+	case "ProjectV2ItemFieldIterationValue":
+		start, err := time.Parse(time.DateOnly, n.StartDate)
+		if err != nil {
+			slog.Warn("failed to parse project iteration start date", "field", name, "value", n.StartDate, "with", err)
+		}
+		value = FieldValue{
+			Type: FieldIteration,
+			Text: n.Title,
+			Iteration: &Iteration{
+				Title:     n.Title,
+				StartDate: start,
+				Duration:  n.Duration,
+			},
+		}
+	case "ProjectV2ItemFieldUserValue":
+		value = FieldValue{Type: FieldUsers, Users: n.Users.logins()}
+	case "ProjectV2ItemFieldMilestoneValue":
+		if n.Milestone == nil {
+			return name, value, false
+		}
+		value = FieldValue{
+			Type: FieldMilestone,
+			Text: n.Milestone.Title,
+			Milestone: &Milestone{
+				Title: n.Milestone.Title,
+				DueOn: n.Milestone.DueOn,
+			},
+		}
+	default:
+		return name, value, false
+	}
+	return name, value, name != ""
+}
+
+type userConnection struct {
+	Nodes []struct {
+		Login string `json:"login"`
+	} `json:"nodes"`
+}
+
+func (c userConnection) logins() []string {
+	logins := make([]string, len(c.Nodes))
+	for i, node := range c.Nodes {
+		logins[i] = node.Login
+	}
+	return logins
 }
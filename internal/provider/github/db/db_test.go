@@ -1,18 +1,26 @@
 package db
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 func TestQueryProjectV2(t *testing.T) {
 	client := New("https://api.github.com/graphql")
 	since := time.Now().Add(-24 * time.Hour)
-	issues, err := client.GetOrgProject(t.Context(), "cyber-valley", 3, since)
+	issues, err := client.GetOrgProject(t.Context(), "cyber-valley", 3, since, time.Time{})
 	if err != nil {
 		t.Error(err)
 	}
-	t.Logf("issues length %d, value %s", len(issues), issues)
+	t.Logf("issues length %d, value %v", len(issues), issues)
 }
 
 func TestListProjects(t *testing.T) {
@@ -23,3 +31,120 @@ func TestListProjects(t *testing.T) {
 	}
 	t.Logf("projects length %d, values %#v", len(projects), projects)
 }
+
+func TestFieldValueNode_Parse(t *testing.T) {
+	blob := `[
+		{"__typename": "ProjectV2ItemFieldSingleSelectValue", "name": "In Progress", "field": {"name": "Status"}},
+		{"__typename": "ProjectV2ItemFieldNumberValue", "number": 12.5, "field": {"name": "Amount"}},
+		{"__typename": "ProjectV2ItemFieldDateValue", "date": "2025-05-01", "field": {"name": "Due"}},
+		{"__typename": "ProjectV2ItemFieldTextValue", "text": "kg", "field": {"name": "Unit"}},
+		{"__typename": "ProjectV2ItemFieldIterationValue", "title": "Sprint 3", "startDate": "2025-04-28", "duration": 14, "field": {"name": "Iteration"}},
+		{"__typename": "ProjectV2ItemFieldUserValue", "users": {"nodes": [{"login": "foo"}, {"login": "bar"}]}, "field": {"name": "Reviewers"}},
+		{"__typename": "ProjectV2ItemFieldMilestoneValue", "milestone": {"title": "v1", "dueOn": "2025-06-01T00:00:00Z"}, "field": {"name": "Milestone"}},
+		{"__typename": "ProjectV2ItemFieldLabelValue", "field": {"name": "Labels"}},
+		{}
+	]`
+	var nodes []FieldValueNode
+	if err := json.Unmarshal([]byte(blob), &nodes); err != nil {
+		t.Fatalf("failed to unmarshal nodes with %s", err)
+	}
+
+	fields := make(map[string]FieldValue)
+	for _, node := range nodes {
+		name, value, ok := node.Parse()
+		if !ok {
+			continue
+		}
+		fields[name] = value
+	}
+	if len(fields) != 7 {
+		t.Errorf("expected 7 parsed fields, got %d: %#v", len(fields), fields)
+	}
+
+	if amount := fields["Amount"]; amount.Type != FieldNumber || amount.Number == nil || *amount.Number != 12.5 {
+		t.Errorf("expected amount 12.5, got %#v", amount)
+	}
+	if due := fields["Due"]; due.Date == nil || !due.Date.Equal(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected due date 2025-05-01, got %#v", due)
+	}
+	if unit := fields["Unit"]; unit.Type != FieldText || unit.Number != nil {
+		t.Errorf("text field shouldn't be parsed as a number, got %#v", unit)
+	}
+	if status := fields["Status"].Text; status != "In Progress" {
+		t.Errorf("expected status 'In Progress', got '%s'", status)
+	}
+	if iteration := fields["Iteration"].Iteration; iteration == nil || iteration.Duration != 14 {
+		t.Errorf("unexpected iteration %#v", iteration)
+	}
+	if users := fields["Reviewers"].Users; !slices.Equal(users, []string{"foo", "bar"}) {
+		t.Errorf("unexpected users %#v", users)
+	}
+	if milestone := fields["Milestone"].Milestone; milestone == nil || milestone.Title != "v1" || milestone.DueOn == nil {
+		t.Errorf("unexpected milestone %#v", milestone)
+	}
+}
+
+func TestGetOrgProject_ContentTypes(t *testing.T) {
+	blob := `{"organization": {"projectV2": {"items": {"nodes": [
+		{"createdAt": "2025-09-02T00:00:00Z", "content": {"__typename": "Issue", "title": "issue", "state": "OPEN", "createdAt": "2025-09-01T00:00:00Z"}},
+		{"content": {"__typename": "PullRequest", "title": "pr", "prState": "MERGED", "merged": true, "mergeable": "UNKNOWN",
+			"reviewDecision": "APPROVED", "closingIssuesReferences": {"nodes": [{"number": 1, "title": "issue"}]}}},
+		{"content": {"__typename": "DraftIssue", "title": "draft", "body": "text"}},
+		{"content": null}
+	]}}}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data": %s}`, blob)
+	}))
+	defer server.Close()
+
+	client := &Client{Endpoint: server.URL, HTTP: server.Client()}
+	issues, err := client.GetOrgProject(t.Context(), "cyber-valley", 1, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []ContentType{ContentIssue, ContentPullRequest, ContentDraftIssue, ContentRedacted}
+	if len(issues) != len(expected) {
+		t.Fatalf("expected %d issues, got %d", len(expected), len(issues))
+	}
+	for i, issue := range issues {
+		if issue.Type != expected[i] {
+			t.Errorf("expected type %s, got %s", expected[i], issue.Type)
+		}
+	}
+
+	issue := issues[0]
+	if !issue.CreatedAt.Equal(time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)) || !issue.AddedAt.Equal(time.Date(2025, 9, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected issue creation and addition to the project dates, got %#v", issue)
+	}
+
+	pr := issues[1]
+	if pr.State != "MERGED" || pr.PullRequest == nil || !pr.PullRequest.Merged || len(pr.PullRequest.LinkedIssues) != 1 {
+		t.Errorf("unexpected pull request %#v", pr)
+	}
+	if draft := issues[2]; draft.State != "DRAFT" || draft.Body != "text" || draft.PullRequest != nil {
+		t.Errorf("unexpected draft issue %#v", draft)
+	}
+}
+
+func TestIssue_Brief(t *testing.T) {
+	issue := Issue{
+		Title:     "Supply",
+		Body:      strings.Repeat("я", briefRunes+10),
+		CreatedAt: time.Now(),
+	}
+	for i := range 5 {
+		issue.Comments = append(issue.Comments, Comment{Author: "foo", Body: strconv.Itoa(i)})
+	}
+	brief := issue.Brief()
+	if n := utf8.RuneCountInString(brief.Body); n != briefRunes+1 {
+		t.Errorf("expected body truncated to %d runes, got %d", briefRunes+1, n)
+	}
+	var bodies []string
+	for _, comment := range brief.Comments {
+		bodies = append(bodies, comment.Body)
+	}
+	if !slices.Equal(bodies, []string{"2", "3", "4"}) {
+		t.Errorf("expected the latest comments, got %v", bodies)
+	}
+}
@@ -0,0 +1,63 @@
+package logseq
+
+import (
+	"slices"
+
+	"mimi/internal/provider/logseq/outline"
+)
+
+// BlockInfo is an outline block flattened in the depth first order
+type BlockInfo struct {
+	Position int
+	// Position of the parent block, -1 for the top level ones
+	Parent int
+	// From the `id::` property, empty when the block isn't referenced
+	UUID    string
+	Content string
+	Props   []Property
+	// Page, tag and block references of the content and properties
+	Refs []Ref
+}
+
+// ParseBlocks flattens the page outline, headlines are blocks of Org pages
+func ParseBlocks(path, content string) []BlockInfo {
+	if isOrgFile(path) {
+		return parseOrgBlocks(content)
+	}
+
+	var blocks []BlockInfo
+	var walk func(children []*outline.Block, parent int)
+	walk = func(children []*outline.Block, parent int) {
+		for _, b := range children {
+			info := BlockInfo{
+				Position: len(blocks),
+				Parent:   parent,
+				UUID:     b.ID(),
+				Content:  b.Content(),
+				Refs:     ParseRefs(b.Content()),
+			}
+			for _, prop := range b.Properties {
+				values := ParsePropertyValues(prop.Value)
+				info.Props = append(info.Props, Property{
+					Name:   prop.Name,
+					Values: values,
+					Level:  BlockLevel,
+					Raw:    prop.Value,
+				})
+				// Block's own UUID isn't a reference
+				if prop.Name == "id" {
+					continue
+				}
+				for _, ref := range values {
+					if ref.Kind != RefText && !slices.Contains(info.Refs, ref) {
+						info.Refs = append(info.Refs, ref)
+					}
+				}
+			}
+			blocks = append(blocks, info)
+			walk(b.Children, info.Position)
+		}
+	}
+	walk(outline.Parse(content).Blocks, -1)
+	return blocks
+}
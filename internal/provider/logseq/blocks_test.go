@@ -0,0 +1,54 @@
+package logseq
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseBlocks(t *testing.T) {
+	content := "tags:: species\n\n- Grows in [[Mexico]]\n\t- Needs #shade\n\t  id:: 6650f2c4-9d5b-4a7e-8d0e-2f3c4b5a6d7e\n- See ((6650f2c4-9d5b-4a7e-8d0e-2f3c4b5a6d7e))\n  source:: [[fern]]"
+	blocks := ParseBlocks("pages/damiana.md", content)
+
+	expected := []BlockInfo{
+		{Position: 0, Parent: -1, Content: "Grows in [[Mexico]]", Refs: []Ref{{Kind: RefPage, Target: "Mexico"}}},
+		{Position: 1, Parent: 0, UUID: "6650f2c4-9d5b-4a7e-8d0e-2f3c4b5a6d7e", Content: "Needs #shade", Refs: []Ref{{Kind: RefTag, Target: "shade"}}},
+		{Position: 2, Parent: -1, Content: "See ((6650f2c4-9d5b-4a7e-8d0e-2f3c4b5a6d7e))", Refs: []Ref{
+			{Kind: RefBlock, Target: "6650f2c4-9d5b-4a7e-8d0e-2f3c4b5a6d7e"},
+			{Kind: RefPage, Target: "fern"},
+		}},
+	}
+	if !slices.EqualFunc(blocks, expected, func(lhs, rhs BlockInfo) bool {
+		return lhs.Position == rhs.Position && lhs.Parent == rhs.Parent && lhs.UUID == rhs.UUID &&
+			lhs.Content == rhs.Content && slices.Equal(lhs.Refs, rhs.Refs)
+	}) {
+		t.Errorf("expected %#v, got %#v", expected, blocks)
+	}
+	if len(blocks) == 3 && (len(blocks[2].Props) != 1 || blocks[2].Props[0].Name != "source") {
+		t.Errorf("unexpected block properties %#v", blocks[2].Props)
+	}
+
+}
+
+func TestParseBlocks_Org(t *testing.T) {
+	content := "#+title: Orchard\n\n* TODO Plant [[fern]] :spring:\n  :PROPERTIES:\n  :id: 6650f2c4-9d5b-4a7e-8d0e-2f3c4b5a6d7e\n  :season: [[summer]]\n  :END:\n  Under the apple trees\n** Notes\n   #+BEGIN_SRC clojure\n   [[not a page]]\n   #+END_SRC\n* Harvest"
+	blocks := ParseBlocks("pages/orchard.org", content)
+
+	expected := []BlockInfo{
+		{Position: 0, Parent: -1, UUID: "6650f2c4-9d5b-4a7e-8d0e-2f3c4b5a6d7e", Content: "TODO Plant [[fern]] :spring:\nUnder the apple trees", Refs: []Ref{
+			{Kind: RefTag, Target: "spring"},
+			{Kind: RefPage, Target: "fern"},
+			{Kind: RefPage, Target: "summer"},
+		}},
+		{Position: 1, Parent: 0, Content: "Notes\n#+BEGIN_SRC clojure\n[[not a page]]\n#+END_SRC"},
+		{Position: 2, Parent: -1, Content: "Harvest"},
+	}
+	if !slices.EqualFunc(blocks, expected, func(lhs, rhs BlockInfo) bool {
+		return lhs.Position == rhs.Position && lhs.Parent == rhs.Parent && lhs.UUID == rhs.UUID &&
+			lhs.Content == rhs.Content && slices.Equal(lhs.Refs, rhs.Refs)
+	}) {
+		t.Errorf("expected %#v, got %#v", expected, blocks)
+	}
+	if len(blocks) == 3 && (len(blocks[0].Props) != 2 || blocks[0].Props[1].Name != "season") {
+		t.Errorf("unexpected block properties %#v", blocks[0].Props)
+	}
+}
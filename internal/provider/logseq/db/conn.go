@@ -0,0 +1,53 @@
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cozodb/cozo-lib-go"
+)
+
+const (
+	// One of "mem", "sqlite" or "rocksdb", defaults to "mem"
+	EngineEnv = "COZO_ENGINE"
+	// Database file for SQLite or directory for RocksDB
+	PathEnv = "COZO_PATH"
+)
+
+// Connect opens CozoDB configured by the environment and migrates its schema
+func Connect() (cozo.CozoDB, error) {
+	conn, err := Open(os.Getenv(EngineEnv), os.Getenv(PathEnv))
+	if err != nil {
+		return conn, err
+	}
+	if err := New(conn).Migrate(); err != nil {
+		conn.Close()
+		return conn, fmt.Errorf("failed to migrate CozoDB with %w", err)
+	}
+	return conn, nil
+}
+
+// Open opens CozoDB with the storage `engine`, durable ones require `path`
+func Open(engine, path string) (cozo.CozoDB, error) {
+	switch engine {
+	case "", "mem":
+		engine, path = "mem", ""
+	case "sqlite", "rocksdb":
+		if path == "" {
+			return cozo.CozoDB{}, fmt.Errorf("%s engine requires %s", engine, PathEnv)
+		}
+		// RocksDB creates its directory, but not the parent one
+		if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+			return cozo.CozoDB{}, fmt.Errorf("failed to create CozoDB directory with %w", err)
+		}
+	default:
+		return cozo.CozoDB{}, fmt.Errorf("unsupported CozoDB engine '%s'", engine)
+	}
+
+	conn, err := cozo.New(engine, path, nil)
+	if err != nil {
+		return conn, fmt.Errorf("failed to open %s CozoDB with %w", engine, err)
+	}
+	return conn, nil
+}
@@ -1,16 +1,18 @@
 package db
 
 import (
-	"encoding/json"
-	"errors"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log/slog"
-	"slices"
 	"strings"
 
 	"github.com/cozodb/cozo-lib-go"
 )
 
+// Dimension of the page embeddings
+const EmbeddingDim = 1536
+
 type Queries struct {
 	db cozo.CozoDB
 }
@@ -21,100 +23,179 @@ func New(db cozo.CozoDB) *Queries {
 	}
 }
 
-func (q *Queries) CreateRelations() error {
-	var errs []error
+type SavePageParams struct {
+	Title   string
+	Content string
+	// Values of the page and its blocks properties
+	Props []SavePropParams
+	// Resolved titles of the referenced pages and tags
+	Refs    []string
+	Tags    []string
+	Aliases []string
+	// Journal's day as yyyymmdd number, zero for regular pages
+	JournalDay int
+	Blocks     []SaveBlockParams
+}
 
-	res, err := q.db.Run("::relations", nil, true)
-	if err != nil {
-		return fmt.Errorf("failed to get relations list with %w", err)
+type SavePropParams struct {
+	Name  string
+	Value string
+	// Text values are matched exactly, others are page titles
+	Text bool
+}
+
+type SaveBlockParams struct {
+	Position int
+	// Position of the parent block, -1 for the top level ones
+	Parent  int
+	UUID    string
+	Content string
+	Props   map[string][]string
+	// Resolved titles of the referenced pages and tags
+	PageRefs []string
+	// UUIDs of the referenced blocks
+	BlockRefs []string
+}
+
+// Relations holding the page data with their key columns,
+// `title` is the column of the page title
+var pageRelations = []struct {
+	name  string
+	title string
+	keys  string
+}{
+	{"page_prop", "page_title", "page_title, name, value"},
+	{"page_ref", "src", "src, target"},
+	{"page_tag", "page_title", "page_title, tag"},
+	{"page_alias", "page_title", "alias, page_title"},
+	{"journal", "page_title", "page_title"},
+	{"block", "page_title", "page_title, position"},
+	{"block_prop", "page_title", "page_title, position, name, value"},
+	{"block_ref", "page_title", "page_title, position, kind, target"},
+}
+
+// deletePageData returns statements removing rows related to the `$title` page
+func deletePageData() []string {
+	tx := make([]string, len(pageRelations))
+	for i, rel := range pageRelations {
+		tx[i] = fmt.Sprintf(
+			`?[%s] := *%s{%s}, %s = $title :rm %s{%s}`,
+			rel.keys, rel.name, rel.keys, rel.title, rel.name, rel.keys,
+		)
 	}
-	relations := make([]string, len(res.Rows))
-	for i, row := range res.Rows {
-		relations[i] = row[0].(string)
+	return tx
+}
+
+// SavePage saves or updates page replacing its blocks, properties and references atomically
+func (q *Queries) SavePage(p SavePageParams) error {
+	sum := sha256.Sum256([]byte(p.Content))
+	params := cozo.Map{
+		"title":   p.Title,
+		"content": p.Content,
+		"hash":    hex.EncodeToString(sum[:]),
+	}
+	tx := append(
+		deletePageData(),
+		`?[title, content, hash] <- [[$title, $content, $hash]] :put page{title => content, hash}`,
+	)
+	// Constant rules can't be empty, statements are added for the present rows only
+	put := func(name string, rows [][]any, query string) {
+		if len(rows) == 0 {
+			return
+		}
+		params[name] = rows
+		tx = append(tx, query)
 	}
 
-	schema := map[string]string{
-		"page": `:create page {
-			title: String
-			=>
-			content: String,
+	var props, refs, tags, aliases [][]any
+	for _, prop := range p.Props {
+		props = append(props, []any{p.Title, prop.Name, prop.Value, prop.Text})
+	}
+	for _, ref := range p.Refs {
+		refs = append(refs, []any{p.Title, ref})
+	}
+	for _, tag := range p.Tags {
+		tags = append(tags, []any{p.Title, tag})
+	}
+	for _, alias := range p.Aliases {
+		aliases = append(aliases, []any{alias, p.Title})
+	}
+	put("props", props, `?[page_title, name, value, text] <- $props :put page_prop{page_title, name, value => text}`)
+	put("refs", refs, `?[src, target] <- $refs :put page_ref{src, target}`)
+	put("tags", tags, `?[page_title, tag] <- $tags :put page_tag{page_title, tag}`)
+	put("aliases", aliases, `?[alias, page_title] <- $aliases :put page_alias{alias, page_title}`)
+	if p.JournalDay != 0 {
+		put("journal", [][]any{{p.Title, p.JournalDay}}, `?[page_title, day] <- $journal :put journal{page_title => day}`)
+	}
+
+	var blocks, blockProps, blockRefs [][]any
+	for _, b := range p.Blocks {
+		blocks = append(blocks, []any{p.Title, b.Position, b.Parent, b.UUID, b.Content})
+		for name, values := range b.Props {
+			for _, value := range values {
+				blockProps = append(blockProps, []any{p.Title, b.Position, name, value})
+			}
 		}
-		`,
-		"page_ref": `:create page_ref {
-			src: String,
-			target: String
-		}`,
-		"page_prop": `:create page_prop {
-			page_title: String,
-			name: String,
-			value: String
-		}`,
-	}
-
-	for name, query := range schema {
-		if slices.Contains(relations, name) {
-			slog.Info("relation already exists", "name", name)
-			continue
+		for _, ref := range b.PageRefs {
+			blockRefs = append(blockRefs, []any{p.Title, b.Position, "page", ref})
 		}
-		_, err = q.db.Run(query, nil, false)
-		if err != nil {
-			errs = append(errs, err)
+		for _, ref := range b.BlockRefs {
+			blockRefs = append(blockRefs, []any{p.Title, b.Position, "block", ref})
 		}
 	}
+	put("blocks", blocks, `?[page_title, position, parent, uuid, content] <- $blocks :put block{page_title, position => parent, uuid, content}`)
+	put("block_props", blockProps, `?[page_title, position, name, value] <- $block_props :put block_prop{page_title, position, name, value}`)
+	put("block_refs", blockRefs, `?[page_title, position, kind, target] <- $block_refs :put block_ref{page_title, position, kind, target}`)
 
-	return errors.Join(errs...)
+	if err := execTx(q.db, tx, params); err != nil {
+		return fmt.Errorf("failed to save or update page '%s' with %w", p.Title, err)
+	}
+	return nil
 }
 
-type SavePageParams struct {
-	Title   string
-	Content string
-	Props   map[string]string
-	Refs    []string
+// DeletePage removes page with its blocks, properties, references and embedding
+func (q *Queries) DeletePage(title string) error {
+	tx := append(
+		deletePageData(),
+		`?[title] <- [[$title]] :rm page{title}`,
+		`?[title] <- [[$title]] :rm page_embedding{title}`,
+	)
+	if err := execTx(q.db, tx, cozo.Map{"title": title}); err != nil {
+		return fmt.Errorf("failed to delete page '%s' with %w", title, err)
+	}
+	return nil
 }
 
-func (q *Queries) SavePage(p SavePageParams) error {
-	var tx []string
-
-	// Save or update page
-	tx = append(tx, fmt.Sprintf(
-		`?[title, content] <- [[%s,%s]] :put page{title, content}`,
-		escape(p.Title),
-		escape(p.Content),
-	))
-
-	// Save or update page properties
-	if len(p.Props) > 0 {
-		for name, value := range p.Props {
-			tx = append(
-				tx,
-				fmt.Sprintf(
-					`?[page_title, name, value] <- [[%s,%s,%s]] :put page_prop{page_title, name, value}`,
-					escape(p.Title),
-					escape(name),
-					escape(value),
-				),
-			)
-		}
+// DeleteOrphanEmbeddings removes embeddings of the missing pages
+func (q *Queries) DeleteOrphanEmbeddings() error {
+	query := `?[title] := *page_embedding{title}, not *page{title} :rm page_embedding{title}`
+	if _, err := q.db.Run(query, nil, false); err != nil {
+		return fmt.Errorf("failed to delete orphan embeddings with %w", err)
 	}
+	return nil
+}
 
-	// Save or update references
-	if len(p.Refs) > 0 {
-		for _, ref := range p.Refs {
-			tx = append(
-				tx,
-				fmt.Sprintf(
-					`?[src, target] <- [[%s,%s]] :put page_ref{src, target}`,
-					escape(p.Title),
-					escape(ref),
-				),
-			)
-		}
+// FindSyncCommit returns the last commit of the source persisted in the database
+func (q *Queries) FindSyncCommit(source string) (commit string, found bool, _ error) {
+	query := `?[commit] := *sync_state{source: $source, commit}`
+	res, err := q.db.Run(query, cozo.Map{"source": source}, true)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to find sync commit of '%s' with %w", source, err)
+	}
+	if len(res.Rows) == 0 {
+		return "", false, nil
 	}
+	return res.Rows[0][0].(string), true, nil
+}
 
-	// Execute queries in transaction
-	err := execTx(q.db, tx)
-	if err != nil {
-		return fmt.Errorf("failed to save or update page '%s' with %w", p.Title, err)
+func (q *Queries) SaveSyncCommit(source, commit string) error {
+	query := `?[source, commit] <- [[$source, $commit]] :put sync_state{source => commit}`
+	params := cozo.Map{
+		"source": source,
+		"commit": commit,
+	}
+	if _, err := q.db.Run(query, params, false); err != nil {
+		return fmt.Errorf("failed to save sync commit of '%s' with %w", source, err)
 	}
 	return nil
 }
@@ -122,64 +203,163 @@ func (q *Queries) SavePage(p SavePageParams) error {
 type FindRelativesRow struct {
 	Title   string
 	Content string
+	Depth   int
 }
 
-// Page titles that are relative to the given one via ref
+// Pages that are relative to the given one via ref ordered by depth
 func (q *Queries) FindRelatives(pageTitle string, depth int) (rows []FindRelativesRow, err error) {
-	query := fmt.Sprintf(
-		`
+	query := `
 		relatives[target, depth] :=
-			*page_ref{src: %s, target},
+			*page_ref{src: $title, target},
 			depth = 1
 
 		relatives[target, depth] :=
 				relatives[new_src, d],
 				*page_ref{src: new_src, target},
 				depth = d + 1,
-				depth <= %d
+				depth <= $depth
 
-		?[target, depth] :=
+		?[target, content, min(depth)] :=
 				relatives[target, depth],
-				*page{title: target}
-		`,
-		escape(pageTitle),
-		depth,
-	)
-	res, err := q.db.Run(query, nil, true)
+				*page{title: target, content}
+
+		:order depth
+		`
+	params := cozo.Map{
+		"title": pageTitle,
+		"depth": depth,
+	}
+	res, err := q.db.Run(query, params, true)
 	if err != nil {
 		return rows, fmt.Errorf("failed to find relatives for '%s' with %w", pageTitle, err)
 	}
 	for _, row := range res.Rows {
 		rows = append(rows, FindRelativesRow{
-			Title: row[0].(string),
+			Title:   row[0].(string),
+			Content: row[1].(string),
+			Depth:   toInt(row[2]),
 		})
 	}
 	return rows, nil
 }
 
+// FindPageHashes returns content hashes of all pages by their titles
+func (q *Queries) FindPageHashes() (map[string]string, error) {
+	res, err := q.db.Run("?[title, hash] := *page{title, hash}", nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find page hashes with %w", err)
+	}
+	hashes := make(map[string]string, len(res.Rows))
+	for _, row := range res.Rows {
+		hashes[row[0].(string)] = row[1].(string)
+	}
+	return hashes, nil
+}
+
+type FindPagesRow struct {
+	Title   string
+	Content string
+	Hash    string
+}
+
+// FindPages returns pages with the given titles
+func (q *Queries) FindPages(titles []string) (pages []FindPagesRow, _ error) {
+	query := `?[title, content, hash] := title in $titles, *page{title, content, hash}`
+	res, err := q.db.Run(query, cozo.Map{"titles": titles}, true)
+	if err != nil {
+		return pages, fmt.Errorf("failed to find pages with %w", err)
+	}
+	for _, row := range res.Rows {
+		pages = append(pages, FindPagesRow{
+			Title:   row[0].(string),
+			Content: row[1].(string),
+			Hash:    row[2].(string),
+		})
+	}
+	return pages, nil
+}
+
+type FindBlocksRow struct {
+	PageTitle string
+	Position  int
+	Content   string
+}
+
+// FindBlocks returns blocks of the given pages in their order
+func (q *Queries) FindBlocks(titles []string) (rows []FindBlocksRow, _ error) {
+	query := `?[page_title, position, content] := page_title in $titles, *block{page_title, position, content}
+		:order page_title, position`
+	res, err := q.db.Run(query, cozo.Map{"titles": titles}, true)
+	if err != nil {
+		return rows, fmt.Errorf("failed to find blocks with %w", err)
+	}
+	for _, row := range res.Rows {
+		rows = append(rows, FindBlocksRow{
+			PageTitle: row[0].(string),
+			Position:  toInt(row[1]),
+			Content:   row[2].(string),
+		})
+	}
+	return rows, nil
+}
+
+type SavePageEmbeddingParams struct {
+	Title string
+	// Hash of the embedded content to skip unchanged pages
+	Hash      string
+	Embedding []float32
+}
+
+func (q *Queries) SavePageEmbedding(p SavePageEmbeddingParams) error {
+	query := `?[title, hash, embedding] <- [[$title, $hash, vec($embedding)]] :put page_embedding{title => hash, embedding}`
+	params := cozo.Map{
+		"title":     p.Title,
+		"hash":      p.Hash,
+		"embedding": p.Embedding,
+	}
+	if _, err := q.db.Run(query, params, false); err != nil {
+		return fmt.Errorf("failed to save embedding of '%s' with %w", p.Title, err)
+	}
+	return nil
+}
+
+// FindPageEmbeddingHash returns hash of the page content embedded last time
+func (q *Queries) FindPageEmbeddingHash(title string) (hash string, found bool, _ error) {
+	query := `?[hash] := *page_embedding{title: $title, hash}`
+	res, err := q.db.Run(query, cozo.Map{"title": title}, true)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to find embedding hash of '%s' with %w", title, err)
+	}
+	if len(res.Rows) == 0 {
+		return "", false, nil
+	}
+	return res.Rows[0][0].(string), true, nil
+}
+
 type SimilarPageRow struct {
 	Dist    float64
 	Title   string
 	Content string
 }
 
-func (q *Queries) FindSimilarPages(vec []float32) (pages []SimilarPageRow, _ error) {
+func (q *Queries) FindSimilarPages(vec []float32, limit int) (pages []SimilarPageRow, _ error) {
 	query := fmt.Sprintf(
 		`
-		?[dist, title, content] := 
-			~page:embedding_index3{title, content |
+		?[dist, title, content] :=
+			~page_embedding:embedding_index{title |
 				query: q,
 				k: 50,
 				ef: 20,
 				bind_distance: dist
-			}, 
-			q = vec(%s)
+			},
+			*page{title, content},
+			q = vec($vec)
 		:order dist
-		:limit 20
+		:limit %d
 		`,
-		escapeSlice(vec),
+		limit,
 	)
-	res, err := q.db.Run(query, nil, false)
+	res, err := q.db.Run(query, cozo.Map{"vec": vec}, true)
 	if err != nil {
 		return pages, fmt.Errorf("failed to find similar pages with %w", err)
 	}
@@ -209,27 +389,50 @@ func (q *Queries) FindTitles() (titles []string, _ error) {
 	return titles, nil
 }
 
-func escape(s string) string {
-	// Weird escaping happens if double quotes pased into the sequence somehow
-	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(s, `"`, "'"))
+// FindTitlesByRules runs the compiled rules whose entry is `?[title]`
+func (q *Queries) FindTitlesByRules(rules string, params cozo.Map) (titles []string, _ error) {
+	res, err := q.db.Run(rules, params, true)
+	if err != nil {
+		return titles, fmt.Errorf("failed to find titles by rules with %w", err)
+	}
+	for _, row := range res.Rows {
+		titles = append(titles, row[0].(string))
+	}
+	return titles, nil
 }
 
-func escapeSlice[T any](s []T) string {
-	b, err := json.Marshal(s)
+// IsSynced reports whether any graph was fully synced,
+// it's false until the first rebuild of the new database completes
+func (q *Queries) IsSynced() (bool, error) {
+	res, err := q.db.Run("?[source] := *sync_state{source} :limit 1", nil, true)
 	if err != nil {
-		panic(err)
+		return false, fmt.Errorf("failed to check sync state with %w", err)
+	}
+	return len(res.Rows) > 0, nil
+}
+
+// toInt converts Cozo's JSON number to int
+func toInt(v any) int {
+	switch n := v.(type) {
+	case float64:
+		return int(n)
+	case int64:
+		return int(n)
+	case int:
+		return n
+	default:
+		return 0
 	}
-	return string(b)
 }
 
-func execTx(db cozo.CozoDB, queries []string) error {
-	wrapped := make([]string, len(queries))
+// execTx runs statements in a single transaction sharing the `params`
+func execTx(db cozo.CozoDB, queries []string, params cozo.Map) error {
+	blocks := make([]string, len(queries))
 	for i, q := range queries {
-		wrapped[i] = fmt.Sprintf("{%s}", q)
-		_, err := db.Run(q, nil, false)
-		if err != nil {
-			return fmt.Errorf("failed to execute transaction query '%s' with %w", q, err)
-		}
+		blocks[i] = fmt.Sprintf("{%s}", q)
+	}
+	if _, err := db.Run(strings.Join(blocks, "\n"), params, false); err != nil {
+		return fmt.Errorf("failed to execute transaction of %d queries with %w", len(queries), err)
 	}
 	return nil
 }
@@ -0,0 +1,158 @@
+package db
+
+import (
+	"slices"
+	"testing"
+)
+
+// open creates migrated in-memory database
+func open(t *testing.T) *Queries {
+	t.Helper()
+	conn, err := Open("mem", "")
+	if err != nil {
+		t.Skipf("CozoDB engine is unavailable: %s", err)
+	}
+	t.Cleanup(conn.Close)
+	q := New(conn)
+	if err := q.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return q
+}
+
+func TestMigrate_Legacy(t *testing.T) {
+	conn, err := Open("mem", "")
+	if err != nil {
+		t.Skipf("CozoDB engine is unavailable: %s", err)
+	}
+	t.Cleanup(conn.Close)
+	q := New(conn)
+
+	// Relations created before versioning
+	legacy := []string{
+		`:create page { title: String => content: String }`,
+		`:create page_prop { page_title: String, name: String, value: String }`,
+		`?[title, content] <- [["fern", "rating:: 5"]] :put page{title, content}`,
+	}
+	for _, query := range legacy {
+		if _, err := conn.Run(query, nil, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := q.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	// Pages are restored by the rebuild
+	if titles, err := q.FindTitles(); err != nil || len(titles) != 0 {
+		t.Fatalf("expected legacy pages to be removed, got %v, %v", titles, err)
+	}
+	props := []SavePropParams{{Name: "rating", Value: "5", Text: true}}
+	if err := q.SavePage(SavePageParams{Title: "fern", Content: "rating:: 5", Props: props}); err != nil {
+		t.Fatal(err)
+	}
+	if synced, err := q.IsSynced(); err != nil || synced {
+		t.Errorf("expected sync to be pending, got %v, %v", synced, err)
+	}
+	if v, err := q.SchemaVersion(); err != nil || v != len(migrations) {
+		t.Errorf("expected version %d, got %d, %v", len(migrations), v, err)
+	}
+}
+
+func TestSavePage(t *testing.T) {
+	q := open(t)
+	pages := []SavePageParams{
+		{
+			Title:   "fern",
+			Content: "tags:: species\nalias:: Polypodiopsida\n\n- Grows near [[damiana]]\n- Needs shade",
+			Props:   []SavePropParams{{Name: "tags", Value: "species"}, {Name: "alias", Value: "Polypodiopsida"}},
+			Refs:    []string{"damiana", "species"},
+			Tags:    []string{"species"},
+			Aliases: []string{"Polypodiopsida"},
+			Blocks: []SaveBlockParams{
+				{Position: 0, Parent: -1, Content: "Grows near [[damiana]]", PageRefs: []string{"damiana"}},
+				{Position: 1, Parent: -1, Content: "Needs shade"},
+			},
+		},
+		{Title: "damiana", Content: "tags:: species", Tags: []string{"species"}, Refs: []string{"species"}},
+		{Title: "oak", Content: "source:: [[polypodiopsida]]", Refs: []string{"fern"}},
+		{Title: "moss", Content: "- Unrelated"},
+	}
+	for _, p := range pages {
+		if err := q.SavePage(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backlinks, err := q.FindBacklinks("damiana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []FindBacklinksRow{{Title: "fern", Position: 0, Content: "Grows near [[damiana]]"}}; !slices.Equal(backlinks, expected) {
+		t.Errorf("expected %v, got %v", expected, backlinks)
+	}
+	// Alias is resolved into the page, references without blocks are page level
+	backlinks, err = q.FindBacklinks("Polypodiopsida")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []FindBacklinksRow{{Title: "oak", Position: -1}}; !slices.Equal(backlinks, expected) {
+		t.Errorf("expected %v, got %v", expected, backlinks)
+	}
+
+	path, err := q.FindShortestPath("oak", "damiana")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"oak", "fern", "damiana"}; !slices.Equal(path, expected) {
+		t.Errorf("expected path %v, got %v", expected, path)
+	}
+	if path, err := q.FindShortestPath("oak", "moss"); err != nil || len(path) != 0 {
+		t.Errorf("expected no path to the unrelated page, got %v, %v", path, err)
+	}
+
+	hashes, err := q.FindPageHashes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := q.FindBlocks([]string{"fern", "moss"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []FindBlocksRow{
+		{PageTitle: "fern", Position: 0, Content: "Grows near [[damiana]]"},
+		{PageTitle: "fern", Position: 1, Content: "Needs shade"},
+	}; !slices.Equal(blocks, expected) {
+		t.Errorf("expected blocks %v, got %v", expected, blocks)
+	}
+
+	// Stale blocks and references are replaced
+	if err := q.SavePage(SavePageParams{Title: "fern", Content: "- Needs shade"}); err != nil {
+		t.Fatal(err)
+	}
+	found, err := q.FindPages([]string{"fern"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].Content != "- Needs shade" || found[0].Hash == hashes["fern"] {
+		t.Errorf("expected updated page with the new hash, got %v", found)
+	}
+	if backlinks, err := q.FindBacklinks("damiana"); err != nil || len(backlinks) != 0 {
+		t.Errorf("expected no backlinks after update, got %v, %v", backlinks, err)
+	}
+
+	if err := q.DeletePage("oak"); err != nil {
+		t.Fatal(err)
+	}
+	titles, err := q.FindTitles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(titles)
+	if expected := []string{"damiana", "fern", "moss"}; !slices.Equal(titles, expected) {
+		t.Errorf("expected %v, got %v", expected, titles)
+	}
+	if backlinks, err := q.FindBacklinks("fern"); err != nil || len(backlinks) != 0 {
+		t.Errorf("expected no backlinks of the deleted page, got %v, %v", backlinks, err)
+	}
+}
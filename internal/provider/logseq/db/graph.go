@@ -0,0 +1,88 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/cozodb/cozo-lib-go"
+)
+
+// Rules resolving `$title` into the page title and titles of the pages it's alias of
+const titleRules = `
+	title[t] := t = $title
+	title[t] := *page_alias{alias, page_title: t}, lowercase(alias) == lowercase($title)
+`
+
+// Undirected edges between pages formed by references and tags
+const edgeRules = `
+	edge[a, b, kind] := *page_ref{src: a, target: b}, kind = "ref"
+	edge[a, b, kind] := *page_tag{page_title: a, tag: b}, kind = "tag"
+	link[a, b] := edge[a, b, kind]
+	link[a, b] := edge[b, a, kind]
+`
+
+type FindBacklinksRow struct {
+	Title string
+	// Position of the referencing block, -1 when page references via its properties
+	Position int
+	// Referencing block content
+	Content string
+}
+
+// FindBacklinks returns blocks and pages referencing the page or its aliases
+func (q *Queries) FindBacklinks(title string) (rows []FindBacklinksRow, _ error) {
+	query := titleRules + `
+		?[src, position, content] :=
+			title[t],
+			*block_ref{page_title: src, position, kind: "page", target: t},
+			*block{page_title: src, position, content}
+
+		?[src, position, content] :=
+			title[t],
+			*page_ref{src, target: t},
+			not *block_ref{page_title: src, kind: "page", target: t},
+			position = -1,
+			content = ""
+
+		:order src, position
+	`
+	res, err := q.db.Run(query, cozo.Map{"title": title}, true)
+	if err != nil {
+		return rows, fmt.Errorf("failed to find backlinks of '%s' with %w", title, err)
+	}
+	for _, row := range res.Rows {
+		rows = append(rows, FindBacklinksRow{
+			Title:    row[0].(string),
+			Position: toInt(row[1]),
+			Content:  row[2].(string),
+		})
+	}
+	return rows, nil
+}
+
+// FindShortestPath returns titles of the pages connecting the given ones
+// via references and tags in any direction, empty when they aren't connected
+func (q *Queries) FindShortestPath(from, to string) (path []string, _ error) {
+	query := edgeRules + `
+		start[t] <- [[$from]]
+		goal[t] <- [[$to]]
+
+		?[start, goal, path] <~ ShortestPathBFS(link[], start[], goal[])
+	`
+	params := cozo.Map{
+		"from": from,
+		"to":   to,
+	}
+	res, err := q.db.Run(query, params, true)
+	if err != nil {
+		return path, fmt.Errorf("failed to find path from '%s' to '%s' with %w", from, to, err)
+	}
+	if len(res.Rows) == 0 {
+		return path, nil
+	}
+	// Path is null when goal is unreachable
+	nodes, _ := res.Rows[0][2].([]any)
+	for _, node := range nodes {
+		path = append(path, node.(string))
+	}
+	return path, nil
+}
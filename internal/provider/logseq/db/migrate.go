@@ -0,0 +1,181 @@
+package db
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// Migrations are applied in order, version is the index of the last applied one plus one.
+// Append new migrations only, never edit the applied ones
+var migrations = []func(q *Queries, relations []string) error{
+	// Initial schema, page relations may exist in databases created before versioning
+	func(q *Queries, relations []string) error {
+		// Old pages miss the content hash and properties miss the text flag,
+		// they are restored by the rebuild since such databases have no synced commits
+		for _, name := range []string{"page", "page_ref", "page_prop"} {
+			if !slices.Contains(relations, name) {
+				continue
+			}
+			if _, err := q.db.Run("::remove "+name, nil, false); err != nil {
+				return fmt.Errorf("failed to remove relation '%s' with %w", name, err)
+			}
+		}
+		relations = slices.DeleteFunc(relations, func(name string) bool {
+			return name == "page" || name == "page_ref" || name == "page_prop"
+		})
+		schema := []struct{ name, query string }{
+			// Hash of the content to reload only changed pages
+			{"page", `:create page {
+				title: String
+				=>
+				content: String,
+				hash: String
+			}`},
+			{"page_ref", `:create page_ref {
+				src: String,
+				target: String
+			}`},
+			// Text values are compared exactly instead of resolving as pages
+			{"page_prop", `:create page_prop {
+				page_title: String,
+				name: String,
+				value: String
+				=>
+				text: Bool
+			}`},
+			{"page_tag", `:create page_tag {
+				page_title: String,
+				tag: String
+			}`},
+			{"page_alias", `:create page_alias {
+				alias: String,
+				page_title: String
+			}`},
+			{"journal", `:create journal {
+				page_title: String
+				=>
+				day: Int
+			}`},
+			{"block", `:create block {
+				page_title: String,
+				position: Int
+				=>
+				parent: Int,
+				uuid: String,
+				content: String
+			}`},
+			{"block_prop", `:create block_prop {
+				page_title: String,
+				position: Int,
+				name: String,
+				value: String
+			}`},
+			{"block_ref", `:create block_ref {
+				page_title: String,
+				position: Int,
+				kind: String,
+				target: String
+			}`},
+			{"page_embedding", fmt.Sprintf(`:create page_embedding {
+				title: String
+				=>
+				hash: String,
+				embedding: <F32; %d>
+			}`, EmbeddingDim)},
+			// Last synced commit of the graph's repository
+			{"sync_state", `:create sync_state {
+				source: String
+				=>
+				commit: String
+			}`},
+		}
+		for _, rel := range schema {
+			if slices.Contains(relations, rel.name) {
+				slog.Info("relation already exists", "name", rel.name)
+				continue
+			}
+			if _, err := q.db.Run(rel.query, nil, false); err != nil {
+				return fmt.Errorf("failed to create relation '%s' with %w", rel.name, err)
+			}
+			// Index is created right after its relation
+			if rel.name != "page_embedding" {
+				continue
+			}
+			_, err := q.db.Run(fmt.Sprintf(`::hnsw create page_embedding:embedding_index {
+				dim: %d,
+				m: 50,
+				dtype: F32,
+				fields: [embedding],
+				distance: Cosine,
+				ef_construction: 20
+			}`, EmbeddingDim), nil, false)
+			if err != nil {
+				return fmt.Errorf("failed to create index for '%s' with %w", rel.name, err)
+			}
+		}
+		return nil
+	},
+}
+
+// Migrate creates or upgrades relations up to the latest schema version
+func (q *Queries) Migrate() error {
+	relations, err := q.relations()
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(relations, "schema_version") {
+		if _, err := q.db.Run(`:create schema_version { version: Int }`, nil, false); err != nil {
+			return fmt.Errorf("failed to create schema version relation with %w", err)
+		}
+	}
+
+	version, err := q.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than supported %d", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		slog.Info("applying CozoDB migration", "version", i+1)
+		// Relations are listed again since previous migrations change them
+		relations, err := q.relations()
+		if err != nil {
+			return err
+		}
+		if err := migrations[i](q, relations); err != nil {
+			return fmt.Errorf("failed to apply migration %d with %w", i+1, err)
+		}
+		query := fmt.Sprintf(`?[version] <- [[%d]] :put schema_version{version}`, i+1)
+		if _, err := q.db.Run(query, nil, false); err != nil {
+			return fmt.Errorf("failed to save schema version %d with %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// SchemaVersion returns the last applied migration, zero for the empty database
+func (q *Queries) SchemaVersion() (int, error) {
+	res, err := q.db.Run(`?[max(version)] := *schema_version{version}`, nil, true)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find schema version with %w", err)
+	}
+	if len(res.Rows) == 0 {
+		return 0, nil
+	}
+	return toInt(res.Rows[0][0]), nil
+}
+
+func (q *Queries) relations() ([]string, error) {
+	res, err := q.db.Run("::relations", nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get relations list with %w", err)
+	}
+	relations := make([]string, len(res.Rows))
+	for i, row := range res.Rows {
+		relations[i] = row[0].(string)
+	}
+	return relations, nil
+}
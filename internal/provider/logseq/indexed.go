@@ -0,0 +1,350 @@
+package logseq
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"iter"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// IndexedGraph keeps parsed pages in memory with inverted indexes,
+// it's safe for concurrent readers while refreshing
+type IndexedGraph struct {
+	graph RegexGraph
+
+	// Serializes refreshes so files are parsed once and hooks see changes in order
+	refreshMu sync.Mutex
+	// Hooks of the running watchers, guarded by refreshMu
+	subscribers map[*subscriber]bool
+
+	mu    sync.RWMutex
+	files map[string]indexedFile
+	// Pages ordered by path
+	pages    []Page
+	resolver Resolver
+	// Canonical names of titles, page tags, properties and referenced pages to page paths
+	byTitle    map[string][]string
+	byTag      map[string][]string
+	byProperty map[string][]string
+	byRef      map[string][]string
+}
+
+type subscriber struct {
+	ctx   context.Context
+	hooks []WatchHook
+}
+
+type indexedFile struct {
+	page    Page
+	modTime time.Time
+	size    int64
+	// Git blob hash of the content
+	hash string
+}
+
+// NewIndexedGraph parses all pages of the graph
+func NewIndexedGraph(g RegexGraph) *IndexedGraph {
+	ig := &IndexedGraph{
+		graph:       g,
+		files:       make(map[string]indexedFile),
+		subscribers: make(map[*subscriber]bool),
+	}
+	ig.Refresh()
+	return ig
+}
+
+// Source returns the graph pages are read from
+func (g *IndexedGraph) Source() RegexGraph {
+	return g.graph
+}
+
+func (g *IndexedGraph) GetConfig() Config {
+	return g.graph.Config
+}
+
+// WalkPages iterates over the snapshot of pages, so refreshes don't block the iteration
+func (g *IndexedGraph) WalkPages() iter.Seq[Page] {
+	g.mu.RLock()
+	pages := g.pages
+	g.mu.RUnlock()
+	return slices.Values(pages)
+}
+
+// Resolver returns resolver of the current pages
+func (g *IndexedGraph) Resolver() Resolver {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.resolver
+}
+
+// PagesByTitle returns pages with the title or its alias
+func (g *IndexedGraph) PagesByTitle(title string) []Page {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.pagesAt(g.byTitle[g.pageKey(title)])
+}
+
+// PagesByTag returns pages tagged with `tag` or its aliases on the page level
+func (g *IndexedGraph) PagesByTag(tag string) []Page {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.pagesAt(g.byTag[g.pageKey(tag)])
+}
+
+// PagesByProperty returns pages having the property on the page or block level
+func (g *IndexedGraph) PagesByProperty(name string) []Page {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.pagesAt(g.byProperty[PageName(name)])
+}
+
+// PagesByRef returns pages referencing the page or having it as a property value
+func (g *IndexedGraph) PagesByRef(title string) []Page {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.pagesAt(g.byRef[g.pageKey(title)])
+}
+
+// pagesAt returns pages by their paths, must be called under the lock
+func (g *IndexedGraph) pagesAt(paths []string) []Page {
+	pages := make([]Page, len(paths))
+	for i, path := range paths {
+		pages[i] = g.files[path].page
+	}
+	return pages
+}
+
+// PageChanges are pages changed by the refresh
+type PageChanges struct {
+	Changed []Page
+	// Deleted pages including the old paths and titles of the renamed ones
+	Removed []Page
+}
+
+func (c PageChanges) Empty() bool {
+	return len(c.Changed) == 0 && len(c.Removed) == 0
+}
+
+// Refresh parses files which content is changed since the last refresh and drops the deleted ones,
+// unreadable files are logged and skipped like in RegexGraph
+func (g *IndexedGraph) Refresh() PageChanges {
+	g.refreshMu.Lock()
+	defer g.refreshMu.Unlock()
+
+	g.mu.RLock()
+	files := maps.Clone(g.files)
+	g.mu.RUnlock()
+
+	var changes PageChanges
+	var touched bool
+	seen := make(map[string]bool, len(files))
+	g.graph.walkFiles(func(path string, info fs.FileInfo) error {
+		seen[path] = true
+		g.update(files, path, info, &changes, &touched)
+		return nil
+	})
+	for path, file := range files {
+		if !seen[path] {
+			delete(files, path)
+			changes.Removed = append(changes.Removed, file.page)
+		}
+	}
+
+	g.commit(files, changes, touched)
+	g.notify(changes)
+	return changes
+}
+
+// RefreshFiles refreshes only the given files, missing ones are dropped
+func (g *IndexedGraph) RefreshFiles(paths []string) PageChanges {
+	g.refreshMu.Lock()
+	defer g.refreshMu.Unlock()
+
+	g.mu.RLock()
+	files := maps.Clone(g.files)
+	g.mu.RUnlock()
+
+	var changes PageChanges
+	var touched bool
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			if file, ok := files[path]; ok {
+				delete(files, path)
+				changes.Removed = append(changes.Removed, file.page)
+			}
+		case err != nil:
+			slog.Error("failed to access path", "path", path, "with", err)
+		case info.IsDir() || !isPageFile(info.Name()):
+			continue
+		default:
+			g.update(files, path, info, &changes, &touched)
+		}
+	}
+
+	g.commit(files, changes, touched)
+	g.notify(changes)
+	return changes
+}
+
+// update reparses the file if its content is changed since the last refresh
+func (g *IndexedGraph) update(files map[string]indexedFile, path string, info fs.FileInfo, changes *PageChanges, touched *bool) {
+	old, ok := files[path]
+	if ok && old.modTime.Equal(info.ModTime()) && old.size == info.Size() {
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("failed to read page", "path", path, "with", err)
+		return
+	}
+	hash := blobHash(content)
+	if ok && old.hash == hash {
+		// Touched without changes e.g. by git checkout
+		old.modTime, old.size = info.ModTime(), info.Size()
+		files[path] = old
+		*touched = true
+		return
+	}
+
+	page, err := g.graph.parsePage(path, content)
+	if err != nil {
+		slog.Error("failed to parse page", "path", path, "with", err)
+		return
+	}
+	files[path] = indexedFile{
+		page:    page,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		hash:    hash,
+	}
+	changes.Changed = append(changes.Changed, page)
+	if ok && old.page.Title() != page.Title() {
+		// Page is retitled by the `title::` property
+		changes.Removed = append(changes.Removed, old.page)
+	}
+}
+
+// commit stores refreshed files reindexing them only on changes
+func (g *IndexedGraph) commit(files map[string]indexedFile, changes PageChanges, touched bool) {
+	if changes.Empty() && g.pages != nil {
+		if touched {
+			// Remember modification times to not hash the files again
+			g.mu.Lock()
+			g.files = files
+			g.mu.Unlock()
+		}
+		return
+	}
+	slog.Info(
+		"refreshed LogSeq graph",
+		"changed", len(changes.Changed),
+		"removed", len(changes.Removed),
+		"total", len(files),
+	)
+	g.index(files)
+}
+
+// index rebuilds indexes and swaps them with the current ones
+func (g *IndexedGraph) index(files map[string]indexedFile) {
+	pages := make([]Page, 0, len(files))
+	for _, path := range slices.Sorted(maps.Keys(files)) {
+		pages = append(pages, files[path].page)
+	}
+	resolver := NewResolver(slices.Values(pages))
+	pageKey := func(title string) string {
+		resolved, _ := resolver.Resolve(title)
+		return PageName(resolved)
+	}
+
+	byTitle := make(map[string][]string)
+	byTag := make(map[string][]string)
+	byProperty := make(map[string][]string)
+	byRef := make(map[string][]string)
+	add := func(index map[string][]string, key, path string) {
+		if !slices.Contains(index[key], path) {
+			index[key] = append(index[key], path)
+		}
+	}
+	for _, page := range pages {
+		add(byTitle, pageKey(page.Title()), page.Path)
+		tags, _ := page.Info.PageLevelTags()
+		for _, tag := range tags {
+			add(byTag, pageKey(tag.Target), page.Path)
+		}
+		for _, prop := range page.Info.Props {
+			add(byProperty, PageName(prop.Name), page.Path)
+			// Text values are indexed too as queries match them exactly
+			for _, value := range prop.Values {
+				add(byRef, pageKey(value.Target), page.Path)
+			}
+		}
+		for _, ref := range page.Info.Refs {
+			if ref.IsPage() {
+				add(byRef, pageKey(ref.Target), page.Path)
+			}
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.files = files
+	g.pages = pages
+	g.resolver = resolver
+	g.byTitle = byTitle
+	g.byTag = byTag
+	g.byProperty = byProperty
+	g.byRef = byRef
+}
+
+// pageKey returns index key of the page, must be called under the lock
+func (g *IndexedGraph) pageKey(title string) string {
+	resolved, _ := g.resolver.Resolve(title)
+	return PageName(resolved)
+}
+
+// subscribe registers hooks called with changes of every refresh until `stop` is called
+func (g *IndexedGraph) subscribe(ctx context.Context, hooks []WatchHook) (stop func()) {
+	sub := &subscriber{ctx: ctx, hooks: hooks}
+	g.refreshMu.Lock()
+	defer g.refreshMu.Unlock()
+	g.subscribers[sub] = true
+	return func() {
+		g.refreshMu.Lock()
+		defer g.refreshMu.Unlock()
+		delete(g.subscribers, sub)
+	}
+}
+
+// notify runs hooks of the watchers, must be called under refreshMu
+func (g *IndexedGraph) notify(changes PageChanges) {
+	if changes.Empty() {
+		return
+	}
+	for sub := range g.subscribers {
+		for _, hook := range sub.hooks {
+			if err := hook(sub.ctx, g, changes); err != nil {
+				slog.Error("LogSeq graph watch hook failed", "with", err)
+			}
+		}
+	}
+}
+
+// blobHash calculates the same hash as `git hash-object`
+func blobHash(content []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,177 @@
+package logseq
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// copyGraph copies fixture graph to be modified by the test
+func copyGraph(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS(fixtureGraph)); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func titles(pages []Page) []string {
+	titles := make([]string, len(pages))
+	for i, page := range pages {
+		titles[i] = page.Title()
+	}
+	slices.Sort(titles)
+	return titles
+}
+
+func TestIndexedGraph(t *testing.T) {
+	g := NewIndexedGraph(NewRegexGraph(fixtureGraph))
+
+	expected := titles(slices.Collect(NewRegexGraph(fixtureGraph).WalkPages()))
+	if got := titles(slices.Collect(g.WalkPages())); !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	if got, ok := g.Resolver().Resolve("Turnera"); !ok || got != "damiana" {
+		t.Errorf("expected alias to resolve to damiana, got %s", got)
+	}
+	if got := titles(g.PagesByTitle("Turnera")); !slices.Equal(got, []string{"damiana"}) {
+		t.Errorf("unexpected pages by title %v", got)
+	}
+	if got := titles(g.PagesByTag("Species")); !slices.Equal(got, []string{"damiana", "fern"}) {
+		t.Errorf("unexpected pages by tag %v", got)
+	}
+	if got := titles(g.PagesByProperty("supply")); !slices.Equal(got, []string{"damiana"}) {
+		t.Errorf("unexpected pages by property %v", got)
+	}
+	if got := titles(g.PagesByRef("turnera")); !slices.Equal(got, []string{"Custom Title", "Feb 1st, 2024", "Jan 1st, 2024", "damiana"}) {
+		t.Errorf("unexpected pages by reference %v", got)
+	}
+	if got := titles(g.PagesByRef("Mar 10th, 2024")); !slices.Equal(got, []string{"project/rockets"}) {
+		t.Errorf("unexpected pages by journal reference %v", got)
+	}
+}
+
+func TestIndexedGraph_Refresh(t *testing.T) {
+	dir := copyGraph(t)
+	g := NewIndexedGraph(NewRegexGraph(dir))
+
+	// Touched file isn't reparsed
+	fern := filepath.Join(dir, "pages", "fern.md")
+	before := g.files[fern]
+	later := before.modTime.Add(time.Hour)
+	if err := os.Chtimes(fern, later, later); err != nil {
+		t.Fatal(err)
+	}
+	pages := g.pages
+	g.Refresh()
+	if !g.files[fern].modTime.Equal(later) || &g.pages[0] != &pages[0] {
+		t.Errorf("expected touched file to update only modification time")
+	}
+
+	// Changed, added and removed files
+	if err := os.WriteFile(fern, []byte("tags:: class\n\n- Needs shade\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pages", "oak.md"), []byte("tags:: species\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "pages", "damiana.md")); err != nil {
+		t.Fatal(err)
+	}
+	g.Refresh()
+
+	if got := titles(g.PagesByTag("species")); !slices.Equal(got, []string{"oak"}) {
+		t.Errorf("unexpected pages by tag after refresh %v", got)
+	}
+	if got := titles(g.PagesByTag("class")); !slices.Equal(got, []string{"fern"}) {
+		t.Errorf("unexpected changed page tags %v", got)
+	}
+	if _, ok := g.Resolver().Resolve("turnera"); ok {
+		t.Errorf("expected alias of the removed page to be dropped")
+	}
+}
+
+func TestIndexedGraph_Concurrent(t *testing.T) {
+	dir := copyGraph(t)
+	g := NewIndexedGraph(NewRegexGraph(dir))
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := range 10 {
+				name := filepath.Join(dir, "pages", "new.md")
+				content := []byte("tags:: species\n\n- " + time.Duration(i*10+j).String())
+				_ = os.WriteFile(name, content, 0o644)
+				g.Refresh()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				for page := range g.WalkPages() {
+					_ = page.Title()
+				}
+				_ = g.PagesByTag("species")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBlobHash(t *testing.T) {
+	// git hash-object of "hello\n"
+	if got := blobHash([]byte("hello\n")); got != "ce013625030ba8dba906f756967f9e9ca394464a" {
+		t.Errorf("unexpected hash %s", got)
+	}
+}
+
+func TestIndexedGraph_RefreshFiles(t *testing.T) {
+	dir := copyGraph(t)
+	g := NewIndexedGraph(NewRegexGraph(dir))
+
+	custom := filepath.Join(dir, "pages", "custom.md")
+	renamed := filepath.Join(dir, "pages", "renamed.md")
+	if err := os.Rename(custom, renamed); err != nil {
+		t.Fatal(err)
+	}
+	fern := filepath.Join(dir, "pages", "fern.md")
+	if err := os.WriteFile(fern, []byte("tags:: class\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Untouched by the refresh
+	if err := os.Remove(filepath.Join(dir, "pages", "damiana.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	changes := g.RefreshFiles([]string{custom, renamed, fern, filepath.Join(dir, "pages", "missing.md")})
+	if !slices.Equal(titles(changes.Changed), []string{"Custom Title", "fern"}) {
+		t.Errorf("unexpected changed pages %v", titles(changes.Changed))
+	}
+	if !slices.Equal(titles(changes.Removed), []string{"Custom Title"}) {
+		t.Errorf("unexpected removed pages %v", titles(changes.Removed))
+	}
+	if got := titles(g.PagesByTag("species")); !slices.Equal(got, []string{"damiana"}) {
+		t.Errorf("unexpected pages by tag %v", got)
+	}
+}
+
+func TestIndexedGraph_Retitle(t *testing.T) {
+	dir := copyGraph(t)
+	g := NewIndexedGraph(NewRegexGraph(dir))
+
+	fern := filepath.Join(dir, "pages", "fern.md")
+	if err := os.WriteFile(fern, []byte("title:: Fern Species\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changes := g.RefreshFiles([]string{fern})
+	if !slices.Equal(titles(changes.Changed), []string{"Fern Species"}) || !slices.Equal(titles(changes.Removed), []string{"fern"}) {
+		t.Errorf("unexpected retitle changes %+v", changes)
+	}
+}
@@ -0,0 +1,203 @@
+package logseq
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// LogSeq's defaults when config.edn doesn't override them
+	DefaultJournalFileFormat  = "yyyy_MM_dd"
+	DefaultJournalTitleFormat = "MMM do, yyyy"
+	DefaultJournalsDirectory  = "journals"
+	DefaultPagesDirectory     = "pages"
+
+	// File name formats of the pages, namespaces are separated with
+	// triple lowbar or URL escaped slash in the legacy format
+	TripleLowbarFileNameFormat = "triple-lowbar"
+	LegacyFileNameFormat       = "legacy"
+)
+
+var (
+	ednCommentRegexp         = regexp.MustCompile(`(?m);.*$`)
+	journalFileFormatRegexp  = regexp.MustCompile(`:journal/file-name-format\s+"([^"]+)"`)
+	journalTitleFormatRegexp = regexp.MustCompile(`:journal/page-title-format\s+"([^"]+)"`)
+	fileNameFormatRegexp     = regexp.MustCompile(`:file/name-format\s+:([\w-]+)`)
+	journalsDirectoryRegexp  = regexp.MustCompile(`:journals-directory\s+"([^"]+)"`)
+	pagesDirectoryRegexp     = regexp.MustCompile(`:pages-directory\s+"([^"]+)"`)
+	ordinalRegexp            = regexp.MustCompile(`(\d+)(?:st|nd|rd|th)`)
+)
+
+// Config holds settings from the graph's logseq/config.edn
+type Config struct {
+	// Date formats in the Java's notation, e.g. yyyy_MM_dd
+	JournalFileFormat  string
+	JournalTitleFormat string
+	// Format of the page file names, see PageFileName
+	FileNameFormat string
+	// Directories of the journals and the new pages relative to the graph
+	JournalsDirectory string
+	PagesDirectory    string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		JournalFileFormat:  DefaultJournalFileFormat,
+		JournalTitleFormat: DefaultJournalTitleFormat,
+		FileNameFormat:     TripleLowbarFileNameFormat,
+		JournalsDirectory:  DefaultJournalsDirectory,
+		PagesDirectory:     DefaultPagesDirectory,
+	}
+}
+
+// ReadConfig reads journal and file name formats with the directories from the graph config, missing keys are left default
+func ReadConfig(graphPath string) (Config, error) {
+	cfg := DefaultConfig()
+	content, err := os.ReadFile(filepath.Join(graphPath, "logseq", "config.edn"))
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read graph config with %w", err)
+	}
+	content = ednCommentRegexp.ReplaceAll(content, nil)
+	if match := journalFileFormatRegexp.FindSubmatch(content); match != nil {
+		cfg.JournalFileFormat = string(match[1])
+	}
+	if match := journalTitleFormatRegexp.FindSubmatch(content); match != nil {
+		cfg.JournalTitleFormat = string(match[1])
+	}
+	if match := fileNameFormatRegexp.FindSubmatch(content); match != nil {
+		cfg.FileNameFormat = string(match[1])
+	}
+	if match := journalsDirectoryRegexp.FindSubmatch(content); match != nil {
+		cfg.JournalsDirectory = filepath.Clean(string(match[1]))
+	}
+	if match := pagesDirectoryRegexp.FindSubmatch(content); match != nil {
+		cfg.PagesDirectory = filepath.Clean(string(match[1]))
+	}
+	return cfg, nil
+}
+
+// ParseJournalFileName parses journal file name without extension into a date
+func (c Config) ParseJournalFileName(name string) (time.Time, error) {
+	return parseDate(c.JournalFileFormat, name)
+}
+
+// FormatJournalFileName formats date into the journal file name without extension
+func (c Config) FormatJournalFileName(date time.Time) string {
+	return formatDate(c.JournalFileFormat, date)
+}
+
+// FormatJournalTitle formats date into the journal page title
+func (c Config) FormatJournalTitle(date time.Time) string {
+	return formatDate(c.JournalTitleFormat, date)
+}
+
+// ParseJournalTitle parses journal page title like "Jan 1st, 2024" into a date
+func (c Config) ParseJournalTitle(title string) (time.Time, error) {
+	return parseDate(c.JournalTitleFormat, title)
+}
+
+// parseDate parses date in UTC, ordinal suffixes are dropped
+// because Go layouts don't support them
+func parseDate(format, value string) (time.Time, error) {
+	layout := dateLayout(format)
+	if strings.Contains(format, "do") {
+		value = ordinalRegexp.ReplaceAllString(value, "$1")
+	}
+	date, err := time.Parse(layout, value)
+	if err != nil {
+		return date, fmt.Errorf("failed to parse date '%s' with format '%s' with %w", value, format, err)
+	}
+	return date, nil
+}
+
+// dateLayout converts date format from the Java's notation used by LogSeq to Go's layout
+func dateLayout(format string) string {
+	var layout strings.Builder
+	walkDateFormat(format, func(_, golang string) {
+		layout.WriteString(golang)
+	}, func(literal string) {
+		layout.WriteString(literal)
+	})
+	return layout.String()
+}
+
+// formatDate formats date in the Java's notation with ordinal days support
+func formatDate(format string, date time.Time) string {
+	var b strings.Builder
+	walkDateFormat(format, func(java, golang string) {
+		if java == "do" {
+			b.WriteString(ordinal(date.Day()))
+			return
+		}
+		b.WriteString(date.Format(golang))
+	}, func(literal string) {
+		b.WriteString(literal)
+	})
+	return b.String()
+}
+
+func ordinal(day int) string {
+	suffix := "th"
+	switch {
+	case day%100 >= 11 && day%100 <= 13:
+	case day%10 == 1:
+		suffix = "st"
+	case day%10 == 2:
+		suffix = "nd"
+	case day%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(day) + suffix
+}
+
+// walkDateFormat splits Java's date format into tokens with their Go's layouts and literal text
+func walkDateFormat(format string, token func(java, golang string), literal func(string)) {
+	// Longer tokens go first to be matched greedily
+	tokens := []struct{ java, golang string }{
+		{"yyyy", "2006"},
+		{"yy", "06"},
+		{"MMMM", "January"},
+		{"MMM", "Jan"},
+		{"MM", "01"},
+		{"M", "1"},
+		{"do", "2"},
+		{"dd", "02"},
+		{"d", "2"},
+		{"EEEE", "Monday"},
+		{"EEE", "Mon"},
+		{"EE", "Mon"},
+		{"E", "Mon"},
+	}
+
+	for i := 0; i < len(format); {
+		if format[i] == '\'' {
+			// Quoted literal text
+			end := strings.IndexByte(format[i+1:], '\'')
+			if end < 0 {
+				literal(format[i+1:])
+				break
+			}
+			literal(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+		matched := false
+		for _, t := range tokens {
+			if strings.HasPrefix(format[i:], t.java) {
+				token(t.java, t.golang)
+				i += len(t.java)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			literal(format[i : i+1])
+			i++
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package logseq
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const fixtureGraph = "testdata/graph"
+
+func TestConfig_ParseJournal(t *testing.T) {
+	expected := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		format string
+		value  string
+	}{
+		{"yyyy_MM_dd", "2024_01_01"},
+		{"yyyy-MM-dd", "2024-01-01"},
+		{"MMM do, yyyy", "Jan 1st, 2024"},
+		{"MMMM d, yyyy", "January 1, 2024"},
+		{"EEE, dd.MM.yyyy", "Mon, 01.01.2024"},
+		{"yyyy'W'dd MM", "2024W01 01"},
+	}
+	for _, c := range cases {
+		cfg := Config{JournalFileFormat: c.format, JournalTitleFormat: c.format}
+		got, err := cfg.ParseJournalTitle(c.value)
+		if err != nil {
+			t.Errorf("failed to parse '%s' with %s", c.value, err)
+			continue
+		}
+		if !got.Equal(expected) {
+			t.Errorf("expected %s, got %s for '%s'", expected, got, c.value)
+		}
+	}
+
+	if _, err := DefaultConfig().ParseJournalTitle("Jan 32nd, 2024"); err == nil {
+		t.Errorf("expected invalid day to fail")
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "logseq"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	config := `{:journal/page-title-format "dd.MM.yyyy"
+ ;; :journal/file-name-format "yyyy_MM"
+ :journal/file-name-format "yyyy-MM-dd"
+ :file/name-format :legacy
+ :journals-directory "daily/"}`
+	if err := os.WriteFile(filepath.Join(dir, "logseq", "config.edn"), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := ReadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.JournalFileFormat != "yyyy-MM-dd" || cfg.JournalTitleFormat != "dd.MM.yyyy" || cfg.FileNameFormat != LegacyFileNameFormat ||
+		cfg.JournalsDirectory != "daily" || cfg.PagesDirectory != DefaultPagesDirectory {
+		t.Errorf("unexpected config %#v", cfg)
+	}
+
+	if _, err := ReadConfig(t.TempDir()); err == nil {
+		t.Errorf("expected missing config to fail")
+	}
+}
+
+func TestRegexGraph_JournalDate(t *testing.T) {
+	journals := make(map[string]time.Time)
+	for page := range NewRegexGraph(fixtureGraph).WalkPages() {
+		if day, ok := page.JournalDate(); ok {
+			journals[page.Title()] = day
+		}
+	}
+	if len(journals) != 4 {
+		t.Fatalf("expected 4 journals, got %v", journals)
+	}
+	if day := journals["Jan 15th, 2024"]; !day.Equal(time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected journal date %s", day)
+	}
+
+	// Journals are looked up in the configured directory
+	dir := t.TempDir()
+	files := map[string]string{
+		"logseq/config.edn":      `{:journals-directory "daily"}`,
+		"daily/2024_01_02.md":    "- note\n",
+		"journals/2024_01_03.md": "- not a journal\n",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	titles := make(map[string]bool)
+	for page := range NewRegexGraph(dir).WalkPages() {
+		titles[page.Title()] = true
+	}
+	if !titles["Jan 2nd, 2024"] || !titles["2024_01_03"] {
+		t.Errorf("unexpected titles %v", titles)
+	}
+}
@@ -2,6 +2,8 @@ package logseq
 
 import (
 	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -9,14 +11,11 @@ import (
 	"log/slog"
 	"os"
 	"path/filepath"
-	"regexp"
 	"slices"
 	"strings"
-)
+	"time"
 
-var (
-	propertyRegexp = regexp.MustCompile(`(\w[\w_-]*\w):: (.+)$`)
-	refRegexp      = regexp.MustCompile(`\[\[(@?[\w_-]*\w)\]\]`)
+	"mimi/internal/provider/logseq/outline"
 )
 
 const (
@@ -25,28 +24,56 @@ const (
 )
 
 type RegexGraph struct {
-	Path string
+	Path   string
+	Config Config
 }
 
+// NewRegexGraph reads graph config falling back to the defaults
 func NewRegexGraph(path string) RegexGraph {
+	cfg, err := ReadConfig(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("failed to read graph config, using defaults", "path", path, "with", err)
+	}
 	return RegexGraph{
-		Path: path,
+		Path:   path,
+		Config: cfg,
 	}
 }
 
+// Graph iterates over the parsed pages
+type Graph interface {
+	WalkPages() iter.Seq[Page]
+	GetConfig() Config
+}
+
+// ResolvingGraph keeps the resolver of its pages to not build it on every use
+type ResolvingGraph interface {
+	Graph
+	Resolver() Resolver
+}
+
+// IndexingGraph finds pages by inverted indexes instead of scanning all of them
+type IndexingGraph interface {
+	ResolvingGraph
+	PagesByTitle(title string) []Page
+	PagesByTag(tag string) []Page
+	PagesByProperty(name string) []Page
+	PagesByRef(title string) []Page
+}
+
+func (g RegexGraph) GetConfig() Config {
+	return g.Config
+}
+
 func (g RegexGraph) WalkPages() iter.Seq[Page] {
 	return func(yield func(p Page) bool) {
-		_ = filepath.Walk(g.Path, func(path string, fs fs.FileInfo, err error) error {
+		g.walkFiles(func(path string, _ fs.FileInfo) error {
+			content, err := os.ReadFile(path)
 			if err != nil {
-				slog.Error("failed to access path", "path", path, "with", err)
+				slog.Error("failed to read page", "path", path, "with", err)
 				return nil
 			}
-
-			if !strings.HasSuffix(fs.Name(), ".md") {
-				return nil
-			}
-
-			page, err := NewPage(path)
+			page, err := g.parsePage(path, content)
 			if err != nil {
 				slog.Error("failed to create new page", "with", err)
 				return nil
@@ -61,9 +88,60 @@ func (g RegexGraph) WalkPages() iter.Seq[Page] {
 	}
 }
 
+// walkFiles calls `fn` for every Markdown and Org file in the graph until it fails
+func (g RegexGraph) walkFiles(fn func(path string, info fs.FileInfo) error) {
+	_ = filepath.Walk(g.Path, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			slog.Error("failed to access path", "path", path, "with", err)
+			return nil
+		}
+
+		if !isPageFile(info.Name()) {
+			return nil
+		}
+
+		return fn(path, info)
+	})
+}
+
+// parsePage parses page with the graph specific journal dates
+func (g RegexGraph) parsePage(path string, content []byte) (Page, error) {
+	info, err := findPageInfo(path, bytes.NewReader(content))
+	if err != nil {
+		return Page{}, fmt.Errorf("failed to read page properties with %w", err)
+	}
+	page := Page{
+		Path: path,
+		Info: info,
+	}
+	if g.isJournal(path) {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		date, err := g.Config.ParseJournalFileName(name)
+		if err != nil {
+			slog.Warn("failed to parse journal date", "path", path, "with", err)
+		} else {
+			page.journalDate = date
+			page.journalTitle = g.Config.FormatJournalTitle(date)
+		}
+	}
+	return page, nil
+}
+
+// isJournal checks that the file lies in the graph's journals directory
+func (g RegexGraph) isJournal(path string) bool {
+	rel, err := filepath.Rel(g.Path, path)
+	if err != nil {
+		return false
+	}
+	return filepath.Dir(rel) == g.Config.JournalsDirectory
+}
+
 type Page struct {
 	Path string
 	Info PageInfo
+	// Zero for regular pages
+	journalDate  time.Time
+	journalTitle string
 }
 
 func NewPage(path string) (Page, error) {
@@ -74,7 +152,7 @@ func NewPage(path string) (Page, error) {
 	}
 	defer file.Close()
 
-	info, err := FindPageInfo(file)
+	info, err := findPageInfo(path, file)
 	if err != nil {
 		return Page{}, fmt.Errorf("failed to read page properties with %w", err)
 	}
@@ -85,9 +163,24 @@ func NewPage(path string) (Page, error) {
 	}, nil
 }
 
+// Title returns page title as LogSeq shows it: formatted date for journals,
+// `title::` property or decoded file name
 func (p Page) Title() string {
+	if p.journalTitle != "" {
+		return p.journalTitle
+	}
+	for _, prop := range p.Info.Props {
+		if prop.Name == "title" && prop.Level == PageLevel && prop.Raw != "" {
+			return prop.Raw
+		}
+	}
 	fileName := filepath.Base(p.Path)
-	return strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	return TitleFromFileName(strings.TrimSuffix(fileName, filepath.Ext(fileName)))
+}
+
+// JournalDate returns the day of the journal page
+func (p Page) JournalDate() (time.Time, bool) {
+	return p.journalDate, !p.journalDate.IsZero()
 }
 
 func (p Page) Read() (string, error) {
@@ -104,16 +197,19 @@ func (p Page) Read() (string, error) {
 
 type PageInfo struct {
 	Props []Property
-	Refs  []string
+	Refs  []Ref
+	Tasks []Task
 }
 
 type Property struct {
 	Name   string
-	Values []string
+	Values []Ref
 	Level  string
+	// Value as it's written
+	Raw string
 }
 
-func (p PageInfo) AllTags() ([]string, bool) {
+func (p PageInfo) AllTags() ([]Ref, bool) {
 	for _, p := range p.Props {
 		if p.Name != "tags" {
 			continue
@@ -123,7 +219,7 @@ func (p PageInfo) AllTags() ([]string, bool) {
 	return nil, false
 }
 
-func (p PageInfo) PageLevelTags() ([]string, bool) {
+func (p PageInfo) PageLevelTags() ([]Ref, bool) {
 	for _, p := range p.Props {
 		if p.Name != "tags" || p.Level != PageLevel {
 			continue
@@ -133,7 +229,7 @@ func (p PageInfo) PageLevelTags() ([]string, bool) {
 	return nil, false
 }
 
-func (p PageInfo) Get(name string) (values []string, ok bool) {
+func (p PageInfo) Get(name string) (values []Ref, ok bool) {
 	for _, p := range p.Props {
 		if p.Name != name {
 			continue
@@ -143,7 +239,7 @@ func (p PageInfo) Get(name string) (values []string, ok bool) {
 	return values, false
 }
 
-func (p PageInfo) PageLevelGet(name string) (values []string, ok bool) {
+func (p PageInfo) PageLevelGet(name string) (values []Ref, ok bool) {
 	for _, p := range p.Props {
 		if p.Name != name || p.Level != PageLevel {
 			continue
@@ -153,10 +249,39 @@ func (p PageInfo) PageLevelGet(name string) (values []string, ok bool) {
 	return values, false
 }
 
+// PageRefs returns titles of the referenced pages and tags
+func (p PageInfo) PageRefs() []string {
+	var titles []string
+	for _, ref := range p.Refs {
+		if ref.IsPage() && !slices.Contains(titles, ref.Target) {
+			titles = append(titles, ref.Target)
+		}
+	}
+	return titles
+}
+
+// addRefs appends only unique references
+func (p *PageInfo) addRefs(refs []Ref) {
+	for _, ref := range refs {
+		if !slices.Contains(p.Refs, ref) {
+			p.Refs = append(p.Refs, ref)
+		}
+	}
+}
+
+// findPageInfo picks the parser by the page file format
+func findPageInfo(path string, r io.Reader) (PageInfo, error) {
+	if isOrgFile(path) {
+		return FindOrgPageInfo(r)
+	}
+	return FindPageInfo(r)
+}
+
 func FindPageInfo(r io.Reader) (PageInfo, error) {
 	var props PageInfo
 	var propertyLevel string
 	pageStart := true
+	codeBlock := false
 
 	// Scan lines and extract properties
 	scanner := bufio.NewScanner(r)
@@ -167,31 +292,38 @@ func FindPageInfo(r io.Reader) (PageInfo, error) {
 			pageStart = false
 			continue
 		}
+		// Skip code blocks
+		if strings.HasPrefix(strings.TrimLeft(line, " \t-"), "```") {
+			codeBlock = !codeBlock
+			continue
+		}
+		if codeBlock {
+			continue
+		}
 		// Collect references
-		matches := refRegexp.FindAllStringSubmatchIndex(line, -1)
-		for _, match := range matches {
-			ref := line[match[2]:match[3]]
-			// Collect only unique references
-			if slices.Contains(props.Refs, ref) {
-				continue
+		props.addRefs(ParseRefs(line))
+
+		// Blocks may start with a workflow keyword
+		if text, ok := strings.CutPrefix(strings.TrimLeft(line, " \t"), "- "); ok {
+			if marker, content, ok := parseTaskMarker(text); ok {
+				props.Tasks = append(props.Tasks, Task{
+					Marker:  marker,
+					Content: content,
+				})
 			}
-			props.Refs = append(props.Refs, ref)
 		}
 
-		// Is there any properties
-		match := propertyRegexp.FindStringSubmatchIndex(line)
-		if match == nil {
+		// Is there any properties, either on its own line or starting the block
+		text := strings.TrimLeft(line, " \t")
+		text = strings.TrimPrefix(text, "- ")
+		match := outline.PropertyRegexp.FindStringSubmatch(text)
+		if match == nil || match[2] == "" {
 			continue
 		}
 
 		// Found property
-		propertyName := line[match[2]:match[3]]
-
-		// Split by comma & trim spaces
-		propertyValues := strings.Split(line[match[4]:match[5]], ",")
-		for idx, value := range propertyValues {
-			propertyValues[idx] = strings.Trim(value, " ")
-		}
+		propertyName := match[1]
+		propertyValue := strings.TrimSpace(match[2])
 
 		// Set property level
 		if pageStart {
@@ -204,8 +336,9 @@ func FindPageInfo(r io.Reader) (PageInfo, error) {
 		// elements with equal Name and differenc Values
 		props.Props = append(props.Props, Property{
 			Name:   propertyName,
-			Values: propertyValues,
+			Values: ParsePropertyValues(propertyValue),
 			Level:  propertyLevel,
+			Raw:    propertyValue,
 		})
 	}
 
@@ -13,6 +13,10 @@ tags:: species, research, psycho
 - supply:: next-month
 [[@master]]
 [[foo]] and [[bar]]`
+	page2 = `тип:: растение
+
+- Полив
+  частота:: 2 дня`
 )
 
 func TestFindPageInfo(t *testing.T) {
@@ -21,21 +25,43 @@ func TestFindPageInfo(t *testing.T) {
 			Props: []Property{
 				Property{
 					Name:   "alias",
-					Values: []string{"damiana"},
+					Values: []Ref{{Kind: RefPage, Target: "damiana"}},
 					Level:  PageLevel,
 				},
 				Property{
-					Name:   "tags",
-					Values: []string{"species", "research", "psycho"},
-					Level:  PageLevel,
+					Name: "tags",
+					Values: []Ref{
+						{Kind: RefPage, Target: "species"},
+						{Kind: RefPage, Target: "research"},
+						{Kind: RefPage, Target: "psycho"},
+					},
+					Level: PageLevel,
 				},
 				Property{
 					Name:   "supply",
-					Values: []string{"next-month"},
+					Values: []Ref{{Kind: RefPage, Target: "next-month"}},
+					Level:  BlockLevel,
+				},
+			},
+			Refs: []Ref{
+				{Kind: RefPage, Target: "@master"},
+				{Kind: RefPage, Target: "foo"},
+				{Kind: RefPage, Target: "bar"},
+			},
+		},
+		page2: PageInfo{
+			Props: []Property{
+				Property{
+					Name:   "тип",
+					Values: []Ref{{Kind: RefPage, Target: "растение"}},
+					Level:  PageLevel,
+				},
+				Property{
+					Name:   "частота",
+					Values: []Ref{{Kind: RefPage, Target: "2 дня"}},
 					Level:  BlockLevel,
 				},
 			},
-			Refs: []string{"@master", "foo", "bar"},
 		},
 	}
 
@@ -71,7 +97,7 @@ func TestPageInfo_Tags(t *testing.T) {
 		}
 
 		tags, _ := info.AllTags()
-		if slices.Equal(tags, expected) {
+		if slices.Equal(Targets(tags), expected) {
 			continue
 		}
 
@@ -0,0 +1,289 @@
+package logseq
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	orgHeadlineRegexp = regexp.MustCompile(`^(\*+)\s+(.*?)\s*$`)
+	orgTagsRegexp     = regexp.MustCompile(`\s+(:(?:[\w@#%-]+:)+)$`)
+	orgPriorityRegexp = regexp.MustCompile(`^\[#[A-Z]\]\s*`)
+	orgKeywordRegexp  = regexp.MustCompile(`^#\+(\w[\w-]*):\s*(.*)$`)
+	orgDrawerRegexp   = regexp.MustCompile(`^:(\w[\w-]*):\s*(.*)$`)
+	orgLinkRegexp     = regexp.MustCompile(`\[\[([^\[\]]+)\](?:\[([^\[\]]*)\])?\]`)
+	orgVerbatimRegexp = regexp.MustCompile(`(^|[\s(])[=~][^\s=~](?:[^=~]*[^\s=~])?[=~]`)
+)
+
+// Workflow keywords LogSeq recognizes at the start of a block
+var taskMarkers = []string{"TODO", "DOING", "DONE", "LATER", "NOW", "WAITING", "WAIT", "IN-PROGRESS", "CANCELED", "CANCELLED"}
+
+// Task is a block marked with a workflow keyword e.g. TODO or DONE
+type Task struct {
+	Marker  string
+	Content string
+}
+
+// parseTaskMarker splits the leading workflow keyword from the block content
+func parseTaskMarker(text string) (marker, content string, ok bool) {
+	word, rest, _ := strings.Cut(text, " ")
+	if !slices.Contains(taskMarkers, word) {
+		return "", text, false
+	}
+	return word, strings.TrimSpace(rest), true
+}
+
+// isOrgFile checks that the page is written in Org mode
+func isOrgFile(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".org")
+}
+
+// isPageFile checks that the file is a Markdown or Org page
+func isPageFile(name string) bool {
+	return strings.HasSuffix(name, ".md") || isOrgFile(name)
+}
+
+// FindOrgPageInfo extracts properties, references and tasks from the Org page.
+// `#+key: value` lines and the drawer before the first headline are page level
+func FindOrgPageInfo(r io.Reader) (PageInfo, error) {
+	var info PageInfo
+	headline := false
+	drawer := ""
+	block := ""
+
+	addProperty := func(name, value, level string) {
+		info.Props = append(info.Props, Property{
+			Name:   strings.ToLower(name),
+			Values: ParsePropertyValues(rewriteOrgLinks(value)),
+			Level:  level,
+			Raw:    value,
+		})
+		info.addRefs(parseOrgRefs(value))
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		upper := strings.ToUpper(line)
+
+		// Skip source and example blocks
+		if block != "" {
+			if strings.HasPrefix(upper, "#+END_"+block) {
+				block = ""
+			}
+			continue
+		}
+		if kind, ok := strings.CutPrefix(upper, "#+BEGIN_"); ok {
+			kind, _, _ = strings.Cut(kind, " ")
+			if kind == "SRC" || kind == "EXAMPLE" || kind == "QUERY" {
+				block = kind
+			}
+			continue
+		}
+		if strings.HasPrefix(upper, "#+END_") {
+			continue
+		}
+
+		// Drawers hold properties or clock entries
+		if drawer != "" {
+			if upper == ":END:" {
+				drawer = ""
+				continue
+			}
+			if drawer != "PROPERTIES" {
+				continue
+			}
+			if match := orgDrawerRegexp.FindStringSubmatch(line); match != nil {
+				level := PageLevel
+				if headline {
+					level = BlockLevel
+				}
+				addProperty(match[1], strings.TrimSpace(match[2]), level)
+			}
+			continue
+		}
+		if match := orgDrawerRegexp.FindStringSubmatch(line); match != nil && match[2] == "" && !strings.EqualFold(match[1], "END") {
+			drawer = strings.ToUpper(match[1])
+			continue
+		}
+
+		// In-buffer settings are page properties
+		if match := orgKeywordRegexp.FindStringSubmatch(line); match != nil {
+			addProperty(match[1], strings.TrimSpace(match[2]), PageLevel)
+			continue
+		}
+
+		if match := orgHeadlineRegexp.FindStringSubmatch(line); match != nil {
+			headline = true
+			text := match[2]
+			if tags := orgTagsRegexp.FindStringSubmatchIndex(text); tags != nil {
+				for tag := range strings.SplitSeq(strings.Trim(text[tags[2]:tags[3]], ":"), ":") {
+					info.addRefs([]Ref{{Kind: RefTag, Target: tag}})
+				}
+				text = text[:tags[0]]
+			}
+			marker, content, ok := parseTaskMarker(text)
+			content = orgPriorityRegexp.ReplaceAllString(content, "")
+			if ok {
+				info.Tasks = append(info.Tasks, Task{
+					Marker:  marker,
+					Content: content,
+				})
+			}
+			info.addRefs(parseOrgRefs(content))
+			continue
+		}
+
+		info.addRefs(parseOrgRefs(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return info, fmt.Errorf("failed to scan org page with %w", err)
+	}
+
+	return info, nil
+}
+
+// parseOrgRefs finds references in the Org text ignoring verbatim and code
+func parseOrgRefs(text string) []Ref {
+	text = orgVerbatimRegexp.ReplaceAllString(text, "$1")
+	return ParseRefs(rewriteOrgLinks(text))
+}
+
+// rewriteOrgLinks converts Org links into LogSeq references,
+// external links are replaced with their descriptions
+func rewriteOrgLinks(text string) string {
+	return orgLinkRegexp.ReplaceAllStringFunc(text, func(link string) string {
+		match := orgLinkRegexp.FindStringSubmatch(link)
+		target, desc := match[1], match[2]
+		switch {
+		case strings.HasPrefix(target, "id:"):
+			return "((" + strings.TrimPrefix(target, "id:") + "))"
+		case strings.HasPrefix(target, "file:"):
+			name := path.Base(strings.TrimPrefix(target, "file:"))
+			return "[[" + TitleFromFileName(strings.TrimSuffix(name, path.Ext(name))) + "]]"
+		case strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:"):
+			if desc == "" {
+				return target
+			}
+			return desc
+		}
+		return "[[" + target + "]]"
+	})
+}
+
+// parseOrgBlocks flattens headlines into blocks, their body lines are added to the content
+// while drawers are dropped. Text before the first headline holds page properties and is skipped
+func parseOrgBlocks(content string) []BlockInfo {
+	var blocks []BlockInfo
+	// Positions of the open headlines by their levels
+	var parents []int
+	var levels []int
+	var body []string
+	flush := func() {
+		if len(blocks) == 0 {
+			return
+		}
+		b := &blocks[len(blocks)-1]
+		b.Content = strings.TrimSpace(strings.Join(append([]string{b.Content}, body...), "\n"))
+		body = body[:0]
+	}
+	addRefs := func(b *BlockInfo, refs []Ref) {
+		for _, ref := range refs {
+			if ref.Kind != RefText && !slices.Contains(b.Refs, ref) {
+				b.Refs = append(b.Refs, ref)
+			}
+		}
+	}
+
+	drawer := ""
+	block := ""
+	for line := range strings.Lines(content) {
+		line = strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimSpace(line)
+		upper := strings.ToUpper(trimmed)
+		if match := orgHeadlineRegexp.FindStringSubmatch(line); match != nil && block == "" {
+			flush()
+			level := len(match[1])
+			for len(levels) > 0 && levels[len(levels)-1] >= level {
+				levels, parents = levels[:len(levels)-1], parents[:len(parents)-1]
+			}
+			parent := -1
+			if len(parents) > 0 {
+				parent = parents[len(parents)-1]
+			}
+			b := BlockInfo{Position: len(blocks), Parent: parent, Content: match[2]}
+			text := match[2]
+			if tags := orgTagsRegexp.FindStringSubmatchIndex(text); tags != nil {
+				for tag := range strings.SplitSeq(strings.Trim(text[tags[2]:tags[3]], ":"), ":") {
+					addRefs(&b, []Ref{{Kind: RefTag, Target: tag}})
+				}
+				text = text[:tags[0]]
+			}
+			addRefs(&b, parseOrgRefs(text))
+			blocks = append(blocks, b)
+			levels, parents = append(levels, level), append(parents, b.Position)
+			continue
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		b := &blocks[len(blocks)-1]
+
+		// Code is kept in the content without references
+		if block != "" {
+			if strings.HasPrefix(upper, "#+END_"+block) {
+				block = ""
+			}
+			body = append(body, trimmed)
+			continue
+		}
+		if kind, ok := strings.CutPrefix(upper, "#+BEGIN_"); ok {
+			kind, _, _ = strings.Cut(kind, " ")
+			if kind == "SRC" || kind == "EXAMPLE" || kind == "QUERY" {
+				block = kind
+			}
+			body = append(body, trimmed)
+			continue
+		}
+
+		if drawer != "" {
+			if upper == ":END:" {
+				drawer = ""
+				continue
+			}
+			match := orgDrawerRegexp.FindStringSubmatch(trimmed)
+			if drawer != "PROPERTIES" || match == nil {
+				continue
+			}
+			name, value := strings.ToLower(match[1]), strings.TrimSpace(match[2])
+			values := ParsePropertyValues(rewriteOrgLinks(value))
+			b.Props = append(b.Props, Property{
+				Name:   name,
+				Values: values,
+				Level:  BlockLevel,
+				Raw:    value,
+			})
+			// Block's own UUID isn't a reference
+			if name == "id" {
+				b.UUID = value
+				continue
+			}
+			addRefs(b, values)
+			continue
+		}
+		if match := orgDrawerRegexp.FindStringSubmatch(trimmed); match != nil && match[2] == "" && !strings.EqualFold(match[1], "END") {
+			drawer = strings.ToUpper(match[1])
+			continue
+		}
+
+		body = append(body, trimmed)
+		addRefs(b, parseOrgRefs(trimmed))
+	}
+	flush()
+	return blocks
+}
@@ -0,0 +1,95 @@
+package logseq
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindOrgPageInfo(t *testing.T) {
+	file, err := os.Open(filepath.Join(fixtureGraph, "pages", "orchard.org"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	info, err := FindOrgPageInfo(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedProps := []Property{
+		{Name: "title", Values: []Ref{{Kind: RefPage, Target: "Orchard"}}, Level: PageLevel, Raw: "Orchard"},
+		{Name: "alias", Values: []Ref{{Kind: RefPage, Target: "fruit garden"}}, Level: PageLevel, Raw: "fruit garden"},
+		{Name: "tags", Values: []Ref{{Kind: RefPage, Target: "garden"}}, Level: PageLevel, Raw: "garden"},
+		{Name: "season", Values: []Ref{{Kind: RefPage, Target: "spring"}}, Level: BlockLevel, Raw: "spring"},
+	}
+	if !slices.EqualFunc(info.Props, expectedProps, func(lhs, rhs Property) bool {
+		return lhs.Name == rhs.Name && lhs.Level == rhs.Level && lhs.Raw == rhs.Raw && slices.Equal(lhs.Values, rhs.Values)
+	}) {
+		t.Errorf("expected %#v, got %#v", expectedProps, info.Props)
+	}
+
+	expectedRefs := []Ref{
+		{Kind: RefTag, Target: "spring"},
+		{Kind: RefTag, Target: "shade"},
+		{Kind: RefPage, Target: "fern"},
+		{Kind: RefPage, Target: "what?"},
+	}
+	if !slices.Equal(info.Refs, expectedRefs) {
+		t.Errorf("expected %v, got %v", expectedRefs, info.Refs)
+	}
+
+	expectedTasks := []Task{
+		{Marker: "TODO", Content: "Plant [[fern]] under the apple trees"},
+		{Marker: "DONE", Content: "Order seeds from [[https://example.com][the shop]]"},
+	}
+	if !slices.Equal(info.Tasks, expectedTasks) {
+		t.Errorf("expected %v, got %v", expectedTasks, info.Tasks)
+	}
+}
+
+func TestRewriteOrgLinks(t *testing.T) {
+	text2expected := map[string]string{
+		"[[fern]] and [[damiana][the herb]]":                     "[[fern]] and [[damiana]]",
+		"see [[https://example.com][docs]] or [[https://x.org]]": "see docs or https://x.org",
+		"[[file:../pages/project___rockets.org][rockets]]":       "[[project/rockets]]",
+		"[[id:6650f2c4-9d5b-4a7e-8d0e-2f3c4b5a6d7e][block]]":     "((6650f2c4-9d5b-4a7e-8d0e-2f3c4b5a6d7e))",
+		"nested [[a [[b]]]] stays":                               "nested [[a [[b]]]] stays",
+	}
+	for text, expected := range text2expected {
+		if got := rewriteOrgLinks(text); got != expected {
+			t.Errorf("expected '%s', got '%s'", expected, got)
+		}
+	}
+}
+
+func TestFindPageInfo_Tasks(t *testing.T) {
+	page := "- TODO Water [[fern]]\n\t- DONE Harvest\n- TODOS aren't tasks\n- Note about DONE"
+	info, err := FindPageInfo(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Task{
+		{Marker: "TODO", Content: "Water [[fern]]"},
+		{Marker: "DONE", Content: "Harvest"},
+	}
+	if !slices.Equal(info.Tasks, expected) {
+		t.Errorf("expected %v, got %v", expected, info.Tasks)
+	}
+}
+
+func TestRegexGraph_OrgPage(t *testing.T) {
+	g := NewRegexGraph(fixtureGraph)
+	for page := range g.WalkPages() {
+		if filepath.Ext(page.Path) != ".org" {
+			continue
+		}
+		if page.Title() != "Orchard" {
+			t.Errorf("unexpected org page title '%s'", page.Title())
+		}
+		return
+	}
+	t.Error("org page wasn't walked")
+}
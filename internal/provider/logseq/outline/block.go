@@ -0,0 +1,238 @@
+package outline
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	blockRefRegexp = regexp.MustCompile(`\(\(([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})\)\)`)
+	embedRegexp    = regexp.MustCompile(`\{\{embed\s+(\[\[(.+?)\]\]|\(\((.+?)\)\))\s*\}\}`)
+)
+
+// Block is a single outline item with its children
+type Block struct {
+	// Whitespace before the bullet
+	Indent string
+	// Nesting depth, zero for top level blocks
+	Level      int
+	Properties []Property
+	Children   []*Block
+	// Content lines with the first one following the bullet
+	lines []line
+	// Index of the content line properties are rendered after,
+	// -1 when properties go before any content
+	propsAfter int
+	// Whether the empty bullet is followed by a space
+	spaced bool
+}
+
+type line struct {
+	text string
+	// Set when the line misses the block's continuation indentation
+	raw bool
+}
+
+type EmbedKind string
+
+const (
+	EmbedPage  EmbedKind = "page"
+	EmbedBlock EmbedKind = "block"
+)
+
+// Embed is a `{{embed [[page]]}}` or `{{embed ((uuid))}}` macro
+type Embed struct {
+	Kind EmbedKind
+	// Page title or block UUID
+	Target string
+}
+
+func newBlock(indent string, level int, content string) *Block {
+	b := &Block{Indent: indent, Level: level}
+	b.SetContent(content)
+	return b
+}
+
+// continuation is the indentation of the block's content lines after the first one
+func (b *Block) continuation() string {
+	return b.Indent + "  "
+}
+
+func (b *Block) parseContinuation(text string) line {
+	if rest, ok := strings.CutPrefix(text, b.continuation()); ok {
+		return line{text: rest}
+	}
+	return line{text: text, raw: true}
+}
+
+// splitProperties moves properties following the first content line
+// (or starting the block) out of the content
+func (b *Block) splitProperties() {
+	b.propsAfter = 0
+	start := 1
+	if prop, ok := parseProperty(b.lines[0].text); ok && !b.lines[0].raw {
+		// Block consists of properties only
+		b.Properties = append(b.Properties, prop)
+		b.propsAfter = -1
+	}
+	end := start
+	for end < len(b.lines) && !b.lines[end].raw {
+		prop, ok := parseProperty(b.lines[end].text)
+		if !ok {
+			break
+		}
+		b.Properties = append(b.Properties, prop)
+		end++
+	}
+	if b.propsAfter == -1 {
+		b.lines = b.lines[end:]
+		return
+	}
+	b.lines = append(b.lines[:start], b.lines[end:]...)
+}
+
+// Content returns block text without properties and indentation
+func (b *Block) Content() string {
+	texts := make([]string, len(b.lines))
+	for i, l := range b.lines {
+		texts[i] = l.text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// SetContent replaces block text keeping its properties
+func (b *Block) SetContent(content string) {
+	b.lines = nil
+	for _, text := range strings.Split(content, "\n") {
+		b.lines = append(b.lines, line{text: text})
+	}
+	if b.propsAfter == -1 && content != "" {
+		b.propsAfter = 0
+	}
+}
+
+// Property returns the block property value
+func (b *Block) Property(name string) (string, bool) {
+	return findProperty(b.Properties, name)
+}
+
+// SetProperty replaces block property value or appends the new one
+func (b *Block) SetProperty(name, value string) {
+	b.Properties = setProperty(b.Properties, name, value)
+}
+
+// RemoveProperty deletes block property
+func (b *Block) RemoveProperty(name string) {
+	b.Properties = removeProperty(b.Properties, name)
+}
+
+// ID returns block UUID from the `id::` property
+func (b *Block) ID() string {
+	id, _ := b.Property("id")
+	return strings.TrimSpace(id)
+}
+
+// BlockRefs returns UUIDs referenced with `((uuid))` in the content and properties
+func (b *Block) BlockRefs() []string {
+	var refs []string
+	for _, m := range blockRefRegexp.FindAllStringSubmatch(b.text(), -1) {
+		refs = appendUnique(refs, m[1])
+	}
+	return refs
+}
+
+// Embeds returns embedded pages and blocks
+func (b *Block) Embeds() []Embed {
+	var embeds []Embed
+	for _, m := range embedRegexp.FindAllStringSubmatch(b.Content(), -1) {
+		if m[2] != "" {
+			embeds = append(embeds, Embed{Kind: EmbedPage, Target: m[2]})
+		} else {
+			embeds = append(embeds, Embed{Kind: EmbedBlock, Target: m[3]})
+		}
+	}
+	return embeds
+}
+
+// AppendChild adds block to the end of the children
+func (b *Block) AppendChild(content string, p *Page) *Block {
+	child := newBlock(b.Indent+p.indentUnit, b.Level+1, content)
+	b.Children = append(b.Children, child)
+	return child
+}
+
+// text joins content and property values
+func (b *Block) text() string {
+	var sb strings.Builder
+	sb.WriteString(b.Content())
+	for _, prop := range b.Properties {
+		sb.WriteString("\n")
+		sb.WriteString(prop.Value)
+	}
+	return sb.String()
+}
+
+// String renders the block with its children as a top level one
+func (b *Block) String() string {
+	var sb strings.Builder
+	b.render(&sb)
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimPrefix(l, b.Indent)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *Block) render(sb *strings.Builder) {
+	cont := b.continuation()
+	first := true
+	writeLine := func(text string, raw bool) {
+		switch {
+		case first:
+			sb.WriteString(b.Indent)
+			sb.WriteString("-")
+			if text != "" || b.spaced {
+				sb.WriteString(" ")
+				sb.WriteString(text)
+			}
+			first = false
+		case raw:
+			sb.WriteString(text)
+		default:
+			sb.WriteString(cont)
+			sb.WriteString(text)
+		}
+		sb.WriteString("\n")
+	}
+	writeProps := func() {
+		for _, prop := range b.Properties {
+			writeLine(prop.String(), false)
+		}
+	}
+
+	if b.propsAfter == -1 {
+		writeProps()
+	}
+	for i, l := range b.lines {
+		writeLine(l.text, l.raw)
+		if i == 0 && b.propsAfter == 0 {
+			writeProps()
+		}
+	}
+	if len(b.lines) == 0 && b.propsAfter != -1 {
+		writeLine("", false)
+		writeProps()
+	}
+	for _, child := range b.Children {
+		child.render(sb)
+	}
+}
+
+func appendUnique(values []string, v string) []string {
+	for _, existing := range values {
+		if existing == v {
+			return values
+		}
+	}
+	return append(values, v)
+}
@@ -0,0 +1,212 @@
+// Package outline parses LogSeq pages into block trees and renders them back.
+// Unmodified pages are rendered byte to byte, so blocks could be edited in place
+package outline
+
+import (
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var bulletRegexp = regexp.MustCompile(`^([ \t]*)-(?: (.*)|)$`)
+
+// PropertyRegexp matches `name:: value` line capturing the name and the optional value,
+// names are in any language, e.g. Cyrillic
+var PropertyRegexp = regexp.MustCompile(`^([\p{L}\p{N}_][\p{L}\p{N}_/-]*)::(?: (.*))?$`)
+
+// Page is a parsed LogSeq page
+type Page struct {
+	// Page properties before the first block
+	Properties []Property
+	// Lines between page properties and the first block, usually a single empty line
+	Preamble []string
+	Blocks   []*Block
+	// Indentation of a single nesting level, tab by default
+	indentUnit string
+	// Whether the file ends with a new line
+	trailingNewline bool
+}
+
+// Property is a `name:: value` line
+type Property struct {
+	Name  string
+	Value string
+	// Line as it was parsed, used while the property isn't changed
+	raw string
+}
+
+func (p Property) String() string {
+	if p.raw != "" {
+		if parsed, ok := parseProperty(p.raw); ok && parsed.Name == p.Name && parsed.Value == p.Value {
+			return p.raw
+		}
+	}
+	return p.Name + ":: " + p.Value
+}
+
+func parseProperty(line string) (Property, bool) {
+	m := PropertyRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return Property{}, false
+	}
+	return Property{Name: m[1], Value: m[2], raw: line}, true
+}
+
+// Parse builds block tree from the page content
+func Parse(content string) *Page {
+	p := &Page{indentUnit: "\t"}
+	p.trailingNewline = strings.HasSuffix(content, "\n")
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return p
+	}
+	lines := strings.Split(content, "\n")
+
+	// Page properties and preamble
+	i := 0
+	for ; i < len(lines); i++ {
+		prop, ok := parseProperty(lines[i])
+		if !ok {
+			break
+		}
+		p.Properties = append(p.Properties, prop)
+	}
+	for ; i < len(lines) && !bulletRegexp.MatchString(lines[i]); i++ {
+		p.Preamble = append(p.Preamble, lines[i])
+	}
+
+	// Blocks, the stack holds the current block with its ancestors
+	var stack []*Block
+	var fence bool
+	unitDetected := false
+	for ; i < len(lines); i++ {
+		text := lines[i]
+		m := bulletRegexp.FindStringSubmatch(text)
+		if m == nil || fence {
+			// Continuation of the current block
+			cur := stack[len(stack)-1]
+			cur.lines = append(cur.lines, cur.parseContinuation(text))
+			if isFence(text) {
+				fence = !fence
+			}
+			continue
+		}
+
+		indent := m[1]
+		for len(stack) > 0 && len(stack[len(stack)-1].Indent) >= len(indent) {
+			stack = stack[:len(stack)-1]
+		}
+		b := &Block{Indent: indent, Level: len(stack)}
+		b.lines = []line{{text: m[2]}}
+		b.spaced = m[2] == "" && strings.HasSuffix(text, "- ")
+		if isFence(m[2]) {
+			fence = !fence
+		}
+		if len(stack) == 0 {
+			p.Blocks = append(p.Blocks, b)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, b)
+			if !unitDetected && strings.HasPrefix(indent, parent.Indent) {
+				p.indentUnit = indent[len(parent.Indent):]
+				unitDetected = true
+			}
+		}
+		stack = append(stack, b)
+	}
+
+	for b := range p.Walk() {
+		b.splitProperties()
+	}
+	return p
+}
+
+func isFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+// String renders the page back into LogSeq's markdown
+func (p *Page) String() string {
+	var b strings.Builder
+	for _, prop := range p.Properties {
+		b.WriteString(prop.String())
+		b.WriteString("\n")
+	}
+	for _, line := range p.Preamble {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	for _, block := range p.Blocks {
+		block.render(&b)
+	}
+	out := b.String()
+	if !p.trailingNewline {
+		out = strings.TrimSuffix(out, "\n")
+	}
+	return out
+}
+
+// Walk iterates over all blocks depth first
+func (p *Page) Walk() iter.Seq[*Block] {
+	return func(yield func(*Block) bool) {
+		var walk func(blocks []*Block) bool
+		walk = func(blocks []*Block) bool {
+			for _, b := range blocks {
+				if !yield(b) || !walk(b.Children) {
+					return false
+				}
+			}
+			return true
+		}
+		walk(p.Blocks)
+	}
+}
+
+// Property returns the page property value
+func (p *Page) Property(name string) (string, bool) {
+	return findProperty(p.Properties, name)
+}
+
+// SetProperty replaces page property value or appends the new one
+func (p *Page) SetProperty(name, value string) {
+	delimited := len(p.Preamble) > 0 && strings.TrimSpace(p.Preamble[0]) == ""
+	if len(p.Properties) == 0 && (len(p.Blocks) > 0 || len(p.Preamble) > 0) && !delimited {
+		// Page properties are delimited from the content
+		p.Preamble = append([]string{""}, p.Preamble...)
+	}
+	p.Properties = setProperty(p.Properties, name, value)
+}
+
+// AppendBlock adds top level block at the end of the page
+func (p *Page) AppendBlock(content string) *Block {
+	b := newBlock("", 0, content)
+	p.Blocks = append(p.Blocks, b)
+	p.trailingNewline = true
+	return b
+}
+
+func findProperty(props []Property, name string) (string, bool) {
+	for _, prop := range props {
+		if prop.Name == name {
+			return prop.Value, true
+		}
+	}
+	return "", false
+}
+
+func removeProperty(props []Property, name string) []Property {
+	return slices.DeleteFunc(props, func(prop Property) bool {
+		return prop.Name == name
+	})
+}
+
+func setProperty(props []Property, name, value string) []Property {
+	for i, prop := range props {
+		if prop.Name == name {
+			props[i].Value = value
+			return props
+		}
+	}
+	return append(props, Property{Name: name, Value: value})
+}
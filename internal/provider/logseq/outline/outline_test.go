@@ -0,0 +1,171 @@
+package outline
+
+import (
+	"slices"
+	"testing"
+)
+
+const (
+	pageTabs = `alias:: damiana
+tags:: species, research, psycho
+
+- supply:: next-month
+- Grows in [[Mexico]]
+  id:: 6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b
+  collapsed:: true
+	- Child with ((6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b))
+	  second line
+		- {{embed [[damiana]]}}
+	- ` + "```go" + `
+	  - not a block
+	  ` + "```" + `
+-
+  empty:: first
+- {{embed ((6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b))}}
+`
+	pageSpaces = `- one
+    - two
+        - three
+    - four
+- five
+  lost indentation
+`
+)
+
+func TestParse_RoundTrip(t *testing.T) {
+	for _, content := range []string{
+		pageTabs,
+		pageSpaces,
+		"",
+		"- no trailing newline",
+		"title:: only properties",
+		"- \n- block with trailing space ",
+		"text before blocks\n\n- block\n  prop::\n  other::  spaced\n",
+	} {
+		if got := Parse(content).String(); got != content {
+			t.Errorf("expected %q, got %q", content, got)
+		}
+	}
+}
+
+func TestParse_Tree(t *testing.T) {
+	p := Parse(pageTabs)
+
+	if v, _ := p.Property("tags"); v != "species, research, psycho" {
+		t.Errorf("unexpected page tags %q", v)
+	}
+	if v, _ := Parse("статус:: готово\n- block\n").Property("статус"); v != "готово" {
+		t.Errorf("unexpected Cyrillic property %q", v)
+	}
+	if len(p.Blocks) != 4 {
+		t.Fatalf("expected 4 top level blocks, got %d", len(p.Blocks))
+	}
+
+	supply := p.Blocks[0]
+	if supply.Content() != "" || len(supply.Properties) != 1 || supply.Properties[0].Name != "supply" {
+		t.Errorf("unexpected properties only block %#v", supply)
+	}
+
+	grows := p.Blocks[1]
+	if grows.ID() != "6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b" {
+		t.Errorf("unexpected id %q", grows.ID())
+	}
+	if grows.Content() != "Grows in [[Mexico]]" {
+		t.Errorf("unexpected content %q", grows.Content())
+	}
+	if len(grows.Children) != 2 {
+		t.Fatalf("expected 2 children, got %d", len(grows.Children))
+	}
+
+	child := grows.Children[0]
+	if child.Level != 1 || child.Content() != "Child with ((6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b))\nsecond line" {
+		t.Errorf("unexpected child %d %q", child.Level, child.Content())
+	}
+	if refs := child.BlockRefs(); !slices.Equal(refs, []string{"6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b"}) {
+		t.Errorf("unexpected refs %v", refs)
+	}
+	embed := child.Children[0]
+	if embed.Level != 2 || !slices.Equal(embed.Embeds(), []Embed{{Kind: EmbedPage, Target: "damiana"}}) {
+		t.Errorf("unexpected embed block %d %v", embed.Level, embed.Embeds())
+	}
+
+	code := grows.Children[1]
+	if len(code.Children) != 0 || code.Content() != "```go\n- not a block\n```" {
+		t.Errorf("unexpected code block %q", code.Content())
+	}
+
+	if !slices.Equal(p.Blocks[3].Embeds(), []Embed{{Kind: EmbedBlock, Target: "6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b"}}) {
+		t.Errorf("unexpected block embed %v", p.Blocks[3].Embeds())
+	}
+
+	var count int
+	for range p.Walk() {
+		count++
+	}
+	if count != 7 {
+		t.Errorf("expected 7 blocks, got %d", count)
+	}
+}
+
+func TestParse_Spaces(t *testing.T) {
+	p := Parse(pageSpaces)
+	if len(p.Blocks) != 2 || len(p.Blocks[0].Children) != 2 || len(p.Blocks[0].Children[0].Children) != 1 {
+		t.Fatalf("unexpected tree for space indentation")
+	}
+	if p.Blocks[0].Children[0].Children[0].Level != 2 {
+		t.Errorf("expected level 2")
+	}
+
+	p.Blocks[0].Children[1].AppendChild("added", p)
+	expected := `- one
+    - two
+        - three
+    - four
+        - added
+- five
+  lost indentation
+`
+	if got := p.String(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestBlock_Edit(t *testing.T) {
+	p := Parse(pageTabs)
+	grows := p.Blocks[1]
+	grows.SetContent("Grows in [[Mexico]] and [[Texas]]")
+	grows.SetProperty("collapsed", "false")
+	grows.SetProperty("source", "[[Wiki]]")
+	grows.Children[0].AppendChild("new", p)
+	p.SetProperty("alias", "damiana, turnera")
+
+	expected := `alias:: damiana, turnera
+tags:: species, research, psycho
+
+- supply:: next-month
+- Grows in [[Mexico]] and [[Texas]]
+  id:: 6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b
+  collapsed:: false
+  source:: [[Wiki]]
+	- Child with ((6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b))
+	  second line
+		- {{embed [[damiana]]}}
+		- new
+	- ` + "```go" + `
+	  - not a block
+	  ` + "```" + `
+-
+  empty:: first
+- {{embed ((6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b))}}
+`
+	if got := p.String(); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+
+	empty := Parse("- block")
+	empty.SetProperty("title", "Block")
+	empty.AppendBlock("another")
+	if got := empty.String(); got != "title:: Block\n\n- block\n- another\n" {
+		t.Errorf("unexpected page %q", got)
+	}
+}
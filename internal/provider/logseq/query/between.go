@@ -0,0 +1,105 @@
+package query
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"mimi/internal/config"
+	"mimi/internal/provider/logseq"
+	"mimi/internal/provider/logseq/query/sexp"
+)
+
+var relativeDateRegexp = regexp.MustCompile(`^([+-])(\d+)([dwmy])$`)
+
+// evalBetween selects journal pages with a day in the inclusive range,
+// bounds are relative like -7d, named like today or journal titles like [[Jan 1st, 2024]]
+func evalBetween(l sexp.List, cfg logseq.Config, now time.Time) (pageFilter, error) {
+	slog.Info("translating 'between' expression")
+	start, end, err := betweenDays(l, cfg, now)
+	if err != nil {
+		return emptyFilter, err
+	}
+
+	return func(p logseq.Page) bool {
+		day, ok := p.JournalDate()
+		if !ok {
+			return false
+		}
+		return !day.Before(start) && !day.After(end)
+	}, nil
+}
+
+// now returns current time in the chats' timezone, so today is the users' day
+func now() time.Time {
+	loc, err := config.Location()
+	if err != nil {
+		slog.Warn("failed to load chat timezone, using the local one", "with", err)
+		return time.Now()
+	}
+	return time.Now().In(loc)
+}
+
+// betweenDays returns the ordered inclusive bounds of the 'between' statement
+func betweenDays(l sexp.List, cfg logseq.Config, now time.Time) (start, end time.Time, _ error) {
+	if len(l) != 3 {
+		return start, end, ErrIncorrectBetween
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	start, err := parseDateBound(l[1], cfg, today)
+	if err != nil {
+		return start, end, fmt.Errorf("failed to parse 'between' start with %w", err)
+	}
+	end, err = parseDateBound(l[2], cfg, today)
+	if err != nil {
+		return start, end, fmt.Errorf("failed to parse 'between' end with %w", err)
+	}
+	if end.Before(start) {
+		start, end = end, start
+	}
+	return start, end, nil
+}
+
+// parseDateBound returns the day in UTC as journal dates are parsed
+func parseDateBound(bound sexp.Sexp, cfg logseq.Config, today time.Time) (time.Time, error) {
+	switch v := bound.I.(type) {
+	case int:
+		// LogSeq's journal day e.g. 20240101
+		date, err := time.Parse("20060102", strconv.Itoa(v))
+		if err != nil {
+			return date, fmt.Errorf("unexpected journal day %d", v)
+		}
+		return date, nil
+	case string:
+		switch v {
+		case "today", "now":
+			return today, nil
+		case "yesterday":
+			return today.AddDate(0, 0, -1), nil
+		case "tomorrow":
+			return today.AddDate(0, 0, 1), nil
+		}
+		if match := relativeDateRegexp.FindStringSubmatch(v); match != nil {
+			n, _ := strconv.Atoi(match[2])
+			if match[1] == "-" {
+				n = -n
+			}
+			switch match[3] {
+			case "d":
+				return today.AddDate(0, 0, n), nil
+			case "w":
+				return today.AddDate(0, 0, 7*n), nil
+			case "m":
+				return today.AddDate(0, n, 0), nil
+			default:
+				return today.AddDate(n, 0, 0), nil
+			}
+		}
+		if match := linkRegex.FindStringSubmatch(v); match != nil {
+			return cfg.ParseJournalTitle(match[1])
+		}
+	}
+	return time.Time{}, fmt.Errorf("unexpected date '%v'", bound.I)
+}
@@ -0,0 +1,314 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"mimi/internal/provider/logseq"
+	"mimi/internal/provider/logseq/db"
+	"mimi/internal/provider/logseq/query/sexp"
+)
+
+var (
+	// ErrUnsupported marks filters without Datalog translation, Eval handles them instead
+	ErrUnsupported = errors.New("filter isn't supported by Datalog")
+	ErrNotSynced   = errors.New("graph isn't fully synced into the database")
+)
+
+// Rules matching names of the pages, values and references the same way logseq.Resolver does:
+// case-insensitively with titles taking precedence over aliases
+const resolveRules = `
+	title_name[k] := *page{title}, k = lowercase(trim(title))
+	resolved[k, r] := title_name[k], r = k
+	resolved[k, r] :=
+		*page_alias{alias, page_title},
+		k = lowercase(trim(alias)),
+		not title_name[k],
+		r = lowercase(trim(page_title))
+	resolvable[k] := resolved[k, r]
+
+	name[s] := *page{title: s}
+	name[s] := *page_prop{value: s}
+	name[s] := *page_ref{target: s}
+	name[s] := *page_tag{tag: s}
+	canon[s, r] := name[s], k = lowercase(trim(s)), resolved[k, r]
+	canon[s, r] := name[s], k = lowercase(trim(s)), not resolvable[k], r = k
+`
+
+// EvalDatalog evaluates the query over the pages synced into Cozo,
+// the table is built from the matched pages of `g`. ErrNotSynced is returned
+// until the graph is fully synced
+func EvalDatalog(ctx context.Context, q *db.Queries, g logseq.Graph, query string) (res Result, _ error) {
+	parsed, err := parseQuery(query)
+	if err != nil {
+		return res, fmt.Errorf("failed to parse query with %w", err)
+	}
+	rules, params, err := toDatalog(parsed.s, g.GetConfig(), now())
+	if err != nil {
+		return res, fmt.Errorf("failed to compile query with %w", err)
+	}
+
+	synced, err := q.IsSynced()
+	if err != nil {
+		return res, err
+	}
+	if !synced {
+		return res, ErrNotSynced
+	}
+	titles, err := q.FindTitlesByRules(rules, params)
+	if err != nil {
+		return res, err
+	}
+
+	matched := make(map[string]bool, len(titles))
+	for _, title := range titles {
+		matched[title] = true
+	}
+	for page := range g.WalkPages() {
+		if matched[page.Title()] {
+			res.Pages = append(res.Pages, page)
+		}
+	}
+	if len(res.Pages) != len(titles) {
+		slog.Warn("database is out of sync with the graph", "matched", len(titles), "found", len(res.Pages))
+	}
+	res.Table = buildTable(res.Pages, parsed.opts)
+	return res, nil
+}
+
+// toDatalog compiles the filter into Cozo rules with the `?[title]` entry
+func toDatalog(s sexp.Sexp, cfg logseq.Config, now time.Time) (string, map[string]any, error) {
+	c := compiler{
+		cfg:    cfg,
+		now:    now,
+		params: make(map[string]any),
+		goals:  make(map[string]string),
+	}
+	top, err := c.compile(s)
+	if err != nil {
+		return "", nil, err
+	}
+	c.rules = append(c.rules, fmt.Sprintf("?[title] := %s[title]", top))
+	if len(c.goals) > 0 {
+		c.rules = append([]string{resolveRules}, c.rules...)
+	}
+	return strings.Join(c.rules, "\n"), c.params, nil
+}
+
+// compiler translates every filter into the `rN[title]` rule
+type compiler struct {
+	cfg    logseq.Config
+	now    time.Time
+	rules  []string
+	params map[string]any
+	// Counter of the generated rule and parameter names
+	next int
+	// Goal rules of the resolved names by the matched titles
+	goals map[string]string
+}
+
+func (c *compiler) name(prefix string) string {
+	c.next++
+	return fmt.Sprintf("%s%d", prefix, c.next)
+}
+
+// param binds the value returning its reference
+func (c *compiler) param(v any) string {
+	name := c.name("p")
+	c.params[name] = v
+	return "$" + name
+}
+
+func (c *compiler) define(rule string, bodies ...string) {
+	for _, body := range bodies {
+		c.rules = append(c.rules, fmt.Sprintf("%s[t] := %s", rule, body))
+	}
+}
+
+// same returns the atoms matching `v` with the page named `title`
+func (c *compiler) same(v, title string) string {
+	goal, ok := c.goals[title]
+	if !ok {
+		goal = c.name("g")
+		c.goals[title] = goal
+		p := c.param(title)
+		c.rules = append(c.rules,
+			fmt.Sprintf("%s[r] := k = lowercase(trim(%s)), resolved[k, r]", goal, p),
+			fmt.Sprintf("%s[r] := k = lowercase(trim(%s)), not resolvable[k], r = k", goal, p),
+		)
+	}
+	return fmt.Sprintf("canon[%s, r], %s[r]", v, goal)
+}
+
+// values returns bodies matching pages with the property value the same way containsValue does,
+// `binding` restricts properties e.g. by name
+func (c *compiler) values(binding, value string) []string {
+	return []string{
+		fmt.Sprintf("*page_prop{page_title: t, %svalue: v, text: false}, %s", binding, c.same("v", value)),
+		fmt.Sprintf("*page_prop{page_title: t, %svalue: %s, text: true}", binding, c.param(value)),
+	}
+}
+
+func (c *compiler) compile(sex sexp.Sexp) (string, error) {
+	switch sex := sex.I.(type) {
+	case sexp.List:
+		if len(sex) == 0 {
+			break
+		}
+		head, ok := sex[0].I.(string)
+		if !ok {
+			return "", fmt.Errorf("unexpected list head type %#v", sex[0].I)
+		}
+		switch head {
+		case "and", "or":
+			return c.compileJunction(head, sex)
+		case "not":
+			return c.compileNot(sex)
+		case "between":
+			return c.compileBetween(sex)
+		case "page-property", "property":
+			return c.compileProperty(head, sex)
+		case "page-tags":
+			return c.compilePageTags(sex)
+		case "task":
+			return "", fmt.Errorf("'task' %w", ErrUnsupported)
+		default:
+			return "", fmt.Errorf("unexpected string list entry %s", head)
+		}
+	case string:
+		return c.compileString(sex)
+	}
+	return "", fmt.Errorf("unexpected sexp format with value %#v", sex)
+}
+
+// compileJunction conjuncts operands in a single body for 'and' and defines a body per operand for 'or'
+func (c *compiler) compileJunction(head string, l sexp.List) (string, error) {
+	if len(l) == 1 {
+		if head == "and" {
+			return "", ErrIncorrectAnd
+		}
+		return "", ErrIncorrectOr
+	}
+	operands := make([]string, len(l)-1)
+	for i := 1; i < len(l); i++ {
+		rule, err := c.compile(l[i])
+		if err != nil {
+			return "", fmt.Errorf("failed to compile '%s' with %w", head, err)
+		}
+		operands[i-1] = rule + "[t]"
+	}
+	rule := c.name("r")
+	if head == "and" {
+		c.define(rule, strings.Join(operands, ", "))
+	} else {
+		c.define(rule, operands...)
+	}
+	return rule, nil
+}
+
+func (c *compiler) compileNot(l sexp.List) (string, error) {
+	if len(l) != 2 {
+		return "", ErrNotSyntaxError
+	}
+	operand, err := c.compile(l[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to compile 'not' operand with %w", err)
+	}
+	rule := c.name("r")
+	c.define(rule, fmt.Sprintf("*page{title: t}, not %s[t]", operand))
+	return rule, nil
+}
+
+func (c *compiler) compileBetween(l sexp.List) (string, error) {
+	start, end, err := betweenDays(l, c.cfg, c.now)
+	if err != nil {
+		return "", err
+	}
+	rule := c.name("r")
+	c.define(rule, fmt.Sprintf(
+		"*journal{page_title: t, day}, day >= %s, day <= %s",
+		c.param(journalDay(start)), c.param(journalDay(end)),
+	))
+	return rule, nil
+}
+
+// compileProperty matches properties at any level, 'page-property' value may be a reference
+func (c *compiler) compileProperty(head string, l sexp.List) (string, error) {
+	incorrect := ErrIncorrectProperty
+	if head == "page-property" {
+		incorrect = ErrIncorrectPageProperty
+	}
+	if len(l) < 2 || len(l) > 3 {
+		return "", incorrect
+	}
+	cdr := make([]string, len(l)-1)
+	for i := 1; i < len(l); i++ {
+		switch el := l[i].I.(type) {
+		case string:
+			cdr[i-1] = el
+		case sexp.QString:
+			if head == "page-property" {
+				return "", fmt.Errorf("got unexpected '%s' value %#v", head, el)
+			}
+			cdr[i-1] = strings.TrimPrefix(strings.TrimSuffix(string(el), `"`), `"`)
+		default:
+			return "", fmt.Errorf("got unexpected '%s' value %#v", head, l[i].I)
+		}
+	}
+
+	rule := c.name("r")
+	name := c.param(strings.TrimPrefix(cdr[0], ":"))
+	if len(cdr) == 1 {
+		c.define(rule, fmt.Sprintf("*page_prop{page_title: t, name: %s}", name))
+		return rule, nil
+	}
+	value := cdr[1]
+	if head == "page-property" {
+		value = logseq.ExtractReference(value)
+	}
+	c.define(rule, c.values(fmt.Sprintf("name: %s, ", name), value)...)
+	return rule, nil
+}
+
+func (c *compiler) compilePageTags(l sexp.List) (string, error) {
+	if len(l) == 1 {
+		return "", ErrIncorrectPageTags
+	}
+	bodies := make([]string, len(l)-1)
+	for i := 1; i < len(l); i++ {
+		tag, ok := l[i].I.(string)
+		if !ok {
+			return "", ErrIncorrectPageTags
+		}
+		bodies[i-1] = "*page_tag{page_title: t, tag: v}, " + c.same("v", logseq.ExtractReference(tag))
+	}
+	rule := c.name("r")
+	c.define(rule, bodies...)
+	return rule, nil
+}
+
+// compileString matches the page itself, its property values and references
+func (c *compiler) compileString(str string) (string, error) {
+	match := linkRegex.FindStringSubmatch(str)
+	if len(match) != 2 {
+		return "", fmt.Errorf("unexpected string atom '%s'", str)
+	}
+	tag := logseq.ExtractReference(match[1])
+
+	rule := c.name("r")
+	c.define(rule,
+		"*page{title: t}, "+c.same("t", tag),
+		"*page_ref{src: t, target: v}, "+c.same("v", tag),
+	)
+	c.define(rule, c.values("", tag)...)
+	return rule, nil
+}
+
+// journalDay formats the date as LogSeq's yyyymmdd journal day
+func journalDay(date time.Time) int {
+	return date.Year()*10000 + int(date.Month())*100 + date.Day()
+}
@@ -0,0 +1,209 @@
+package query
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mimi/internal/provider/logseq"
+	"mimi/internal/provider/logseq/db"
+	"mimi/internal/provider/logseq/query/sexp"
+)
+
+func TestToDatalog(t *testing.T) {
+	now := time.Date(2024, time.January, 20, 15, 4, 5, 0, time.UTC)
+	compile := func(q string) (string, map[string]any, error) {
+		parsed, err := sexp.Parse(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return toDatalog(parsed, logseq.DefaultConfig(), now)
+	}
+
+	rules, params, err := compile(`(or (page-tags [[Species]]) (and [[fern]] (between -7d today)))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rules, "?[title] := r") || !strings.Contains(rules, resolveRules) {
+		t.Errorf("unexpected rules %s", rules)
+	}
+	values := make([]any, 0, len(params))
+	for _, v := range params {
+		values = append(values, v)
+	}
+	for _, v := range []any{"Species", "fern", 20240113, 20240120} {
+		if !slices.Contains(values, v) {
+			t.Errorf("expected %v among params %v", v, params)
+		}
+	}
+
+	// Journal days are matched without resolving names
+	rules, _, err = compile(`(between 20240101 20240131)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(rules, resolveRules) {
+		t.Errorf("unexpected resolve rules in %s", rules)
+	}
+
+	query2expected := map[string]error{
+		`(task todo)`:                     ErrUnsupported,
+		`(and [[fern]] (task))`:           ErrUnsupported,
+		`(or)`:                            ErrIncorrectOr,
+		`(not [[a]] [[b]])`:               ErrNotSyntaxError,
+		`(page-tags)`:                     ErrIncorrectPageTags,
+		`(property :a :b :c)`:             ErrIncorrectProperty,
+		`(page-property :a b c)`:          ErrIncorrectPageProperty,
+		`(between 20240101)`:              ErrIncorrectBetween,
+		`(not (and (between -7d today)))`: nil,
+	}
+	for q, expected := range query2expected {
+		_, _, err := compile(q)
+		if expected == nil && err != nil {
+			t.Errorf("failed to compile '%s' with %s", q, err)
+		}
+		if expected != nil && !errors.Is(err, expected) {
+			t.Errorf("expected '%s' to fail with '%s', got '%v'", q, expected, err)
+		}
+	}
+}
+
+// Names are resolved with titles taking precedence over aliases
+const goldenResolveRules = `title_name[k] := *page{title}, k = lowercase(trim(title))
+resolved[k, r] := title_name[k], r = k
+resolved[k, r] :=
+*page_alias{alias, page_title},
+k = lowercase(trim(alias)),
+not title_name[k],
+r = lowercase(trim(page_title))
+resolvable[k] := resolved[k, r]
+name[s] := *page{title: s}
+name[s] := *page_prop{value: s}
+name[s] := *page_ref{target: s}
+name[s] := *page_tag{tag: s}
+canon[s, r] := name[s], k = lowercase(trim(s)), resolved[k, r]
+canon[s, r] := name[s], k = lowercase(trim(s)), not resolvable[k], r = k
+`
+
+func TestToDatalog_Golden(t *testing.T) {
+	cases := []struct {
+		query  string
+		rules  string
+		params map[string]any
+	}{
+		{
+			query: `(page-tags [[Species]])`,
+			rules: goldenResolveRules + `g1[r] := k = lowercase(trim($p2)), resolved[k, r]
+g1[r] := k = lowercase(trim($p2)), not resolvable[k], r = k
+r3[t] := *page_tag{page_title: t, tag: v}, canon[v, r], g1[r]
+?[title] := r3[title]`,
+			params: map[string]any{"p2": "Species"},
+		},
+		{
+			// Text values are compared exactly, others are resolved as pages
+			query: `(property :rating "5")`,
+			rules: goldenResolveRules + `g3[r] := k = lowercase(trim($p4)), resolved[k, r]
+g3[r] := k = lowercase(trim($p4)), not resolvable[k], r = k
+r1[t] := *page_prop{page_title: t, name: $p2, value: v, text: false}, canon[v, r], g3[r]
+r1[t] := *page_prop{page_title: t, name: $p2, value: $p5, text: true}
+?[title] := r1[title]`,
+			params: map[string]any{"p2": "rating", "p4": "5", "p5": "5"},
+		},
+		{
+			query: `(between 20240101 20240131)`,
+			rules: `r1[t] := *journal{page_title: t, day}, day >= $p2, day <= $p3
+?[title] := r1[title]`,
+			params: map[string]any{"p2": 20240101, "p3": 20240131},
+		},
+	}
+	// Indentation of the rules doesn't matter
+	normalize := func(rules string) []string {
+		var lines []string
+		for _, line := range strings.Split(rules, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		return lines
+	}
+	for _, c := range cases {
+		parsed, err := sexp.Parse(c.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules, params, err := toDatalog(parsed, logseq.DefaultConfig(), time.Now())
+		if err != nil {
+			t.Fatalf("failed to compile '%s' with %s", c.query, err)
+		}
+		if !slices.Equal(normalize(rules), normalize(c.rules)) {
+			t.Errorf("unexpected rules of '%s':\n%s", c.query, rules)
+		}
+		if !maps.Equal(params, c.params) {
+			t.Errorf("expected params %v, got %v for '%s'", c.params, params, c.query)
+		}
+	}
+}
+
+func TestEvalDatalog(t *testing.T) {
+	conn, err := db.Open("mem", "")
+	if err != nil {
+		t.Skipf("CozoDB engine is unavailable: %s", err)
+	}
+	defer conn.Close()
+	q := db.New(conn)
+	if err := q.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	g := logseq.NewRegexGraph(fixtureGraph)
+	if err := logseq.Sync(t.Context(), g, q, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	queries := []string{
+		`{{query [[fern]]}}`,
+		`{{query [[Turnera]]}}`,
+		`{{query [[Project/Rockets]]}}`,
+		`{{query [[Fruit Garden]]}}`,
+		`{{query (page-tags [[Species]])}}`,
+		`{{query (page-tags [[Garden]] [[species]])}}`,
+		`{{query (page-property :alias Turnera)}}`,
+		`{{query (page-property :tags)}}`,
+		`{{query (property :season "spring")}}`,
+		`{{query (between [[Jan 1st, 2024]] [[Feb 1st, 2024]])}}`,
+		`{{query (and [[damiana]] (between [[Jan 1st, 2024]] [[Mar 1st, 2024]]))}}`,
+		`{{query (or (page-tags [[garden]]) [[what?]])}}`,
+		`{{query (not (page-tags [[species]]))}}`,
+		`{{query (and (not [[fern]]) (or [[damiana]] (page-property :alias)))}}`,
+	}
+	for _, query := range queries {
+		expected, err := Eval(t.Context(), g, query)
+		if err != nil {
+			t.Errorf("failed to eval query '%s' with %s", query, err)
+			continue
+		}
+		got, err := EvalDatalog(t.Context(), q, g, query)
+		if err != nil {
+			t.Errorf("failed to eval Datalog query '%s' with %s", query, err)
+			continue
+		}
+		if !slices.Equal(pageTitles(got.Pages), pageTitles(expected.Pages)) {
+			t.Errorf("expected %v, got %v for '%s'", pageTitles(expected.Pages), pageTitles(got.Pages), query)
+		}
+	}
+
+	if _, err := EvalDatalog(t.Context(), q, g, `{{query (task todo)}}`); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected unsupported 'task', got %v", err)
+	}
+}
+
+func pageTitles(pages []logseq.Page) []string {
+	titles := make([]string, len(pages))
+	for i, page := range pages {
+		titles[i] = page.Title()
+	}
+	slices.Sort(titles)
+	return titles
+}
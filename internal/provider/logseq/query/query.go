@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"slices"
 	"strings"
+	"time"
 
 	"mimi/internal/provider/logseq"
 	"mimi/internal/provider/logseq/query/sexp"
@@ -32,6 +33,9 @@ var (
 	ErrIncorrectPageTags     = fmt.Errorf("incorrect 'page-tags' statement")
 	ErrIncorrectProperty     = fmt.Errorf("incorrect 'property' statement")
 	ErrIncorrectAnd          = fmt.Errorf("incorrect 'and' statement")
+	ErrIncorrectOr           = fmt.Errorf("incorrect 'or' statement")
+	ErrIncorrectBetween      = fmt.Errorf("incorrect 'between' statement")
+	ErrIncorrectTask         = fmt.Errorf("incorrect 'task' statement")
 )
 
 type QueryOptions struct {
@@ -78,7 +82,7 @@ type Result struct {
 	Table [][]string
 }
 
-func Eval(ctx context.Context, g logseq.RegexGraph, q string) (res Result, _ error) {
+func Eval(ctx context.Context, g logseq.Graph, q string) (res Result, _ error) {
 	// Parse query
 	parsed, err := parseQuery(q)
 	if err != nil {
@@ -86,14 +90,34 @@ func Eval(ctx context.Context, g logseq.RegexGraph, q string) (res Result, _ err
 	}
 
 	// Evaluate state
-	filter, err := eval(parsed.s)
+	var resolver logseq.Resolver
+	if rg, ok := g.(logseq.ResolvingGraph); ok {
+		resolver = rg.Resolver()
+	} else {
+		resolver = logseq.NewResolver(g.WalkPages())
+	}
+	filter, err := eval(parsed.s, env{
+		cfg:      g.GetConfig(),
+		now:      now(),
+		resolver: resolver,
+	})
 	if err != nil {
 		return res, fmt.Errorf("failed to evaluate state with %w", err)
 	}
 
-	// Filter pages
+	// Narrow pages down with the indexes and filter them
+	var pages []logseq.Page
+	indexed := false
+	if ig, ok := g.(logseq.IndexingGraph); ok {
+		var found map[string]logseq.Page
+		found, indexed = candidates(parsed.s, ig)
+		pages = slices.Collect(maps.Values(found))
+	}
+	if !indexed {
+		pages = slices.Collect(g.WalkPages())
+	}
 	pageSet := make(map[string]logseq.Page)
-	for page := range g.WalkPages() {
+	for _, page := range pages {
 		if !filter(page) {
 			continue
 		}
@@ -107,7 +131,105 @@ func Eval(ctx context.Context, g logseq.RegexGraph, q string) (res Result, _ err
 	return res, nil
 }
 
-func eval(sex sexp.Sexp) (pageFilter, error) {
+// candidates returns pages by paths which may match the query according to the graph indexes,
+// it reports false when the query can't be narrowed down and all pages have to be filtered
+func candidates(sex sexp.Sexp, g logseq.IndexingGraph) (map[string]logseq.Page, bool) {
+	collect := func(lists ...[]logseq.Page) map[string]logseq.Page {
+		found := make(map[string]logseq.Page)
+		for _, pages := range lists {
+			for _, page := range pages {
+				found[page.Path] = page
+			}
+		}
+		return found
+	}
+
+	switch sex := sex.I.(type) {
+	case string:
+		match := linkRegex.FindStringSubmatch(sex)
+		if len(match) != 2 {
+			return nil, false
+		}
+		title := logseq.ExtractReference(match[1])
+		return collect(g.PagesByTitle(title), g.PagesByRef(title)), true
+	case sexp.List:
+		if len(sex) < 2 {
+			return nil, false
+		}
+		head, _ := sex[0].I.(string)
+		switch head {
+		case "and":
+			// Any narrowed down operand limits the result
+			var found map[string]logseq.Page
+			for _, operand := range sex[1:] {
+				pages, ok := candidates(operand, g)
+				if !ok {
+					continue
+				}
+				if found == nil {
+					found = pages
+					continue
+				}
+				maps.DeleteFunc(found, func(path string, _ logseq.Page) bool {
+					_, ok := pages[path]
+					return !ok
+				})
+			}
+			return found, found != nil
+		case "or":
+			found := make(map[string]logseq.Page)
+			for _, operand := range sex[1:] {
+				pages, ok := candidates(operand, g)
+				if !ok {
+					return nil, false
+				}
+				maps.Copy(found, pages)
+			}
+			return found, true
+		case "page-tags":
+			var lists [][]logseq.Page
+			for _, operand := range sex[1:] {
+				tag, ok := operand.I.(string)
+				if !ok {
+					return nil, false
+				}
+				lists = append(lists, g.PagesByTag(logseq.ExtractReference(tag)))
+			}
+			return collect(lists...), true
+		case "page-property", "property":
+			name, ok := sex[1].I.(string)
+			if !ok {
+				return nil, false
+			}
+			return collect(g.PagesByProperty(strings.TrimPrefix(name, ":"))), true
+		}
+	}
+	return nil, false
+}
+
+// Validate ensures that query is supported without evaluating it against the graph,
+// config is required to parse journal dates
+func Validate(cfg logseq.Config, q string) error {
+	parsed, err := parseQuery(q)
+	if err != nil {
+		return fmt.Errorf("failed to parse query with %w", err)
+	}
+	if _, err := eval(parsed.s, env{cfg: cfg}); err != nil {
+		return fmt.Errorf("failed to evaluate state with %w", err)
+	}
+	return nil
+}
+
+// env holds graph settings required to evaluate the query
+type env struct {
+	cfg logseq.Config
+	// Relative dates like today are resolved against it
+	now time.Time
+	// Empty one matches titles only case-insensitively
+	resolver logseq.Resolver
+}
+
+func eval(sex sexp.Sexp, e env) (pageFilter, error) {
 	switch sex := sex.I.(type) {
 	case sexp.List:
 		// Most of the query logic sits inside of a list
@@ -120,15 +242,21 @@ func eval(sex sexp.Sexp) (pageFilter, error) {
 			// Find out filter and execute it
 			switch head {
 			case "and":
-				return evalAnd(sex)
+				return evalAnd(sex, e)
+			case "or":
+				return evalOr(sex, e)
 			case "not":
-				return evalNot(sex)
+				return evalNot(sex, e)
+			case "between":
+				return evalBetween(sex, e.cfg, e.now)
 			case "page-property":
-				return evalPageProperty(sex)
+				return evalPageProperty(sex, e.resolver)
 			case "page-tags":
-				return evalPageTags(sex)
+				return evalPageTags(sex, e.resolver)
 			case "property":
-				return evalProperty(sex)
+				return evalProperty(sex, e.resolver)
+			case "task":
+				return evalTask(sex)
 			default:
 				return emptyFilter, fmt.Errorf("unexpected string list entry %s", head)
 			}
@@ -136,20 +264,20 @@ func eval(sex sexp.Sexp) (pageFilter, error) {
 			return emptyFilter, fmt.Errorf("unexpected list head type %#v", head)
 		}
 	case string:
-		return evalString(sex)
+		return evalString(sex, e.resolver)
 	}
 
 	return emptyFilter, fmt.Errorf("unexpected sexp format with value %#v", sex)
 }
 
-func evalAnd(l sexp.List) (pageFilter, error) {
+func evalAnd(l sexp.List, e env) (pageFilter, error) {
 	slog.Info("translating 'and' expression")
 	if len(l) == 1 {
 		return emptyFilter, ErrIncorrectAnd
 	}
 	filters := make([]pageFilter, len(l)-1)
 	for i := 1; i < len(l); i++ {
-		filter, err := eval(l[i])
+		filter, err := eval(l[i], e)
 		if err != nil {
 			return emptyFilter, fmt.Errorf("failed to evaluate 'and' with %w", err)
 		}
@@ -165,12 +293,35 @@ func evalAnd(l sexp.List) (pageFilter, error) {
 	}, nil
 }
 
-func evalNot(l sexp.List) (pageFilter, error) {
+func evalOr(l sexp.List, e env) (pageFilter, error) {
+	slog.Info("translating 'or' expression")
+	if len(l) == 1 {
+		return emptyFilter, ErrIncorrectOr
+	}
+	filters := make([]pageFilter, len(l)-1)
+	for i := 1; i < len(l); i++ {
+		filter, err := eval(l[i], e)
+		if err != nil {
+			return emptyFilter, fmt.Errorf("failed to evaluate 'or' with %w", err)
+		}
+		filters[i-1] = filter
+	}
+	return func(p logseq.Page) bool {
+		for _, filter := range filters {
+			if filter(p) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func evalNot(l sexp.List, e env) (pageFilter, error) {
 	slog.Info("translating 'not' expression")
 	if len(l) != 2 {
 		return emptyFilter, ErrNotSyntaxError
 	}
-	filter, err := eval(l[1])
+	filter, err := eval(l[1], e)
 	if err != nil {
 		return emptyFilter, fmt.Errorf("failed to eval 'not' operand with %w", err)
 	}
@@ -179,7 +330,7 @@ func evalNot(l sexp.List) (pageFilter, error) {
 	}, nil
 }
 
-func evalPageProperty(l sexp.List) (pageFilter, error) {
+func evalPageProperty(l sexp.List, r logseq.Resolver) (pageFilter, error) {
 	slog.Info("translating 'page-property' expression")
 	if len(l) < 2 || len(l) > 3 {
 		return emptyFilter, ErrIncorrectPageProperty
@@ -198,24 +349,15 @@ func evalPageProperty(l sexp.List) (pageFilter, error) {
 	// Build filter
 	return func(p logseq.Page) bool {
 		propName := strings.TrimPrefix(cdr[0], ":")
-		values, ok := p.Info.Get(propName)
-		if !ok {
-			return false
-		}
+		values := propValues(p, propName)
 		if len(cdr) == 1 {
 			return len(values) > 0
 		}
-		propValue := cdr[1]
-		for _, value := range values {
-			if value == propValue {
-				return true
-			}
-		}
-		return false
+		return containsValue(r, values, logseq.ExtractReference(cdr[1]))
 	}, nil
 }
 
-func evalPageTags(l sexp.List) (pageFilter, error) {
+func evalPageTags(l sexp.List, r logseq.Resolver) (pageFilter, error) {
 	if len(l) == 1 {
 		return emptyFilter, ErrIncorrectPageTags
 	}
@@ -238,7 +380,9 @@ func evalPageTags(l sexp.List) (pageFilter, error) {
 		}
 
 		for _, tag := range tags {
-			if slices.Contains(cdr, tag) {
+			if slices.ContainsFunc(cdr, func(title string) bool {
+				return r.Same(title, tag.Target)
+			}) {
 				return true
 			}
 		}
@@ -247,7 +391,7 @@ func evalPageTags(l sexp.List) (pageFilter, error) {
 	}, nil
 }
 
-func evalProperty(l sexp.List) (pageFilter, error) {
+func evalProperty(l sexp.List, r logseq.Resolver) (pageFilter, error) {
 	if len(l) < 2 || len(l) > 3 {
 		return emptyFilter, ErrIncorrectProperty
 	}
@@ -273,16 +417,13 @@ func evalProperty(l sexp.List) (pageFilter, error) {
 		propName := strings.TrimPrefix(cdr[0], ":")
 
 		// Check page props
-		pageProps, ok := p.Info.Get(propName)
-		if !ok {
-			return false
-		}
+		pageProps := propValues(p, propName)
 		if len(cdr) == 1 {
 			// We need only property existence
 			return len(pageProps) > 0
 		} else {
 			// Should ensure value match
-			if slices.Contains(pageProps, cdr[1]) {
+			if containsValue(r, pageProps, cdr[1]) {
 				return true
 			}
 		}
@@ -291,7 +432,28 @@ func evalProperty(l sexp.List) (pageFilter, error) {
 	}, nil
 }
 
-func evalString(str string) (pageFilter, error) {
+func evalTask(l sexp.List) (pageFilter, error) {
+	if len(l) == 1 {
+		return emptyFilter, ErrIncorrectTask
+	}
+
+	markers := make([]string, len(l)-1)
+	for i := 1; i < len(l); i++ {
+		marker, ok := l[i].I.(string)
+		if !ok {
+			return emptyFilter, ErrIncorrectTask
+		}
+		markers[i-1] = strings.ToUpper(marker)
+	}
+
+	return func(p logseq.Page) bool {
+		return slices.ContainsFunc(p.Info.Tasks, func(task logseq.Task) bool {
+			return slices.Contains(markers, task.Marker)
+		})
+	}, nil
+}
+
+func evalString(str string, r logseq.Resolver) (pageFilter, error) {
 	match := linkRegex.FindStringSubmatch(str)
 
 	switch len(match) {
@@ -300,15 +462,17 @@ func evalString(str string) (pageFilter, error) {
 	case 2:
 		return func(p logseq.Page) bool {
 			tag := logseq.ExtractReference(match[1])
-			if p.Title() == tag {
+			if r.Same(p.Title(), tag) {
 				return true
 			}
 			for _, prop := range p.Info.Props {
-				if slices.Contains(prop.Values, tag) {
+				if containsValue(r, prop.Values, tag) {
 					return true
 				}
 			}
-			return slices.Contains(p.Info.Refs, tag)
+			return slices.ContainsFunc(p.Info.Refs, func(ref logseq.Ref) bool {
+				return ref.IsPage() && r.Same(ref.Target, tag)
+			})
 		}, nil
 	}
 
@@ -370,10 +534,33 @@ func parseQuery(q string) (res Query, err error) {
 			}
 		}
 	}
+	if err == nil && !slices.Contains(res.opts.properties, res.opts.sortBy) {
+		err = fmt.Errorf("sort column '%s' is not among query properties %v", res.opts.sortBy, res.opts.properties)
+	}
 
 	return
 }
 
+// propValues collects values of all the page and block properties named `name`
+func propValues(p logseq.Page, name string) (values []logseq.Ref) {
+	for _, prop := range p.Info.Props {
+		if prop.Name == name {
+			values = append(values, prop.Values...)
+		}
+	}
+	return values
+}
+
+// containsValue matches page references by the resolved titles and text values exactly
+func containsValue(r logseq.Resolver, values []logseq.Ref, value string) bool {
+	return slices.ContainsFunc(values, func(ref logseq.Ref) bool {
+		if ref.IsPage() {
+			return r.Same(ref.Target, value)
+		}
+		return ref.Target == value
+	})
+}
+
 func emptyFilter(page logseq.Page) bool {
 	return false
 }
@@ -395,7 +582,7 @@ func buildTable(pages []logseq.Page, queryOpts QueryOptions) [][]string {
 					rows[i][j] = ""
 					continue
 				}
-				rows[i][j] = strings.Join(props, ", ")
+				rows[i][j] = strings.Join(logseq.Targets(props), ", ")
 			}
 		}
 	}
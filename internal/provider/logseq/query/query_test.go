@@ -1,15 +1,21 @@
 package query
 
 import (
+	"os"
+	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"mimi/internal/provider/logseq"
+	"mimi/internal/provider/logseq/query/sexp"
 )
 
 const (
 	// FIXME: Simplify graph and store in the project
-	graphPath = "/home/user/code/clone/cvland"
+	graphPath    = "/home/user/code/clone/cvland"
+	fixtureGraph = "../testdata/graph"
 )
 
 func TestEval(t *testing.T) {
@@ -118,16 +124,16 @@ func TestBuildTable(t *testing.T) {
 					Props: []logseq.Property{
 						logseq.Property{
 							Name:   "tags",
-							Values: []string{"1", "2", "3"},
+							Values: []logseq.Ref{{Kind: logseq.RefText, Target: "1"}, {Kind: logseq.RefText, Target: "2"}, {Kind: logseq.RefText, Target: "3"}},
 							Level:  logseq.PageLevel,
 						},
 						logseq.Property{
 							Name:   "alias",
-							Values: []string{"bar"},
+							Values: []logseq.Ref{{Kind: logseq.RefPage, Target: "bar"}},
 							Level:  logseq.PageLevel,
 						},
 					},
-					Refs: []string{},
+					Refs: []logseq.Ref{},
 				},
 			},
 			logseq.Page{
@@ -136,11 +142,11 @@ func TestBuildTable(t *testing.T) {
 					Props: []logseq.Property{
 						logseq.Property{
 							Name:   "tags",
-							Values: []string{"baz", "huz"},
+							Values: []logseq.Ref{{Kind: logseq.RefPage, Target: "baz"}, {Kind: logseq.RefPage, Target: "huz"}},
 							Level:  logseq.PageLevel,
 						},
 					},
-					Refs: []string{},
+					Refs: []logseq.Ref{},
 				},
 			},
 		},
@@ -189,3 +195,223 @@ func TestBuildTable(t *testing.T) {
 		t.Errorf("expected %#v, got %#v", expected[i], table)
 	}
 }
+
+func TestValidate(t *testing.T) {
+	valid := []string{
+		`{{query (and (page-tags [[species]]) (not (property :supply "next-month")))}}`,
+		"{{query (page-tags [[psycho]])}}\nquery-properties:: [:page :supply]\nquery-sort-by:: supply",
+		`(page-property :wood-durability)`,
+		`{{query (or (page-tags [[species]]) [[fern]])}}`,
+	}
+	for _, q := range valid {
+		if err := Validate(logseq.DefaultConfig(), q); err != nil {
+			t.Errorf("expected '%s' to be valid, got %s", q, err)
+		}
+	}
+
+	invalid := []string{
+		`{{query (or)}}`,
+		`{{query (and (page-tags [[species]])}}`,
+		`{{query (not [[a]] [[b]])}}`,
+		"{{query [[a]]}}\nquery-properties:: [:page]\nquery-sort-by:: supply",
+	}
+	for _, q := range invalid {
+		if err := Validate(logseq.DefaultConfig(), q); err == nil {
+			t.Errorf("expected '%s' to be invalid", q)
+		}
+	}
+}
+
+func TestFindVocabulary(t *testing.T) {
+	dir := t.TempDir()
+	pages := map[string]string{
+		"damiana.md": "tags:: species, psycho\nsupply:: next-month\n\n- note",
+		"fern.md":    "tags:: species\nsupply:: now\n\n- note",
+		"notes.md":   "- [[fern]]",
+	}
+	for name, content := range pages {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	v := FindVocabulary(logseq.NewRegexGraph(dir))
+	if !slices.Equal(v.Tags, []string{"species", "psycho"}) {
+		t.Errorf("unexpected tags %v", v.Tags)
+	}
+	if len(v.Properties) != 1 || v.Properties[0].Name != "supply" ||
+		!slices.Equal(v.Properties[0].Values, []string{"next-month", "now"}) {
+		t.Errorf("unexpected properties %#v", v.Properties)
+	}
+}
+
+func TestEval_Between(t *testing.T) {
+	query2expected := map[string][]string{
+		`{{query (between [[Jan 1st, 2024]] [[Feb 1st, 2024]])}}`:                   {"Feb 1st, 2024", "Jan 15th, 2024", "Jan 1st, 2024"},
+		`{{query (between [[Feb 1st, 2024]] [[Jan 10th, 2024]])}}`:                  {"Feb 1st, 2024", "Jan 15th, 2024"},
+		`{{query (between 20240301 20240331)}}`:                                     {"Mar 10th, 2024"},
+		`{{query (and [[damiana]] (between [[Jan 1st, 2024]] [[Mar 1st, 2024]]))}}`: {"Feb 1st, 2024", "Jan 1st, 2024"},
+	}
+	g := logseq.NewRegexGraph(fixtureGraph)
+
+	for q, expected := range query2expected {
+		res, err := Eval(t.Context(), g, q)
+		if err != nil {
+			t.Errorf("failed to eval query '%s' with %s", q, err)
+			continue
+		}
+		titles := make([]string, len(res.Pages))
+		for i, page := range res.Pages {
+			titles[i] = page.Title()
+		}
+		slices.Sort(titles)
+		if !slices.Equal(titles, expected) {
+			t.Errorf("expected %v, got %v for '%s'", expected, titles, q)
+		}
+	}
+}
+
+func TestEvalBetween_Relative(t *testing.T) {
+	now := time.Date(2024, time.January, 20, 15, 4, 5, 0, time.UTC)
+	query2expected := map[string][]string{
+		`(between -7d today)`:     {"Jan 15th, 2024"},
+		`(between -1m now)`:       {"Jan 15th, 2024", "Jan 1st, 2024"},
+		`(between -3w yesterday)`: {"Jan 15th, 2024", "Jan 1st, 2024"},
+		`(between tomorrow +2w)`:  {"Feb 1st, 2024"},
+		`(between -1y -6d)`:       {"Jan 1st, 2024"},
+	}
+	g := logseq.NewRegexGraph(fixtureGraph)
+
+	for q, expected := range query2expected {
+		parsed, err := sexp.Parse(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filter, err := evalBetween(parsed.I.(sexp.List), g.Config, now)
+		if err != nil {
+			t.Errorf("failed to eval '%s' with %s", q, err)
+			continue
+		}
+		var titles []string
+		for page := range g.WalkPages() {
+			if filter(page) {
+				titles = append(titles, page.Title())
+			}
+		}
+		slices.Sort(titles)
+		if !slices.Equal(titles, expected) {
+			t.Errorf("expected %v, got %v for '%s'", expected, titles, q)
+		}
+	}
+
+	for _, q := range []string{`(between -7d)`, `(between -7x today)`, `(between [[Jan 42nd, 2024]] today)`} {
+		if err := Validate(g.Config, q); err == nil {
+			t.Errorf("expected '%s' to be invalid", q)
+		}
+	}
+}
+
+func TestEval_Resolve(t *testing.T) {
+	query2expected := map[string][]string{
+		`{{query [[Turnera]]}}`:                    {"Custom Title", "Feb 1st, 2024", "Jan 1st, 2024", "damiana"},
+		`{{query [[Project/Rockets]]}}`:            {"Custom Title", "project/rockets"},
+		`{{query (page-tags [[Species]])}}`:        {"damiana", "fern"},
+		`{{query (page-property :alias Turnera)}}`: {"damiana"},
+	}
+	g := logseq.NewRegexGraph(fixtureGraph)
+
+	for _, g := range []logseq.Graph{g, logseq.NewIndexedGraph(g)} {
+		for q, expected := range query2expected {
+			res, err := Eval(t.Context(), g, q)
+			if err != nil {
+				t.Errorf("failed to eval query '%s' with %s", q, err)
+				continue
+			}
+			titles := make([]string, len(res.Pages))
+			for i, page := range res.Pages {
+				titles[i] = page.Title()
+			}
+			slices.Sort(titles)
+			if !slices.Equal(titles, expected) {
+				t.Errorf("expected %v, got %v for '%s' in %T", expected, titles, q, g)
+			}
+		}
+	}
+}
+
+func TestEval_Org(t *testing.T) {
+	query2expected := map[string][]string{
+		`{{query (page-tags [[Garden]])}}`:      {"Orchard"},
+		`{{query [[fern]]}}`:                    {"Jan 15th, 2024", "Orchard", "fern", "what?"},
+		`{{query (property :season "spring")}}`: {"Orchard"},
+		`{{query (and [[what?]] (task todo))}}`: {"Orchard"},
+		`{{query [[Fruit Garden]]}}`:            {"Orchard"},
+	}
+	g := logseq.NewIndexedGraph(logseq.NewRegexGraph(fixtureGraph))
+
+	for q, expected := range query2expected {
+		res, err := Eval(t.Context(), g, q)
+		if err != nil {
+			t.Errorf("failed to eval query '%s' with %s", q, err)
+			continue
+		}
+		titles := make([]string, len(res.Pages))
+		for i, page := range res.Pages {
+			titles[i] = page.Title()
+		}
+		slices.Sort(titles)
+		if !slices.Equal(titles, expected) {
+			t.Errorf("expected %v, got %v for '%s'", expected, titles, q)
+		}
+	}
+}
+
+func TestEval_Indexed(t *testing.T) {
+	queries := []string{
+		`{{query [[Turnera]]}}`,
+		`{{query (page-tags [[species]] [[garden]])}}`,
+		`{{query (property :supply)}}`,
+		`{{query (property :season "spring")}}`,
+		`{{query (and [[fern]] (task todo))}}`,
+		`{{query (and (page-tags [[species]]) (not [[fern]]))}}`,
+		`{{query (or (page-property :alias) [[what?]])}}`,
+		`{{query (or (page-tags [[species]]) (task todo))}}`,
+		`{{query (not (page-tags [[species]]))}}`,
+	}
+	g := logseq.NewRegexGraph(fixtureGraph)
+	ig := logseq.NewIndexedGraph(g)
+
+	sorted := func(res Result) []string {
+		titles := make([]string, len(res.Pages))
+		for i, page := range res.Pages {
+			titles[i] = page.Title()
+		}
+		slices.Sort(titles)
+		return titles
+	}
+	for _, q := range queries {
+		expected, err := Eval(t.Context(), g, q)
+		if err != nil {
+			t.Fatalf("failed to eval query '%s' with %s", q, err)
+		}
+		got, err := Eval(t.Context(), ig, q)
+		if err != nil {
+			t.Fatalf("failed to eval indexed query '%s' with %s", q, err)
+		}
+		if !slices.Equal(sorted(got), sorted(expected)) {
+			t.Errorf("expected %v, got %v for '%s'", sorted(expected), sorted(got), q)
+		}
+	}
+}
+
+func TestPropValues(t *testing.T) {
+	info, err := logseq.FindPageInfo(strings.NewReader("supply:: now\n\n- Seeds\n  supply:: [[next-month]]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Block level values are matched along with the page level ones
+	got := logseq.Targets(propValues(logseq.Page{Info: info}, "supply"))
+	if expected := []string{"now", "next-month"}; !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
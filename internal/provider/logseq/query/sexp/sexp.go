@@ -20,6 +20,9 @@ type List []Sexp
 // Quoted strings go from one " to the next.  There is no escape character,
 // all characters except " are valid.
 //
+// Page references like [[Jan 1st, 2024]] are unquoted string atoms
+// even when they contain white space or parens.
+//
 // Otherwise atoms are any string of characters between any of '(', ')',
 // '"', or white space characters.  If the atom parses as a Go int type
 // using strconv.Atoi, it is taken as int; if it parses as a Go float64
@@ -106,6 +109,13 @@ func gettok(s string) (tok interface{}, rem string) {
 			return QString(s[1 : i+1]), s[i+2:]
 		}
 		return errors.New(`unmatched "`), s
+	case '[':
+		if strings.HasPrefix(s, "[[") {
+			if i := strings.Index(s, "]]"); i >= 0 {
+				return s[:i+2], s[i+2:]
+			}
+			return errors.New("unmatched [["), s
+		}
 	}
 	i := 1
 	for i < len(s) && s[i] != '(' && s[i] != ')' && s[i] != '"' &&
@@ -0,0 +1,67 @@
+package query
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+
+	"mimi/internal/provider/logseq"
+)
+
+// Upper bound of the sample values kept for each property
+const maxPropertyValues = 10
+
+// Vocabulary describes what could be queried in the graph
+type Vocabulary struct {
+	Properties []PropertyInfo `json:"properties"`
+	Tags       []string       `json:"tags"`
+}
+
+type PropertyInfo struct {
+	Name string `json:"name"`
+	// The most frequent values
+	Values []string `json:"values"`
+}
+
+// FindVocabulary collects property names with their frequent values
+// and page tags ordered by usage
+func FindVocabulary(g logseq.Graph) Vocabulary {
+	props := make(map[string]map[string]int)
+	tags := make(map[string]int)
+	for page := range g.WalkPages() {
+		for _, prop := range page.Info.Props {
+			if _, ok := props[prop.Name]; !ok {
+				props[prop.Name] = make(map[string]int)
+			}
+			for _, value := range prop.Values {
+				props[prop.Name][value.Target]++
+			}
+		}
+		pageTags, _ := page.Info.PageLevelTags()
+		for _, tag := range pageTags {
+			tags[tag.Target]++
+		}
+	}
+
+	var v Vocabulary
+	for _, name := range slices.Sorted(maps.Keys(props)) {
+		if name == "tags" {
+			continue
+		}
+		values := byFrequency(props[name])
+		v.Properties = append(v.Properties, PropertyInfo{
+			Name:   name,
+			Values: values[:min(len(values), maxPropertyValues)],
+		})
+	}
+	v.Tags = byFrequency(tags)
+	return v
+}
+
+func byFrequency(counts map[string]int) []string {
+	keys := slices.Collect(maps.Keys(counts))
+	slices.SortFunc(keys, func(lhs, rhs string) int {
+		return cmp.Or(cmp.Compare(counts[rhs], counts[lhs]), cmp.Compare(lhs, rhs))
+	})
+	return keys
+}
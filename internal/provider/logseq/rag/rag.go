@@ -2,6 +2,8 @@ package rag
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"log"
 	"log/slog"
@@ -50,7 +52,7 @@ func New(ctx context.Context, q *db.Queries) RAG {
 
 func (r RAG) Embed(ctx context.Context, content string) ([]float32, error) {
 	slog.Info("calculating embedding for content", "size", len(content))
-	v := make([]float32, 1536)
+	v := make([]float32, db.EmbeddingDim)
 	if len(content) == 0 {
 		return v, nil
 	}
@@ -75,28 +77,52 @@ func (r RAG) Embed(ctx context.Context, content string) ([]float32, error) {
 	return resp.Embeddings[0].Embedding, nil
 }
 
-func (r RAG) Retrieve(ctx context.Context, query string) (docs []*ai.Document, _ error) {
-	// Embed query
-	vec, err := r.Embed(ctx, query)
+// IndexPage embeds page's content unless it was embedded before
+func (r RAG) IndexPage(ctx context.Context, title, content string) error {
+	sum := sha256.Sum256([]byte(content))
+	hash := hex.EncodeToString(sum[:])
+	old, found, err := r.q.FindPageEmbeddingHash(title)
+	if err != nil {
+		return err
+	}
+	if found && old == hash {
+		return nil
+	}
+
+	vec, err := r.Embed(ctx, content)
 	if err != nil {
-		return docs, fmt.Errorf("failed to embed for retrieve with %w", err)
+		return fmt.Errorf("failed to embed page '%s' with %w", title, err)
 	}
+	return r.q.SavePageEmbedding(db.SavePageEmbeddingParams{
+		Title:     title,
+		Hash:      hash,
+		Embedding: vec,
+	})
+}
 
-	// Query CozoDB
-	pages, err := r.q.FindSimilarPages(vec)
+// Search finds up to `limit` pages closest to the query
+func (r RAG) Search(ctx context.Context, query string, limit int) ([]db.SimilarPageRow, error) {
+	vec, err := r.Embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed for retrieve with %w", err)
+	}
+	pages, err := r.q.FindSimilarPages(vec, limit)
 	if err != nil {
-		return docs, fmt.Errorf("failed to find similar pages with %w", err)
+		return nil, err
 	}
 	slog.Info("found similar pages", "len", len(pages))
+	return pages, nil
+}
 
+func (r RAG) Retrieve(ctx context.Context, query string) (docs []*ai.Document, _ error) {
+	pages, err := r.Search(ctx, query, 20)
+	if err != nil {
+		return docs, err
+	}
 	for _, page := range pages {
-		slog.Info("retrieved", "title", page.Title, "content", page.Content)
-		docs = append(docs, &ai.Document{
-			Content: []*ai.Part{ai.NewDataPart(page.Content)},
-			Metadata: map[string]any{
-				"title": page.Title,
-			},
-		})
+		docs = append(docs, ai.DocumentFromText(page.Content, map[string]any{
+			"title": page.Title,
+		}))
 	}
 	return docs, nil
 }
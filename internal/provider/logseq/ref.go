@@ -0,0 +1,255 @@
+package logseq
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+type RefKind string
+
+const (
+	// [[page]]
+	RefPage RefKind = "page"
+	// #tag or #[[multi word tag]]
+	RefTag RefKind = "tag"
+	// ((uuid))
+	RefBlock RefKind = "block"
+	// Property value which isn't a reference, e.g. quoted text or number
+	RefText RefKind = "text"
+)
+
+// Ref is a typed reference found in a page text or a property value
+type Ref struct {
+	Kind RefKind
+	// Page title, tag name, block UUID or plain text
+	Target string
+}
+
+// IsPage checks that reference points to a page, tags are pages too
+func (r Ref) IsPage() bool {
+	return r.Kind == RefPage || r.Kind == RefTag
+}
+
+// String formats reference back into LogSeq's syntax
+func (r Ref) String() string {
+	switch r.Kind {
+	case RefPage:
+		return "[[" + r.Target + "]]"
+	case RefTag:
+		if strings.IndexFunc(r.Target, isTagTerminator) >= 0 {
+			return "#[[" + r.Target + "]]"
+		}
+		return "#" + r.Target
+	case RefBlock:
+		return "((" + r.Target + "))"
+	default:
+		return r.Target
+	}
+}
+
+// Targets returns targets of the references
+func Targets(refs []Ref) []string {
+	targets := make([]string, len(refs))
+	for i, ref := range refs {
+		targets[i] = ref.Target
+	}
+	return targets
+}
+
+// ParseRefs finds all references in the text including nested ones
+// like [[foo [[bar]]]], references inside of code spans are ignored
+func ParseRefs(text string) []Ref {
+	refs, _ := scanRefs(text, true)
+	return refs
+}
+
+// scanRefs returns references with the text left outside of them,
+// nested references are skipped unless requested
+func scanRefs(text string, nested bool) (refs []Ref, plain string) {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		size := 1
+		switch {
+		case rest[0] == '`':
+			size = skipCode(rest)
+			b.WriteString(rest[:size])
+		case strings.HasPrefix(rest, "[["):
+			target, n, ok := bracketed(rest)
+			if !ok {
+				size = 2
+				b.WriteString(rest[:size])
+				break
+			}
+			refs = append(refs, Ref{Kind: RefPage, Target: target})
+			if nested {
+				refs = append(refs, ParseRefs(target)...)
+			}
+			size = n
+		case strings.HasPrefix(rest, "(("):
+			end := strings.Index(rest, "))")
+			if end < 0 || !uuidRegexp.MatchString(rest[2:end]) {
+				size = 2
+				b.WriteString(rest[:size])
+				break
+			}
+			refs = append(refs, Ref{Kind: RefBlock, Target: rest[2:end]})
+			size = end + 2
+		case rest[0] == '#' && isTagStart(text[:i]) && strings.HasPrefix(rest[1:], "[["):
+			target, n, ok := bracketed(rest[1:])
+			if !ok {
+				size = 3
+				b.WriteString(rest[:size])
+				break
+			}
+			refs = append(refs, Ref{Kind: RefTag, Target: target})
+			if nested {
+				refs = append(refs, ParseRefs(target)...)
+			}
+			size = 1 + n
+		case rest[0] == '#' && isTagStart(text[:i]):
+			tag := rest[1:]
+			if end := strings.IndexFunc(tag, isTagTerminator); end >= 0 {
+				tag = tag[:end]
+			}
+			// Trailing punctuation ends the sentence rather than the tag
+			tag = strings.TrimRight(tag, ".:'")
+			if tag == "" || tag[0] == '#' {
+				size = 1 + len(tag)
+				b.WriteString(rest[:size])
+				break
+			}
+			refs = append(refs, Ref{Kind: RefTag, Target: tag})
+			size = 1 + len(tag)
+		default:
+			b.WriteByte(rest[0])
+		}
+		i += size
+	}
+	return refs, b.String()
+}
+
+// ParsePropertyValues splits property value by commas respecting quotes,
+// references and code spans. Plain values are page references like in LogSeq,
+// quoted values, numbers, links and text mixed with references are kept as text
+func ParsePropertyValues(value string) []Ref {
+	var refs []Ref
+	for _, part := range splitValues(value) {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "":
+			continue
+		case len(part) > 1 && part[0] == '"' && part[len(part)-1] == '"':
+			refs = append(refs, Ref{Kind: RefText, Target: part[1 : len(part)-1]})
+		case onlyRefs(part):
+			// Nested references belong to the outer one
+			found, _ := scanRefs(part, false)
+			refs = append(refs, found...)
+		case isPlainText(part):
+			refs = append(refs, Ref{Kind: RefText, Target: part})
+		default:
+			refs = append(refs, Ref{Kind: RefPage, Target: part})
+		}
+	}
+	return refs
+}
+
+// splitValues splits by commas on the top level
+func splitValues(value string) []string {
+	var parts []string
+	var depth int
+	start := 0
+	for i := 0; i < len(value); {
+		rest := value[i:]
+		switch {
+		case rest[0] == '`':
+			i += skipCode(rest)
+		case rest[0] == '"' && depth == 0:
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				i++
+				continue
+			}
+			i += end + 2
+		case strings.HasPrefix(rest, "[[") || strings.HasPrefix(rest, "(("):
+			depth++
+			i += 2
+		case (strings.HasPrefix(rest, "]]") || strings.HasPrefix(rest, "))")) && depth > 0:
+			depth--
+			i += 2
+		case rest[0] == ',' && depth == 0:
+			parts = append(parts, value[start:i])
+			i++
+			start = i
+		default:
+			i++
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// onlyRefs checks that the value consists of references separated by spaces
+func onlyRefs(value string) bool {
+	refs, plain := scanRefs(value, false)
+	return len(refs) > 0 && strings.TrimSpace(plain) == ""
+}
+
+func isPlainText(value string) bool {
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return true
+	}
+	return strings.Contains(value, "://") || strings.ContainsAny(value, "`\"") ||
+		len(ParseRefs(value)) > 0
+}
+
+// bracketed returns the content of balanced [[...]] at the start of the text
+func bracketed(text string) (target string, size int, ok bool) {
+	depth := 0
+	for i := 0; i < len(text)-1; {
+		switch {
+		case text[i] == '[' && text[i+1] == '[':
+			depth++
+			i += 2
+		case text[i] == ']' && text[i+1] == ']':
+			depth--
+			i += 2
+			if depth == 0 {
+				return text[2 : i-2], i, true
+			}
+		default:
+			i++
+		}
+	}
+	return "", 0, false
+}
+
+// skipCode returns the size of the code span at the start of the text,
+// unclosed backticks are skipped as is
+func skipCode(text string) int {
+	n := len(text) - len(strings.TrimLeft(text, "`"))
+	fence := text[:n]
+	end := strings.Index(text[n:], fence)
+	if end < 0 {
+		return n
+	}
+	return n + end + n
+}
+
+// isTagStart checks that hash isn't a part of a word, URL or HTML entity
+func isTagStart(before string) bool {
+	if before == "" {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(before)
+	return unicode.IsSpace(r) || slices.Contains([]rune{'(', '[', ','}, r)
+}
+
+func isTagTerminator(r rune) bool {
+	return unicode.IsSpace(r) || strings.ContainsRune(`,;!?"()[]{}`, r)
+}
@@ -0,0 +1,113 @@
+package logseq
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseRefs(t *testing.T) {
+	text2expected := map[string][]Ref{
+		"[[@master]] and [[multi word page]]": {
+			{Kind: RefPage, Target: "@master"},
+			{Kind: RefPage, Target: "multi word page"},
+		},
+		"[[Дамиана]], [[v1.2]] and [[project/rockets]]": {
+			{Kind: RefPage, Target: "Дамиана"},
+			{Kind: RefPage, Target: "v1.2"},
+			{Kind: RefPage, Target: "project/rockets"},
+		},
+		"#species #[[multi word tag]] (#psycho), #урожай.": {
+			{Kind: RefTag, Target: "species"},
+			{Kind: RefTag, Target: "multi word tag"},
+			{Kind: RefTag, Target: "psycho"},
+			{Kind: RefTag, Target: "урожай"},
+		},
+		"[[foo [[bar]]]] #[[baz [[qux]]]]": {
+			{Kind: RefPage, Target: "foo [[bar]]"},
+			{Kind: RefPage, Target: "bar"},
+			{Kind: RefTag, Target: "baz [[qux]]"},
+			{Kind: RefPage, Target: "qux"},
+		},
+		"see ((6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b)) not ((x + y))": {
+			{Kind: RefBlock, Target: "6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b"},
+		},
+		"`[[code]]` and ``#not `[[tag]]` `` but [[real]]": {
+			{Kind: RefPage, Target: "real"},
+		},
+		"```\n[[fenced]]\n```\n#after": {
+			{Kind: RefTag, Target: "after"},
+		},
+		"# Heading, https://site.com/page#anchor, &#39; and [[unclosed": nil,
+	}
+
+	for text, expected := range text2expected {
+		if got := ParseRefs(text); !slices.Equal(got, expected) {
+			t.Errorf("expected %v, got %v for %q", expected, got, text)
+		}
+	}
+}
+
+func TestParsePropertyValues(t *testing.T) {
+	value2expected := map[string][]Ref{
+		"species, research": {
+			{Kind: RefPage, Target: "species"},
+			{Kind: RefPage, Target: "research"},
+		},
+		`[[Rockets, Inc]], "quoted, text", 42`: {
+			{Kind: RefPage, Target: "Rockets, Inc"},
+			{Kind: RefText, Target: "quoted, text"},
+			{Kind: RefText, Target: "42"},
+		},
+		"[[a]] #b, [[c [[d]]]]": {
+			{Kind: RefPage, Target: "a"},
+			{Kind: RefTag, Target: "b"},
+			{Kind: RefPage, Target: "c [[d]]"},
+		},
+		"see [[Wiki]] page, https://example.com/a,b": {
+			{Kind: RefText, Target: "see [[Wiki]] page"},
+			{Kind: RefText, Target: "https://example.com/a"},
+			{Kind: RefPage, Target: "b"},
+		},
+		"`a, b`": {
+			{Kind: RefText, Target: "`a, b`"},
+		},
+	}
+
+	for value, expected := range value2expected {
+		if got := ParsePropertyValues(value); !slices.Equal(got, expected) {
+			t.Errorf("expected %v, got %v for %q", expected, got, value)
+		}
+	}
+}
+
+func TestRef_String(t *testing.T) {
+	for _, text := range []string{"[[multi word]]", "#tag", "#[[multi word]]", "((6650a1b2-0c3d-4e5f-8a9b-0c1d2e3f4a5b))"} {
+		refs := ParseRefs(text)
+		if len(refs) != 1 || refs[0].String() != text {
+			t.Errorf("expected %q to round trip, got %v", text, refs)
+		}
+	}
+}
+
+func TestFindPageInfo_Refs(t *testing.T) {
+	page := "tags:: #[[multi word]], [[Дамиана]]\n\n- Uses `[[not a ref]]`\n- ```\n  [[fenced]]\n  prop:: fenced\n  ```\n- #done [[Дамиана]]"
+	info, err := FindPageInfo(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Ref{
+		{Kind: RefTag, Target: "multi word"},
+		{Kind: RefPage, Target: "Дамиана"},
+		{Kind: RefTag, Target: "done"},
+	}
+	if !slices.Equal(info.Refs, expected) {
+		t.Errorf("expected %v, got %v", expected, info.Refs)
+	}
+	if len(info.Props) != 1 || !slices.Equal(Targets(info.Props[0].Values), []string{"multi word", "Дамиана"}) {
+		t.Errorf("unexpected properties %#v", info.Props)
+	}
+	if !slices.Equal(info.PageRefs(), []string{"multi word", "Дамиана", "done"}) {
+		t.Errorf("unexpected page refs %v", info.PageRefs())
+	}
+}
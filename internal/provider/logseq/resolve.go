@@ -0,0 +1,105 @@
+package logseq
+
+import (
+	"fmt"
+	"iter"
+	"net/url"
+	"strings"
+)
+
+// Characters which aren't allowed in file names on some platforms or break URL unescaping
+const reservedFileNameChars = `<>:"\|?*#%`
+
+// PageName returns LogSeq's canonical page name used to match titles case-insensitively
+func PageName(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
+
+// TitleFromFileName decodes page title from the file name without extension,
+// namespaces are encoded with triple lowbar and special characters are URL escaped
+func TitleFromFileName(name string) string {
+	title := strings.ReplaceAll(name, "___", "/")
+	if unescaped, err := url.PathUnescape(title); err == nil {
+		title = unescaped
+	}
+	return title
+}
+
+// PageFileName encodes page title into the file name without extension the way
+// LogSeq does for the configured format, TitleFromFileName decodes it back
+func (c Config) PageFileName(title string) string {
+	namespace := "___"
+	if c.FileNameFormat == LegacyFileNameFormat {
+		namespace = "%2F"
+	}
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(title) {
+		switch {
+		case r == '/':
+			b.WriteString(namespace)
+		case strings.ContainsRune(reservedFileNameChars, r):
+			fmt.Fprintf(&b, "%%%02X", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Resolver finds pages by their titles and aliases the same way LogSeq does
+type Resolver struct {
+	// Canonical names of the titles and aliases to the page titles
+	titles map[string]string
+	// Canonical page names to their aliases
+	aliases map[string][]string
+}
+
+func NewResolver(pages iter.Seq[Page]) Resolver {
+	r := Resolver{
+		titles:  make(map[string]string),
+		aliases: make(map[string][]string),
+	}
+	var aliased []Page
+	for page := range pages {
+		r.titles[PageName(page.Title())] = page.Title()
+		if _, ok := page.Info.PageLevelGet("alias"); ok {
+			aliased = append(aliased, page)
+		}
+	}
+	// Page titles take precedence over aliases
+	for _, page := range aliased {
+		values, _ := page.Info.PageLevelGet("alias")
+		for _, alias := range values {
+			name := PageName(alias.Target)
+			if _, ok := r.titles[name]; ok {
+				continue
+			}
+			r.titles[name] = page.Title()
+			r.aliases[PageName(page.Title())] = append(r.aliases[PageName(page.Title())], alias.Target)
+		}
+	}
+	return r
+}
+
+// Resolve returns the title of the page named or aliased by `title`,
+// the title is returned as is for unknown pages
+func (r Resolver) Resolve(title string) (string, bool) {
+	resolved, ok := r.titles[PageName(title)]
+	if !ok {
+		return title, false
+	}
+	return resolved, true
+}
+
+// Same checks that both titles point to the same page
+func (r Resolver) Same(lhs, rhs string) bool {
+	lhs, _ = r.Resolve(lhs)
+	rhs, _ = r.Resolve(rhs)
+	return PageName(lhs) == PageName(rhs)
+}
+
+// Aliases returns aliases of the page
+func (r Resolver) Aliases(title string) []string {
+	resolved, _ := r.Resolve(title)
+	return r.aliases[PageName(resolved)]
+}
@@ -0,0 +1,96 @@
+package logseq
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestTitleFromFileName(t *testing.T) {
+	name2expected := map[string]string{
+		"damiana":                "damiana",
+		"project___rockets":      "project/rockets",
+		"project%2Frockets":      "project/rockets",
+		"what%3F":                "what?",
+		"a___b___c":              "a/b/c",
+		"100% broken %zz escape": "100% broken %zz escape",
+	}
+	for name, expected := range name2expected {
+		if got := TitleFromFileName(name); got != expected {
+			t.Errorf("expected %q, got %q for %q", expected, got, name)
+		}
+	}
+}
+
+func TestConfig_PageFileName(t *testing.T) {
+	legacy := DefaultConfig()
+	legacy.FileNameFormat = LegacyFileNameFormat
+	cases := []struct {
+		cfg      Config
+		title    string
+		expected string
+	}{
+		{DefaultConfig(), "damiana", "damiana"},
+		{DefaultConfig(), "project/rockets", "project___rockets"},
+		{DefaultConfig(), "what?", "what%3F"},
+		{DefaultConfig(), "100% done", "100%25 done"},
+		{legacy, "project/rockets", "project%2Frockets"},
+	}
+	for _, c := range cases {
+		got := c.cfg.PageFileName(c.title)
+		if got != c.expected {
+			t.Errorf("expected %q, got %q for %q", c.expected, got, c.title)
+		}
+		if title := TitleFromFileName(got); title != c.title {
+			t.Errorf("expected %q to be decoded back, got %q", c.title, title)
+		}
+	}
+}
+
+func TestConfig_FormatJournalTitle(t *testing.T) {
+	cfg := DefaultConfig()
+	for day, expected := range map[int]string{1: "Jan 1st, 2024", 2: "Jan 2nd, 2024", 3: "Jan 3rd, 2024", 11: "Jan 11th, 2024", 22: "Jan 22nd, 2024"} {
+		if got := cfg.FormatJournalTitle(time.Date(2024, time.January, day, 0, 0, 0, 0, time.UTC)); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+	if got := cfg.FormatJournalFileName(time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)); got != "2024_01_02" {
+		t.Errorf("unexpected journal file name %q", got)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	g := NewRegexGraph(fixtureGraph)
+	var titles []string
+	for page := range g.WalkPages() {
+		titles = append(titles, page.Title())
+	}
+	for _, title := range []string{"Custom Title", "project/rockets", "what?", "Mar 10th, 2024"} {
+		if !slices.Contains(titles, title) {
+			t.Errorf("expected title %q among %v", title, titles)
+		}
+	}
+
+	r := NewResolver(g.WalkPages())
+	title2expected := map[string]string{
+		"Turnera":         "damiana",
+		"DAMIANA":         "damiana",
+		"PROJECT/Rockets": "project/rockets",
+		"custom title":    "Custom Title",
+		"mar 10TH, 2024":  "Mar 10th, 2024",
+	}
+	for title, expected := range title2expected {
+		if got, ok := r.Resolve(title); !ok || got != expected {
+			t.Errorf("expected %q, got %q for %q", expected, got, title)
+		}
+	}
+	if got, ok := r.Resolve("Missing Page"); ok || got != "Missing Page" {
+		t.Errorf("expected missing page to be kept as is, got %q", got)
+	}
+	if !r.Same("turnera", "Damiana") || r.Same("fern", "damiana") {
+		t.Errorf("unexpected page identity")
+	}
+	if !slices.Equal(r.Aliases("Turnera"), []string{"turnera"}) {
+		t.Errorf("unexpected aliases %v", r.Aliases("Turnera"))
+	}
+}
@@ -0,0 +1,104 @@
+package retriever
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Okapi BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+type bm25Doc struct {
+	length int
+	tf     map[string]int
+}
+
+// bm25Index is an in-memory full-text index over the fixed set of texts
+type bm25Index struct {
+	docs   []bm25Doc
+	df     map[string]int
+	avgLen float64
+}
+
+type hit struct {
+	doc   int
+	score float64
+}
+
+func newBM25Index(texts []string) *bm25Index {
+	idx := &bm25Index{
+		docs: make([]bm25Doc, len(texts)),
+		df:   make(map[string]int),
+	}
+	var total int
+	for i, text := range texts {
+		tokens := tokenize(text)
+		tf := make(map[string]int)
+		for _, t := range tokens {
+			tf[t]++
+		}
+		for t := range tf {
+			idx.df[t]++
+		}
+		idx.docs[i] = bm25Doc{length: len(tokens), tf: tf}
+		total += len(tokens)
+	}
+	if len(texts) > 0 {
+		idx.avgLen = float64(total) / float64(len(texts))
+	}
+	return idx
+}
+
+// search returns up to `limit` documents matching any query term ordered by score
+func (idx *bm25Index) search(query string, limit int) []hit {
+	terms := slices.Compact(slices.Sorted(slices.Values(tokenize(query))))
+	n := float64(len(idx.docs))
+	scores := make(map[int]float64)
+	for _, term := range terms {
+		df := idx.df[term]
+		if df == 0 {
+			continue
+		}
+		idf := math.Log(1 + (n-float64(df)+0.5)/(float64(df)+0.5))
+		for i, doc := range idx.docs {
+			tf := float64(doc.tf[term])
+			if tf == 0 {
+				continue
+			}
+			norm := 1 - bm25B + bm25B*float64(doc.length)/idx.avgLen
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+
+	hits := make([]hit, 0, len(scores))
+	for doc, score := range scores {
+		hits = append(hits, hit{doc: doc, score: score})
+	}
+	slices.SortFunc(hits, func(lhs, rhs hit) int {
+		return cmp.Or(cmp.Compare(rhs.score, lhs.score), cmp.Compare(lhs.doc, rhs.doc))
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// tokenize splits text into lower cased words skipping single characters
+func tokenize(text string) (tokens []string) {
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if utf8.RuneCountInString(word) < 2 {
+			continue
+		}
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
@@ -0,0 +1,311 @@
+package retriever
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"mimi/internal/provider/logseq/db"
+	"mimi/internal/provider/logseq/rag"
+)
+
+const (
+	// Reciprocal rank fusion constant, bigger values flatten the rank difference
+	rrfK = 60
+	// Length of each ranked list before fusion
+	searchLimit = 20
+	// Top fused pages which references are added to the candidates
+	seedCount = 5
+	// Upper bound of the pages in the final context
+	maxResults = 15
+	// Rough estimation, Cyrillic texts are tokenized denser than English ones
+	charsPerToken = 3
+	// How long the full-text index is reused before checking pages for changes
+	corpusTTL = 5 * time.Minute
+)
+
+// Retriever finds pages relevant to the query combining full-text search,
+// vector similarity, references between pages in both directions and paths between them
+type Retriever struct {
+	q *db.Queries
+	// Optional, vector search is skipped when nil
+	rag *rag.RAG
+
+	mu sync.Mutex
+	// Loaded pages by their titles, only changed ones are reloaded
+	pages     map[string]page
+	corpus    *corpus
+	checkedAt time.Time
+}
+
+func New(q *db.Queries, r *rag.RAG) *Retriever {
+	return &Retriever{q: q, rag: r, pages: make(map[string]page)}
+}
+
+type Result struct {
+	Title   string
+	Content string
+	// Reciprocal rank fusion score
+	Score float64
+}
+
+// Retrieve returns relevant pages which content fits into the token budget.
+// Pages exceeding the budget are represented by their matched blocks only
+func (r *Retriever) Retrieve(ctx context.Context, query string, budget int) ([]Result, error) {
+	c, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// Full-text search over whole pages and separate blocks
+	var lists [][]string
+	var pageHits []string
+	for _, h := range c.pageIndex.search(query, searchLimit) {
+		pageHits = append(pageHits, c.titles[h.doc])
+	}
+	blockHits := c.blockIndex.search(query, searchLimit*2)
+	var blockPages []string
+	for _, h := range blockHits {
+		if title := c.blocks[h.doc].page; !slices.Contains(blockPages, title) {
+			blockPages = append(blockPages, title)
+		}
+	}
+	lists = append(lists, pageHits, blockPages)
+
+	// Vector similarity
+	if r.rag != nil {
+		similar, err := r.rag.Search(ctx, query, searchLimit)
+		if err != nil {
+			// Full-text results are still useful
+			slog.Warn("vector search failed", "with", err)
+		}
+		var titles []string
+		for _, page := range similar {
+			titles = append(titles, page.Title)
+		}
+		lists = append(lists, titles)
+	}
+
+	// Expand the best candidates with the pages they reference and pages referencing them
+	seeds, _ := fuse(lists...)
+	var related, backlinks []string
+	for _, seed := range seeds[:min(len(seeds), seedCount)] {
+		rels, err := r.q.FindRelatives(seed, 1)
+		if err != nil {
+			return nil, err
+		}
+		for _, rel := range rels {
+			if !slices.Contains(related, rel.Title) {
+				related = append(related, rel.Title)
+			}
+		}
+		links, err := r.q.FindBacklinks(seed)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			if !slices.Contains(backlinks, link.Title) {
+				backlinks = append(backlinks, link.Title)
+			}
+		}
+	}
+	// Pages connecting the two best candidates explain how they are related
+	var bridges []string
+	if len(seeds) > 1 {
+		path, err := r.q.FindShortestPath(seeds[0], seeds[1])
+		if err != nil {
+			return nil, err
+		}
+		if len(path) > 2 {
+			bridges = path[1 : len(path)-1]
+		}
+	}
+	lists = append(lists, related, backlinks, bridges)
+
+	ranked, scores := fuse(lists...)
+	results := c.assemble(ranked, blockHits, budget)
+	for i := range results {
+		results[i].Score = scores[results[i].Title]
+	}
+	slog.Info(
+		"retrieved LogSeq pages",
+		"fullText", len(pageHits),
+		"blocks", len(blockPages),
+		"related", len(related),
+		"backlinks", len(backlinks),
+		"bridges", len(bridges),
+		"results", len(results),
+	)
+	return results, nil
+}
+
+// load returns cached corpus or rebuilds it reloading the changed pages from DB
+func (r *Retriever) load() (*corpus, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.corpus != nil && time.Since(r.checkedAt) < corpusTTL {
+		return r.corpus, nil
+	}
+	hashes, err := r.q.FindPageHashes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pages for retrieval with %w", err)
+	}
+	changed, removed := diffPages(r.pages, hashes)
+	if err := r.reload(changed); err != nil {
+		return nil, err
+	}
+	for _, title := range removed {
+		delete(r.pages, title)
+	}
+	if r.corpus == nil || len(changed) > 0 || len(removed) > 0 {
+		slog.Info("reloaded retrieval corpus", "changed", len(changed), "removed", len(removed))
+		r.corpus = newCorpus(r.pages)
+	}
+	r.checkedAt = time.Now()
+	return r.corpus, nil
+}
+
+// reload reads pages with their blocks from DB
+func (r *Retriever) reload(titles []string) error {
+	if len(titles) == 0 {
+		return nil
+	}
+	pages, err := r.q.FindPages(titles)
+	if err != nil {
+		return fmt.Errorf("failed to load pages for retrieval with %w", err)
+	}
+	blocks, err := r.q.FindBlocks(titles)
+	if err != nil {
+		return fmt.Errorf("failed to load blocks for retrieval with %w", err)
+	}
+	byPage := make(map[string][]string)
+	for _, b := range blocks {
+		if text := strings.TrimSpace(b.Content); text != "" {
+			byPage[b.PageTitle] = append(byPage[b.PageTitle], text)
+		}
+	}
+	for _, p := range pages {
+		r.pages[p.Title] = page{hash: p.Hash, content: p.Content, blocks: byPage[p.Title]}
+	}
+	return nil
+}
+
+// diffPages returns titles of the new or changed pages and the missing ones
+func diffPages(pages map[string]page, hashes map[string]string) (changed, removed []string) {
+	for title, hash := range hashes {
+		if p, ok := pages[title]; !ok || p.hash != hash {
+			changed = append(changed, title)
+		}
+	}
+	for title := range pages {
+		if _, ok := hashes[title]; !ok {
+			removed = append(removed, title)
+		}
+	}
+	slices.Sort(changed)
+	slices.Sort(removed)
+	return changed, removed
+}
+
+type page struct {
+	hash    string
+	content string
+	blocks  []string
+}
+
+type block struct {
+	page string
+	text string
+}
+
+type corpus struct {
+	titles     []string
+	pages      map[string]string
+	blocks     []block
+	pageIndex  *bm25Index
+	blockIndex *bm25Index
+}
+
+func newCorpus(pages map[string]page) *corpus {
+	c := &corpus{pages: make(map[string]string, len(pages))}
+	var pageTexts, blockTexts []string
+	for _, title := range slices.Sorted(maps.Keys(pages)) {
+		p := pages[title]
+		c.titles = append(c.titles, title)
+		c.pages[title] = p.content
+		// Title is a part of the searchable text
+		pageTexts = append(pageTexts, title+"\n"+p.content)
+		for _, text := range p.blocks {
+			c.blocks = append(c.blocks, block{page: title, text: text})
+			blockTexts = append(blockTexts, title+"\n"+text)
+		}
+	}
+	c.pageIndex = newBM25Index(pageTexts)
+	c.blockIndex = newBM25Index(blockTexts)
+	return c
+}
+
+// assemble fills the budget with ranked pages replacing pages
+// which don't fit with their matched blocks
+func (c *corpus) assemble(ranked []string, blockHits []hit, budget int) (results []Result) {
+	for _, title := range ranked {
+		if len(results) == maxResults {
+			break
+		}
+		content, ok := c.pages[title]
+		if !ok {
+			continue
+		}
+		if estimateTokens(content) > budget {
+			var matched []string
+			tokens := 0
+			for _, h := range blockHits {
+				b := c.blocks[h.doc]
+				if b.page != title || tokens+estimateTokens(b.text) > budget {
+					continue
+				}
+				matched = append(matched, b.text)
+				tokens += estimateTokens(b.text)
+			}
+			content = strings.Join(matched, "\n")
+		}
+		if content == "" {
+			continue
+		}
+		results = append(results, Result{Title: title, Content: content})
+		budget -= estimateTokens(content)
+		if budget <= 0 {
+			break
+		}
+	}
+	return results
+}
+
+// fuse merges ranked lists with reciprocal rank fusion
+func fuse(lists ...[]string) ([]string, map[string]float64) {
+	scores := make(map[string]float64)
+	for _, list := range lists {
+		for rank, title := range list {
+			scores[title] += 1 / float64(rrfK+rank+1)
+		}
+	}
+	ranked := make([]string, 0, len(scores))
+	for title := range scores {
+		ranked = append(ranked, title)
+	}
+	slices.SortFunc(ranked, func(lhs, rhs string) int {
+		return cmp.Or(cmp.Compare(scores[rhs], scores[lhs]), cmp.Compare(lhs, rhs))
+	})
+	return ranked, scores
+}
+
+func estimateTokens(s string) int {
+	return utf8.RuneCountInString(s)/charsPerToken + 1
+}
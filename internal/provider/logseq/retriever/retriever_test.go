@@ -0,0 +1,104 @@
+package retriever
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var pages = map[string]page{
+	"banana": {
+		content: "type:: plant\n\n- Banana grows in the valley\n- Needs a lot of water\n\t- Water twice a week",
+		blocks:  []string{"Banana grows in the valley", "Needs a lot of water", "Water twice a week"},
+	},
+	"water": {
+		content: "- Water supply of the valley comes from the river",
+		blocks:  []string{"Water supply of the valley comes from the river"},
+	},
+	"rockets": {
+		content: "- Rockets team meets on Monday",
+		blocks:  []string{"Rockets team meets on Monday"},
+	},
+	"journal": {
+		content: "- " + strings.Repeat("long text ", 300) + "\n- Banana harvest was collected",
+		blocks:  []string{strings.Repeat("long text ", 300), "Banana harvest was collected"},
+	},
+}
+
+func TestBM25(t *testing.T) {
+	idx := newBM25Index([]string{
+		"banana banana water",
+		"water supply",
+		"rockets",
+	})
+	hits := idx.search("Banana water", 10)
+	if len(hits) != 2 || hits[0].doc != 0 || hits[1].doc != 1 {
+		t.Fatalf("unexpected hits %#v", hits)
+	}
+	if hits := idx.search("unknown", 10); len(hits) != 0 {
+		t.Fatalf("expected no hits, got %#v", hits)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("Банан:: растёт, в [[valley]] a-b 2025")
+	expected := []string{"банан", "растёт", "valley", "2025"}
+	if !slices.Equal(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestDiffPages(t *testing.T) {
+	cached := map[string]page{
+		"banana":  {hash: "a"},
+		"water":   {hash: "b"},
+		"rockets": {hash: "c"},
+	}
+	changed, removed := diffPages(cached, map[string]string{"banana": "a", "water": "d", "journal": "e"})
+	if !slices.Equal(changed, []string{"journal", "water"}) {
+		t.Errorf("unexpected changed pages %v", changed)
+	}
+	if !slices.Equal(removed, []string{"rockets"}) {
+		t.Errorf("unexpected removed pages %v", removed)
+	}
+}
+
+func TestFuse(t *testing.T) {
+	ranked, scores := fuse(
+		[]string{"a", "b", "c"},
+		[]string{"b", "c"},
+		[]string{"d"},
+	)
+	expected := []string{"b", "c", "a", "d"}
+	if !slices.Equal(ranked, expected) {
+		t.Fatalf("expected %v, got %v", expected, ranked)
+	}
+	if math.Abs(scores["b"]-(1.0/62+1.0/61)) > 1e-9 {
+		t.Fatalf("unexpected score %f", scores["b"])
+	}
+}
+
+func TestAssemble(t *testing.T) {
+	c := newCorpus(pages)
+	blockHits := c.blockIndex.search("banana harvest", 10)
+	results := c.assemble([]string{"journal", "missing", "banana", "water"}, blockHits, 100)
+
+	titles := make([]string, len(results))
+	for i, r := range results {
+		titles[i] = r.Title
+	}
+	if !slices.Equal(titles, []string{"journal", "banana", "water"}) {
+		t.Fatalf("unexpected results %v", titles)
+	}
+	// Journal doesn't fit, only matched block is kept
+	if results[0].Content != "Banana harvest was collected" {
+		t.Fatalf("unexpected journal content %q", results[0].Content)
+	}
+
+	// Budget is exhausted by the first page
+	results = c.assemble([]string{"banana", "water"}, blockHits, 10)
+	if len(results) != 1 {
+		t.Fatalf("expected single result, got %#v", results)
+	}
+}
@@ -3,62 +3,314 @@ package logseq
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
-	"strings"
+	"os"
+	"path/filepath"
+	"slices"
 
+	"mimi/internal/provider/git"
 	"mimi/internal/provider/logseq/db"
+	"mimi/internal/provider/logseq/rag"
 )
 
 type Syncer = func(ctx context.Context, path string) error
 
-func NewSyncer(q *db.Queries) Syncer {
+// NewSyncer creates syncer of the pages changed since the last synced commit,
+// nil `r` disables embeddings calculation
+func NewSyncer(q *db.Queries, r *rag.RAG) Syncer {
 	return func(ctx context.Context, path string) error {
-		return Sync(ctx, NewRegexGraph(path), q)
+		return SyncCommits(ctx, NewRegexGraph(path), q, r)
 	}
 }
 
-// Sync persist provided logseq graph `g` in CozoDB
-// Brew some coffee, it's really slow process which takes around 30 minutes
-// Vs lbh fcraq fbzr gvzr ba qrpbqvat guvf pbzzrag, gura lbh'q srry rknpgyl nf
-// V qhevat vagrtengvat Pbmb QO
-func Sync(ctx context.Context, g RegexGraph, q *db.Queries) error {
-	slog.Info("Starting syncing LogSeq graph")
-	props := make(map[string]string)
+// NewWatchSyncer creates hook persisting pages changed in the watched graph,
+// nil `r` disables embeddings calculation
+func NewWatchSyncer(q *db.Queries, r *rag.RAG) WatchHook {
+	return func(ctx context.Context, g *IndexedGraph, changes PageChanges) error {
+		return SyncChanges(ctx, g.Resolver(), changes, q, r)
+	}
+}
 
+// SyncChanges persists only changed pages and deletes removed ones,
+// renamed pages keeping their title are updated in place
+func SyncChanges(ctx context.Context, resolver Resolver, changes PageChanges, q *db.Queries, r *rag.RAG) error {
 	var errs []error
-	for p := range g.WalkPages() {
-		// Get page data
-		content, err := p.Read()
+	changed := make(map[string]bool, len(changes.Changed))
+	for _, p := range changes.Changed {
+		changed[p.Title()] = true
+	}
+	for _, p := range changes.Removed {
+		if changed[p.Title()] {
+			continue
+		}
+		slog.Debug("deleting page", "title", p.Title())
+		if err := q.DeletePage(p.Title()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, p := range changes.Changed {
+		// Stale properties and references are replaced on save
+		if err := savePage(ctx, resolver, p, q, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	slog.Info("synced LogSeq graph changes", "changed", len(changes.Changed), "removed", len(changes.Removed))
+	return errors.Join(errs...)
+}
+
+// SyncCommits persists pages changed since the last synced commit of the graph's
+// repository, removed and renamed pages are deleted with all their relations.
+// Graph without the synced commit or outside of a git repository is rebuilt
+func SyncCommits(ctx context.Context, g RegexGraph, q *db.Queries, r *rag.RAG) error {
+	head, err := git.HeadCommit(g.Path)
+	if err != nil {
+		slog.Warn("failed to find LogSeq graph's commit, rebuilding", "with", err)
+		return Sync(ctx, g, q, r)
+	}
+	last, found, err := q.FindSyncCommit(g.Path)
+	if err != nil {
+		return err
+	}
+	if !found || last == "" {
+		slog.Info("LogSeq graph wasn't synced from a commit yet, rebuilding", "commit", head)
+		return Sync(ctx, g, q, r)
+	}
+	if last == head {
+		slog.Info("LogSeq graph is up to date", "commit", head)
+		return nil
+	}
+
+	files, err := git.ChangedFiles(g.Path, last, head)
+	if err != nil {
+		// Synced commit may be lost after the history rewrite
+		slog.Warn("failed to diff synced commit, rebuilding", "commit", last, "with", err)
+		return Sync(ctx, g, q, r)
+	}
+	changes, err := g.changesSince(last, files)
+	if err != nil {
+		return err
+	}
+
+	// Aliases of the unchanged pages are required to resolve references
+	resolver := NewResolver(g.WalkPages())
+	if err := SyncChanges(ctx, resolver, changes, q, r); err != nil {
+		// Commit isn't saved to retry the same changes
+		return err
+	}
+	return q.SaveSyncCommit(g.Path, head)
+}
+
+// changesSince parses pages changed after the `commit`, removed and modified pages are read
+// from the commit to know their old titles
+func (g RegexGraph) changesSince(commit string, files []git.FileChange) (PageChanges, error) {
+	var changes PageChanges
+	var errs []error
+	old := func(path string) (Page, bool) {
+		content, err := git.Show(g.Path, commit, path)
 		if err != nil {
 			errs = append(errs, err)
-			continue
+			return Page{}, false
+		}
+		page, err := g.parsePage(filepath.Join(g.Path, path), content)
+		if err != nil {
+			errs = append(errs, err)
+			return Page{}, false
+		}
+		return page, true
+	}
+	removed := func(path string) {
+		if page, ok := old(path); ok {
+			changes.Removed = append(changes.Removed, page)
 		}
+	}
 
-		// Collect properties
-		for _, prop := range p.Info.Props {
-			value := strings.Join(prop.Values, ", ")
-			if old, ok := props[prop.Name]; ok && old != value {
-				value += old
+	for _, file := range files {
+		if file.Status == git.FileRenamed && isPageFile(file.OldPath) {
+			removed(file.OldPath)
+		}
+		if !isPageFile(file.Path) {
+			continue
+		}
+		if file.Status == git.FileDeleted {
+			removed(file.Path)
+			continue
+		}
+		path := filepath.Join(g.Path, file.Path)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read page with %w", err))
+			continue
+		}
+		page, err := g.parsePage(path, content)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		changes.Changed = append(changes.Changed, page)
+		if file.Status == git.FileModified || file.Status == git.FileTyped {
+			// Page is retitled by the `title::` property
+			if prev, ok := old(file.Path); ok && prev.Title() != page.Title() {
+				changes.Removed = append(changes.Removed, prev)
 			}
-			props[prop.Name] = value
 		}
+	}
 
-		// Persist page
-		slog.Debug("saving page", "title", p.Title())
-		err = q.SavePage(db.SavePageParams{
-			Title:   p.Title(),
-			Content: content,
-			Props:   props,
-			Refs:    p.Info.Refs,
-		})
-		if err != nil {
+	return changes, errors.Join(errs...)
+}
+
+// Sync rebuilds provided logseq graph `g` in CozoDB and records its commit,
+// pages are updated in place and the missing ones are deleted afterwards
+// to keep them queryable during the rebuild. Unchanged pages aren't embedded again.
+// It's a fallback of SyncCommits which persists only pages changed since the recorded commit
+func Sync(ctx context.Context, g RegexGraph, q *db.Queries, r *rag.RAG) error {
+	slog.Info("Starting syncing LogSeq graph")
+	// Taken before reading pages to sync changes made meanwhile next time
+	head, headErr := git.HeadCommit(g.Path)
+
+	// References are saved with the resolved titles to be joined with pages
+	pages := slices.Collect(g.WalkPages())
+	resolver := NewResolver(slices.Values(pages))
+
+	var errs []error
+	titles := make(map[string]bool, len(pages))
+	for _, p := range pages {
+		titles[p.Title()] = true
+		if err := savePage(ctx, resolver, p, q, r); err != nil {
 			slog.Error("failed to save page", "with", err)
 			errs = append(errs, err)
+		}
+	}
+	synced, err := q.FindTitles()
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	for _, title := range synced {
+		if titles[title] {
 			continue
 		}
+		slog.Debug("deleting page", "title", title)
+		if err := q.DeletePage(title); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := q.DeleteOrphanEmbeddings(); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
 	}
 
-	slog.Info("LogSeq graph's sync succeeded")
+	// Completed rebuild is recorded even without the commit to enable Datalog queries
+	if headErr != nil {
+		slog.Warn("LogSeq graph commit isn't recorded", "with", headErr)
+	}
+	if err := q.SaveSyncCommit(g.Path, head); err != nil {
+		return err
+	}
 
-	return errors.Join(errs...)
+	slog.Info("LogSeq graph's sync succeeded", "commit", head)
+	return nil
+}
+
+// savePage persists page with its properties and resolved references
+// and embeds it for the vector search
+func savePage(ctx context.Context, resolver Resolver, p Page, q *db.Queries, r *rag.RAG) error {
+	content, err := p.Read()
+	if err != nil {
+		return err
+	}
+
+	params := db.SavePageParams{
+		Title:   p.Title(),
+		Content: content,
+		Props:   propParams(p.Info.Props),
+		Refs:    resolveRefs(resolver, p.Info.PageRefs()),
+	}
+	if tags, ok := p.Info.PageLevelTags(); ok {
+		params.Tags = resolveRefs(resolver, Targets(tags))
+	}
+	if aliases, ok := p.Info.PageLevelGet("alias"); ok {
+		params.Aliases = Targets(aliases)
+	}
+	if date, ok := p.JournalDate(); ok {
+		params.JournalDay = date.Year()*10000 + int(date.Month())*100 + date.Day()
+	}
+	for _, b := range ParseBlocks(p.Path, content) {
+		block := db.SaveBlockParams{
+			Position: b.Position,
+			Parent:   b.Parent,
+			UUID:     b.UUID,
+			Content:  b.Content,
+			Props:    propValues(b.Props),
+		}
+		for _, ref := range b.Refs {
+			switch {
+			case ref.IsPage():
+				block.PageRefs = append(block.PageRefs, ref.Target)
+			case ref.Kind == RefBlock:
+				block.BlockRefs = append(block.BlockRefs, ref.Target)
+			}
+		}
+		block.PageRefs = resolveRefs(resolver, block.PageRefs)
+		params.Blocks = append(params.Blocks, block)
+	}
+
+	slog.Debug("saving page", "title", p.Title(), "blocks", len(params.Blocks))
+	if err := q.SavePage(params); err != nil {
+		return err
+	}
+
+	if r != nil {
+		if err := r.IndexPage(ctx, p.Title(), content); err != nil {
+			slog.Error("failed to index page", "with", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// propValues collects unique values of the properties by name
+func propValues(props []Property) map[string][]string {
+	values := make(map[string][]string)
+	for _, prop := range props {
+		for _, value := range Targets(prop.Values) {
+			if !slices.Contains(values[prop.Name], value) {
+				values[prop.Name] = append(values[prop.Name], value)
+			}
+		}
+	}
+	return values
+}
+
+// propParams collects unique values of the properties, a value is text
+// only when it never occurs as a page reference
+func propParams(props []Property) []db.SavePropParams {
+	var params []db.SavePropParams
+	for _, prop := range props {
+		for _, ref := range prop.Values {
+			i := slices.IndexFunc(params, func(p db.SavePropParams) bool {
+				return p.Name == prop.Name && p.Value == ref.Target
+			})
+			if i == -1 {
+				params = append(params, db.SavePropParams{Name: prop.Name, Value: ref.Target, Text: true})
+				i = len(params) - 1
+			}
+			params[i].Text = params[i].Text && !ref.IsPage()
+		}
+	}
+	return params
+}
+
+func resolveRefs(r Resolver, refs []string) []string {
+	resolved := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		title, _ := r.Resolve(ref)
+		if !slices.Contains(resolved, title) {
+			resolved = append(resolved, title)
+		}
+	}
+	return resolved
 }
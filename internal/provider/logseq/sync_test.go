@@ -0,0 +1,145 @@
+package logseq
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"mimi/internal/provider/git"
+	"mimi/internal/provider/logseq/db"
+)
+
+// commitAll commits the whole working tree returning the commit's hash
+func commitAll(t *testing.T, dir, message string) string {
+	t.Helper()
+	if _, err := git.Git(dir, "add", "-A"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := git.Git(dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-qm", message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := git.HeadCommit(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
+
+func TestRegexGraph_ChangesSince(t *testing.T) {
+	dir := copyGraph(t)
+	if _, err := git.Git(dir, "init", "-q"); err != nil {
+		t.Fatal(err)
+	}
+	first := commitAll(t, dir, "init")
+
+	// Modify, delete, rename and add pages along with a non page file
+	if err := os.WriteFile(filepath.Join(dir, "pages", "fern.md"), []byte("tags:: species\n\n- Needs water\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "pages", "custom.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(filepath.Join(dir, "pages", "damiana.md"), filepath.Join(dir, "pages", "turnera diffusa.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pages", "moss.org"), []byte("#+title: Moss\n* Grows on [[fern]]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "logseq", "custom.css"), []byte("body {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Retitled in place
+	if err := os.WriteFile(filepath.Join(dir, "pages", "project___rockets.md"), []byte("title:: Rockets\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	second := commitAll(t, dir, "edit")
+
+	files, err := git.ChangedFiles(dir, first, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewRegexGraph(dir)
+	changes, err := g.changesSince(first, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := titles(changes.Changed); !slices.Equal(got, []string{"Moss", "Rockets", "fern", "turnera diffusa"}) {
+		t.Errorf("unexpected changed pages %v", got)
+	}
+	// Removed titles come from the old content
+	if got := titles(changes.Removed); !slices.Equal(got, []string{"Custom Title", "damiana", "project/rockets"}) {
+		t.Errorf("unexpected removed pages %v", got)
+	}
+}
+
+func TestPropValues(t *testing.T) {
+	props := []Property{
+		{Name: "tags", Values: []Ref{{Kind: RefPage, Target: "species"}}, Level: PageLevel},
+		{Name: "supply", Values: []Ref{{Kind: RefPage, Target: "next-month"}}, Level: BlockLevel},
+		{Name: "supply", Values: []Ref{{Kind: RefPage, Target: "now"}, {Kind: RefPage, Target: "next-month"}}, Level: BlockLevel},
+	}
+	expected := map[string][]string{
+		"tags":   {"species"},
+		"supply": {"next-month", "now"},
+	}
+	if got := propValues(props); !maps.EqualFunc(got, expected, slices.Equal) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+	// Nothing is left from the previous calls
+	if got := propValues(nil); len(got) != 0 {
+		t.Errorf("expected no properties, got %v", got)
+	}
+}
+
+func TestPropParams(t *testing.T) {
+	props := []Property{
+		{Name: "rating", Values: []Ref{{Kind: RefText, Target: "5"}}, Level: PageLevel},
+		{Name: "source", Values: []Ref{{Kind: RefText, Target: "book"}, {Kind: RefPage, Target: "fern"}}, Level: PageLevel},
+		{Name: "source", Values: []Ref{{Kind: RefPage, Target: "book"}}, Level: BlockLevel},
+	}
+	expected := []db.SavePropParams{
+		{Name: "rating", Value: "5", Text: true},
+		{Name: "source", Value: "book"},
+		{Name: "source", Value: "fern"},
+	}
+	if got := propParams(props); !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestSyncCommits_NotGit(t *testing.T) {
+	conn, err := db.Open("mem", "")
+	if err != nil {
+		t.Skipf("CozoDB engine is unavailable: %s", err)
+	}
+	defer conn.Close()
+	q := db.New(conn)
+	if err := q.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Graph outside of a repository is rebuilt on every sync
+	dir := copyGraph(t)
+	g := NewRegexGraph(dir)
+	if err := SyncCommits(t.Context(), g, q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "pages", "damiana.md")); err != nil {
+		t.Fatal(err)
+	}
+	if err := SyncCommits(t.Context(), g, q, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	synced, err := q.FindTitles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(synced)
+	if expected := titles(slices.Collect(g.WalkPages())); !slices.Equal(synced, expected) {
+		t.Errorf("expected %v, got %v", expected, synced)
+	}
+}
@@ -0,0 +1,153 @@
+package logseq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Continuously written files are refreshed at least once per this many debounce intervals
+const maxWaitDebounces = 10
+
+// WatchHook receives pages changed in the graph after the index is refreshed
+type WatchHook = func(ctx context.Context, g *IndexedGraph, changes PageChanges) error
+
+// Watch refreshes files touched in the graph directory until the context is done,
+// events are debounced to process editor's bursts of writes and renames at once.
+// Hooks receive changes of any refresh while watching, not only ones made by the watcher
+func Watch(ctx context.Context, g *IndexedGraph, debounce time.Duration, hooks ...WatchHook) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher with %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watchDirs(watcher, g.graph.Path); err != nil {
+		return fmt.Errorf("failed to watch graph directories with %w", err)
+	}
+	slog.Info("watching LogSeq graph", "path", g.graph.Path)
+	defer g.subscribe(ctx, hooks)()
+
+	pending := make(map[string]bool)
+	// Time of the first event since the last refresh
+	var first time.Time
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if isHidden(g.graph.Path, event.Name) {
+				continue
+			}
+			if event.Has(fsnotify.Create) {
+				// New directories aren't watched recursively
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watchDirs(watcher, event.Name); err != nil {
+						slog.Error("failed to watch new directory", "path", event.Name, "with", err)
+					}
+					for _, path := range pageFiles(event.Name) {
+						pending[path] = true
+					}
+				}
+			}
+			if isPageFile(event.Name) || event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				pending[event.Name] = true
+			}
+			now := time.Now()
+			if first.IsZero() {
+				first = now
+			}
+			timer.Reset(min(debounce, max(first.Add(maxWaitDebounces*debounce).Sub(now), 0)))
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Error("LogSeq graph watcher failed", "with", err)
+		case <-timer.C:
+			paths := slices.Sorted(maps.Keys(pending))
+			clear(pending)
+			first = time.Time{}
+			// Hooks are run by the refresh
+			g.RefreshFiles(expandRemoved(g, paths))
+		}
+	}
+}
+
+// expandRemoved adds indexed files of the removed or renamed directories
+func expandRemoved(g *IndexedGraph, paths []string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var expanded []string
+	for _, path := range paths {
+		expanded = append(expanded, path)
+		if isPageFile(path) {
+			continue
+		}
+		prefix := path + string(filepath.Separator)
+		for indexed := range g.files {
+			if strings.HasPrefix(indexed, prefix) {
+				expanded = append(expanded, indexed)
+			}
+		}
+	}
+	return expanded
+}
+
+// watchDirs adds the directory with all nested ones except hidden
+func watchDirs(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// pageFiles lists pages of the directory moved into the graph
+func pageFiles(root string) []string {
+	var paths []string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && isPageFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths
+}
+
+func isHidden(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if strings.HasPrefix(part, ".") && part != "." && part != ".." {
+			return true
+		}
+	}
+	return false
+}
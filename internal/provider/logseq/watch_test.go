@@ -0,0 +1,151 @@
+package logseq
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	dir := copyGraph(t)
+	g := NewIndexedGraph(NewRegexGraph(dir))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	events := make(chan PageChanges, 10)
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, g, 50*time.Millisecond, func(_ context.Context, _ *IndexedGraph, changes PageChanges) error {
+			events <- changes
+			return nil
+		})
+	}()
+	// Let the watcher add directories
+	time.Sleep(100 * time.Millisecond)
+
+	next := func() PageChanges {
+		t.Helper()
+		select {
+		case changes := <-events:
+			return changes
+		case <-time.After(5 * time.Second):
+			t.Fatal("no changes were received")
+			return PageChanges{}
+		}
+	}
+
+	// Burst of writes is debounced into a single change
+	fern := filepath.Join(dir, "pages", "fern.md")
+	for _, tag := range []string{"class", "genus", "order"} {
+		if err := os.WriteFile(fern, []byte("tags:: "+tag+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	changes := next()
+	if len(changes.Changed) != 1 || len(changes.Removed) != 0 {
+		t.Fatalf("unexpected changes %+v", changes)
+	}
+	if got := titles(g.PagesByTag("order")); !slices.Equal(got, []string{"fern"}) {
+		t.Errorf("unexpected pages by tag %v", got)
+	}
+
+	// Rename
+	if err := os.Rename(fern, filepath.Join(dir, "pages", "ferns.md")); err != nil {
+		t.Fatal(err)
+	}
+	changes = next()
+	if !slices.Equal(titles(changes.Changed), []string{"ferns"}) || !slices.Equal(titles(changes.Removed), []string{"fern"}) {
+		t.Errorf("unexpected rename changes %+v", changes)
+	}
+
+	// Deletion
+	if err := os.Remove(filepath.Join(dir, "pages", "damiana.md")); err != nil {
+		t.Fatal(err)
+	}
+	changes = next()
+	if len(changes.Changed) != 0 || !slices.Equal(titles(changes.Removed), []string{"damiana"}) {
+		t.Errorf("unexpected deletion changes %+v", changes)
+	}
+
+	// New directory with pages
+	nested := filepath.Join(t.TempDir(), "nested")
+	if err := os.Mkdir(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nested, "oak.md"), []byte("tags:: species\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(nested, filepath.Join(dir, "pages", "nested")); err != nil {
+		t.Fatal(err)
+	}
+	changes = next()
+	if !slices.Equal(titles(changes.Changed), []string{"oak"}) {
+		t.Errorf("unexpected moved directory changes %+v", changes)
+	}
+
+	// Refresh outside the watcher runs the hooks once
+	oak := filepath.Join(dir, "pages", "nested", "oak.md")
+	if err := os.WriteFile(oak, []byte("tags:: tree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	g.Refresh()
+	changes = next()
+	if !slices.Equal(titles(changes.Changed), []string{"oak"}) {
+		t.Errorf("unexpected refreshed changes %+v", changes)
+	}
+	select {
+	case changes := <-events:
+		t.Errorf("unexpected changes after the refresh %+v", changes)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// Hidden files are ignored
+	if err := os.WriteFile(filepath.Join(dir, "pages", ".oak.md.swp"), []byte("swap"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case changes := <-events:
+		t.Errorf("unexpected changes of the hidden file %+v", changes)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("watcher failed with %s", err)
+	}
+}
+
+func TestWatch_MaxWait(t *testing.T) {
+	dir := copyGraph(t)
+	g := NewIndexedGraph(NewRegexGraph(dir))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	events := make(chan PageChanges, 10)
+	go func() {
+		_ = Watch(ctx, g, 50*time.Millisecond, func(_ context.Context, _ *IndexedGraph, changes PageChanges) error {
+			events <- changes
+			return nil
+		})
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	// Writes more often than debounce interval don't postpone the refresh forever
+	fern := filepath.Join(dir, "pages", "fern.md")
+	deadline := time.After(5 * time.Second)
+	for i := 0; ; i++ {
+		if err := os.WriteFile(fern, []byte("- note "+time.Duration(i).String()+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-events:
+			return
+		case <-deadline:
+			t.Fatal("no changes were received while writing")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
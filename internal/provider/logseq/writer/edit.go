@@ -0,0 +1,128 @@
+package writer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mimi/internal/provider/logseq"
+	"mimi/internal/provider/logseq/outline"
+)
+
+type Property struct {
+	Name  string
+	Value string
+}
+
+// JournalPath returns path of the new journal page with the graph's file name format
+func JournalPath(cfg logseq.Config, day time.Time) string {
+	return filepath.Join(cfg.JournalsDirectory, cfg.FormatJournalFileName(day)+".md")
+}
+
+// PagePath returns path of the new page with the graph's file name format
+func PagePath(cfg logseq.Config, title string) string {
+	return filepath.Join(cfg.PagesDirectory, cfg.PageFileName(title)+".md")
+}
+
+// appendBlocks adds top level blocks to the end of the page
+func appendBlocks(path, content string, blocks []string) string {
+	if filepath.Ext(path) == ".org" {
+		return appendHeadlines(content, blocks)
+	}
+	page := outline.Parse(strings.TrimRight(content, "\n"))
+	if len(page.Blocks) == 1 && isEmpty(page.Blocks[0]) {
+		// LogSeq creates empty journals with a single empty block
+		page.Blocks = nil
+	}
+	for _, block := range blocks {
+		page.AppendBlock(strings.TrimSpace(block))
+	}
+	return page.String()
+}
+
+func isEmpty(b *outline.Block) bool {
+	return b.Content() == "" && len(b.Properties) == 0 && len(b.Children) == 0
+}
+
+// appendHeadlines adds top level headlines to the end of the Org page
+func appendHeadlines(content string, blocks []string) string {
+	content = strings.TrimRight(content, "\n")
+	if strings.TrimSpace(content) == "*" {
+		content = ""
+	}
+	var b strings.Builder
+	b.WriteString(content)
+	for _, block := range blocks {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		b.WriteString("* " + strings.Join(lines, "\n  "))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// newPage renders page properties followed by the blocks
+func newPage(props []Property, body string) string {
+	body = strings.TrimRight(body, "\n")
+	if body == "" {
+		body = "-"
+	}
+	return setProperties(body+"\n", props)
+}
+
+// setProperties replaces existing page properties and appends the new ones
+func setProperties(content string, props []Property) string {
+	page := outline.Parse(content)
+	for _, p := range props {
+		page.SetProperty(p.Name, p.Value)
+	}
+	return page.String()
+}
+
+// findTemplate searches pages for the block marked with `template:: name`
+// and returns its content ready to be inserted as top level blocks
+func findTemplate(pagesPath, name string) (string, error) {
+	entries, err := os.ReadDir(pagesPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to list pages with %w", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(pagesPath, e.Name()))
+		if err != nil {
+			return "", fmt.Errorf("failed to read page '%s' with %w", e.Name(), err)
+		}
+		if body, ok := extractTemplate(string(content), name); ok {
+			return body, nil
+		}
+	}
+	return "", fmt.Errorf("template '%s' not found", name)
+}
+
+func extractTemplate(content, name string) (string, bool) {
+	page := outline.Parse(content)
+	for b := range page.Walk() {
+		if value, ok := b.Property("template"); !ok || !strings.EqualFold(strings.TrimSpace(value), name) {
+			continue
+		}
+		blocks := []*outline.Block{b}
+		if value, _ := b.Property("template-including-parent"); strings.TrimSpace(value) == "false" {
+			blocks = b.Children
+		}
+		b.RemoveProperty("template")
+		b.RemoveProperty("template-including-parent")
+
+		texts := make([]string, len(blocks))
+		for i, block := range blocks {
+			texts[i] = block.String()
+		}
+		return strings.Join(texts, "\n"), true
+	}
+	return "", false
+}
@@ -0,0 +1,103 @@
+package writer
+
+import (
+	"testing"
+	"time"
+
+	"mimi/internal/provider/logseq"
+)
+
+func TestPaths(t *testing.T) {
+	day := time.Date(2025, 9, 8, 23, 0, 0, 0, time.UTC)
+	cfg := logseq.DefaultConfig()
+	if got := JournalPath(cfg, day); got != "journals/2025_09_08.md" {
+		t.Fatalf("unexpected journal path %s", got)
+	}
+	if got := PagePath(cfg, "plants/fern"); got != "pages/plants___fern.md" {
+		t.Fatalf("unexpected page path %s", got)
+	}
+
+	cfg.JournalFileFormat = "yyyy-MM-dd"
+	cfg.FileNameFormat = logseq.LegacyFileNameFormat
+	cfg.JournalsDirectory = "daily"
+	if got := JournalPath(cfg, day); got != "daily/2025-09-08.md" {
+		t.Fatalf("unexpected journal path %s", got)
+	}
+	if got := PagePath(cfg, "plants/fern?"); got != "pages/plants%2Ffern%3F.md" {
+		t.Fatalf("unexpected page path %s", got)
+	}
+}
+
+func TestAppendBlocks(t *testing.T) {
+	cases := []struct {
+		path     string
+		content  string
+		blocks   []string
+		expected string
+	}{
+		{"a.md", "", []string{"first"}, "- first\n"},
+		{"a.md", "-\n", []string{"first"}, "- first\n"},
+		{"a.md", "- old\n\t- child\n\n", []string{"new\nsecond line", "another"}, "- old\n\t- child\n- new\n  second line\n- another\n"},
+		{"a.org", "* old\n", []string{"new"}, "* old\n* new\n"},
+	}
+	for _, c := range cases {
+		if got := appendBlocks(c.path, c.content, c.blocks); got != c.expected {
+			t.Fatalf("expected %q, got %q", c.expected, got)
+		}
+	}
+}
+
+func TestSetProperties(t *testing.T) {
+	props := []Property{{"tags", "species, psycho"}, {"supply", "next-month"}}
+	cases := map[string]string{
+		"tags:: species\nalias:: foo\n\n- block\n": "tags:: species, psycho\nalias:: foo\nsupply:: next-month\n\n- block\n",
+		"- block\n":                   "tags:: species, psycho\nsupply:: next-month\n\n- block\n",
+		"тип:: растение\n\n- block\n": "тип:: растение\ntags:: species, psycho\nsupply:: next-month\n\n- block\n",
+	}
+	for content, expected := range cases {
+		if got := setProperties(content, props); got != expected {
+			t.Fatalf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	got := newPage([]Property{{"tags", "species"}}, "- type:: plant\n")
+	if got != "tags:: species\n\n- type:: plant\n" {
+		t.Fatalf("unexpected page %q", got)
+	}
+	if got := newPage(nil, ""); got != "-\n" {
+		t.Fatalf("unexpected empty page %q", got)
+	}
+}
+
+const templates = `- Other block
+- Plant
+  template:: plant
+  template-including-parent:: false
+	- type:: species
+	- ## Care
+		- watering
+- Event
+  template:: event
+	- date::
+- Last`
+
+func TestExtractTemplate(t *testing.T) {
+	cases := map[string]string{
+		"plant": "- type:: species\n- ## Care\n\t- watering",
+		"Event": "- Event\n\t- date::",
+	}
+	for name, expected := range cases {
+		got, ok := extractTemplate(templates, name)
+		if !ok {
+			t.Fatalf("template '%s' not found", name)
+		}
+		if got != expected {
+			t.Fatalf("expected %q for '%s', got %q", expected, name, got)
+		}
+	}
+	if _, ok := extractTemplate(templates, "missing"); ok {
+		t.Fatal("expected missing template not to be found")
+	}
+}
@@ -0,0 +1,261 @@
+package writer
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"mimi/internal/provider/git"
+	"mimi/internal/provider/logseq"
+)
+
+// Upper bound of push attempts when the remote has diverged
+const maxPushAttempts = 3
+
+type Author struct {
+	Name  string
+	Email string
+}
+
+func (a Author) String() string {
+	return fmt.Sprintf("%s <%s>", a.Name, a.Email)
+}
+
+// Writer edits LogSeq graph files and publishes them to the graph's git remote
+type Writer struct {
+	repoPath  string
+	committer Author
+	env       []string
+	// Serializes edits of the working tree
+	mu sync.Mutex
+	// Called between commit and push, used by tests to emulate concurrent pushes
+	beforePush func()
+}
+
+type Option = func(*Writer)
+
+// WithAuthToken authorizes pushes to GitHub over HTTP
+func WithAuthToken(token string) Option {
+	return func(w *Writer) {
+		if token == "" {
+			return
+		}
+		basic := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		// Passed via environment to keep the token out of the logged arguments
+		w.env = append(w.env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=AUTHORIZATION: basic "+basic,
+		)
+	}
+}
+
+func New(repoPath string, committer Author, opts ...Option) *Writer {
+	w := &Writer{
+		repoPath:  repoPath,
+		committer: committer,
+		env: []string{
+			"GIT_AUTHOR_NAME=" + committer.Name,
+			"GIT_AUTHOR_EMAIL=" + committer.Email,
+			"GIT_COMMITTER_NAME=" + committer.Name,
+			"GIT_COMMITTER_EMAIL=" + committer.Email,
+		},
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Commit describes published change
+type Commit struct {
+	Hash string
+	// Changed files relative to the repository root
+	Paths []string
+}
+
+// AppendToJournal adds blocks to the end of the `day`'s journal page creating it if needed
+func (w *Writer) AppendToJournal(ctx context.Context, day time.Time, blocks []string, coAuthor *Author) (Commit, error) {
+	msg := fmt.Sprintf("Add %d block(s) to %s journal", len(blocks), day.Format(time.DateOnly))
+	return w.apply(ctx, msg, coAuthor, func() ([]string, error) {
+		g := logseq.NewRegexGraph(w.repoPath)
+		path, ok := w.findPage(g, g.Config.FormatJournalTitle(day))
+		if !ok {
+			path = JournalPath(g.Config, day)
+		}
+		content, err := w.read(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return []string{path}, w.write(path, appendBlocks(path, content, blocks))
+	})
+}
+
+// CreatePage creates a new page with properties from the named template,
+// empty `template` creates page without any blocks
+func (w *Writer) CreatePage(ctx context.Context, title, template string, props []Property, coAuthor *Author) (Commit, error) {
+	msg := fmt.Sprintf("Create page %s", title)
+	return w.apply(ctx, msg, coAuthor, func() ([]string, error) {
+		g := logseq.NewRegexGraph(w.repoPath)
+		if path, ok := w.findPage(g, title); ok {
+			return nil, fmt.Errorf("page '%s' already exists at %s", title, path)
+		}
+		var body string
+		if template != "" {
+			var err error
+			body, err = findTemplate(filepath.Join(w.repoPath, g.Config.PagesDirectory), template)
+			if err != nil {
+				return nil, err
+			}
+		}
+		path := PagePath(g.Config, title)
+		return []string{path}, w.write(path, newPage(props, body))
+	})
+}
+
+// SetPageProperties adds or replaces page level properties of the existing page
+func (w *Writer) SetPageProperties(ctx context.Context, title string, props []Property, coAuthor *Author) (Commit, error) {
+	msg := fmt.Sprintf("Set %s properties", title)
+	return w.apply(ctx, msg, coAuthor, func() ([]string, error) {
+		path, ok := w.findPage(logseq.NewRegexGraph(w.repoPath), title)
+		if !ok {
+			return nil, fmt.Errorf("page '%s' not found", title)
+		}
+		if filepath.Ext(path) == ".org" {
+			return nil, fmt.Errorf("setting properties of Org page '%s' isn't supported", title)
+		}
+		content, err := w.read(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read page '%s' with %w", title, err)
+		}
+		return []string{path}, w.write(path, setProperties(content, props))
+	})
+}
+
+// findPage returns path of the existing page named or aliased by `title` relative to the repository,
+// journals are named by their formatted titles
+func (w *Writer) findPage(g logseq.RegexGraph, title string) (string, bool) {
+	pages := slices.Collect(g.WalkPages())
+	resolved, ok := logseq.NewResolver(slices.Values(pages)).Resolve(title)
+	if !ok {
+		return "", false
+	}
+	for _, page := range pages {
+		if logseq.PageName(page.Title()) != logseq.PageName(resolved) {
+			continue
+		}
+		path, err := filepath.Rel(w.repoPath, page.Path)
+		if err != nil {
+			slog.Warn("failed to find page path in the repository", "path", page.Path, "with", err)
+			return "", false
+		}
+		return path, true
+	}
+	return "", false
+}
+
+// apply syncs the working tree, edits it, commits and pushes the changes.
+// When the remote diverges the commit is rebased, conflicting edits are
+// applied again on top of the remote state
+func (w *Writer) apply(ctx context.Context, msg string, coAuthor *Author, edit func() ([]string, error)) (Commit, error) {
+	var c Commit
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// Keeps scrapers from pulling into the working tree in the middle of the edit
+	unlock, err := git.Lock(w.repoPath)
+	if err != nil {
+		return c, err
+	}
+	defer unlock()
+
+	if _, err := w.git("pull", "--rebase"); err != nil {
+		return c, fmt.Errorf("failed to sync graph repository with %w", err)
+	}
+	if coAuthor != nil {
+		msg = fmt.Sprintf("%s\n\nCo-authored-by: %s", msg, coAuthor)
+	}
+
+	committed := false
+	for attempt := 1; attempt <= maxPushAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return c, w.reset(err)
+		}
+		if !committed {
+			paths, err := edit()
+			if err != nil {
+				return c, w.reset(err)
+			}
+			args := append([]string{"add", "--"}, paths...)
+			if _, err := w.git(args...); err != nil {
+				return c, w.reset(err)
+			}
+			if _, err := w.git("commit", "-m", msg); err != nil {
+				return c, w.reset(err)
+			}
+			c.Paths = paths
+			committed = true
+		}
+
+		if w.beforePush != nil {
+			w.beforePush()
+		}
+		_, err := w.git("push")
+		if err == nil {
+			hash, err := w.git("rev-parse", "HEAD")
+			if err != nil {
+				return c, err
+			}
+			c.Hash = strings.TrimSpace(string(hash))
+			slog.Info("pushed LogSeq graph changes", "commit", c.Hash, "attempt", attempt)
+			return c, nil
+		}
+		slog.Warn("failed to push LogSeq graph changes", "attempt", attempt, "with", err)
+
+		if _, err := w.git("pull", "--rebase"); err != nil {
+			// Concurrent changes conflict with ours, edit the fresh state again
+			slog.Warn("failed to rebase LogSeq graph changes", "with", err)
+			_, _ = w.git("rebase", "--abort")
+			if _, err := w.git("reset", "--hard", "@{u}"); err != nil {
+				return c, err
+			}
+			committed = false
+		}
+	}
+	return c, w.reset(fmt.Errorf("failed to push changes in %d attempts", maxPushAttempts))
+}
+
+// reset drops local changes and commits which weren't published
+func (w *Writer) reset(cause error) error {
+	_, _ = w.git("rebase", "--abort")
+	if _, err := w.git("reset", "--hard", "@{u}"); err != nil {
+		return fmt.Errorf("%w, failed to reset repository with %w", cause, err)
+	}
+	return cause
+}
+
+func (w *Writer) git(args ...string) ([]byte, error) {
+	return git.GitEnv(w.repoPath, w.env, args...)
+}
+
+func (w *Writer) read(path string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(w.repoPath, path))
+	return string(content), err
+}
+
+func (w *Writer) write(path, content string) error {
+	full := filepath.Join(w.repoPath, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for '%s' with %w", path, err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write '%s' with %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mimi/internal/provider/git"
+)
+
+var (
+	committer = Author{Name: "mimi", Email: "mimi@example.com"}
+	coAuthor  = Author{Name: "Alice", Email: "42@telegram.invalid"}
+	identity  = []string{
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	}
+	day = time.Date(2025, 9, 18, 12, 0, 0, 0, time.UTC)
+)
+
+// setupRemote creates bare repository with initial graph and returns its path
+func setupRemote(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	remote := filepath.Join(dir, "remote.git")
+	seed := filepath.Join(dir, "seed")
+	mustGit(t, dir, "init", "--bare", "--initial-branch=main", remote)
+	mustGit(t, dir, "clone", remote, seed)
+	writeFile(t, seed, "pages/fern.md", "tags:: species\n\n- Fern\n")
+	writeFile(t, seed, "pages/templates.md", "- Plant\n  template:: plant\n  template-including-parent:: false\n\t- type:: species\n")
+	writeFile(t, seed, "pages/plants___palm.md", "alias:: coconut\n\n- Palm\n")
+	writeFile(t, seed, "journals/2025_09_18.md", "- morning\n")
+	writeFile(t, seed, "journals/2025_09_19.org", "* morning\n")
+	mustGit(t, seed, "add", ".")
+	mustGit(t, seed, "commit", "-m", "init")
+	mustGit(t, seed, "push", "origin", "HEAD:main")
+	return remote
+}
+
+func clone(t *testing.T, remote string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "clone")
+	mustGit(t, filepath.Dir(path), "clone", remote, path)
+	return path
+}
+
+func mustGit(t *testing.T, cwd string, args ...string) string {
+	t.Helper()
+	out, err := git.GitEnv(cwd, identity, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func writeFile(t *testing.T, repo, path, content string) {
+	t.Helper()
+	full := filepath.Join(repo, path)
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readRemote(t *testing.T, remote, path string) string {
+	t.Helper()
+	return mustGit(t, filepath.Dir(remote), "--git-dir", remote, "show", "main:"+path)
+}
+
+func TestWriter(t *testing.T) {
+	remote := setupRemote(t)
+	w := New(clone(t, remote), committer)
+	ctx := t.Context()
+
+	c, err := w.AppendToJournal(ctx, day, []string{"evening"}, &coAuthor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := readRemote(t, remote, "journals/2025_09_18.md"); got != "- morning\n- evening\n" {
+		t.Fatalf("unexpected journal %q", got)
+	}
+	msg := mustGit(t, filepath.Dir(remote), "--git-dir", remote, "log", "-1", "--format=%an%n%B", c.Hash)
+	if !strings.HasPrefix(msg, "mimi\n") || !strings.Contains(msg, "Co-authored-by: Alice <42@telegram.invalid>") {
+		t.Fatalf("unexpected commit message %q", msg)
+	}
+
+	if _, err := w.CreatePage(ctx, "damiana", "plant", []Property{{"tags", "species"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := readRemote(t, remote, "pages/damiana.md"); got != "tags:: species\n\n- type:: species\n" {
+		t.Fatalf("unexpected page %q", got)
+	}
+	for _, title := range []string{"fern", "Coconut"} {
+		if _, err := w.CreatePage(ctx, title, "", nil, nil); err == nil {
+			t.Fatalf("expected error for existing page '%s'", title)
+		}
+	}
+	if _, err := w.CreatePage(ctx, "plants/cycad", "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := readRemote(t, remote, "pages/plants___cycad.md"); got != "-\n" {
+		t.Fatalf("unexpected page %q", got)
+	}
+
+	if _, err := w.SetPageProperties(ctx, "fern", []Property{{"supply", "now"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := readRemote(t, remote, "pages/fern.md"); got != "tags:: species\nsupply:: now\n\n- Fern\n" {
+		t.Fatalf("unexpected page %q", got)
+	}
+	// Existing pages are found by their aliases
+	if _, err := w.SetPageProperties(ctx, "coconut", []Property{{"supply", "now"}}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := readRemote(t, remote, "pages/plants___palm.md"); got != "alias:: coconut\nsupply:: now\n\n- Palm\n" {
+		t.Fatalf("unexpected page %q", got)
+	}
+	if _, err := w.SetPageProperties(ctx, "missing", []Property{{"supply", "now"}}, nil); err == nil {
+		t.Fatal("expected error for missing page")
+	}
+
+	// Journals in Org format are kept instead of creating Markdown duplicates
+	if _, err := w.AppendToJournal(ctx, day.AddDate(0, 0, 1), []string{"evening"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := readRemote(t, remote, "journals/2025_09_19.org"); got != "* morning\n* evening\n" {
+		t.Fatalf("unexpected journal %q", got)
+	}
+}
+
+func TestWriter_Conflict(t *testing.T) {
+	remote := setupRemote(t)
+	other := clone(t, remote)
+	w := New(clone(t, remote), committer)
+
+	// Somebody appends to the same journal right before our push
+	pushes := 0
+	w.beforePush = func() {
+		pushes++
+		if pushes > 1 {
+			return
+		}
+		writeFile(t, other, "journals/2025_09_18.md", "- morning\n- concurrent\n")
+		mustGit(t, other, "commit", "-am", "concurrent")
+		mustGit(t, other, "push")
+	}
+
+	if _, err := w.AppendToJournal(t.Context(), day, []string{"evening"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if pushes != 2 {
+		t.Fatalf("expected 2 push attempts, got %d", pushes)
+	}
+	if got := readRemote(t, remote, "journals/2025_09_18.md"); got != "- morning\n- concurrent\n- evening\n" {
+		t.Fatalf("unexpected journal %q", got)
+	}
+}
@@ -0,0 +1,262 @@
+package x
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format of the `created_at` fields
+const createdAtLayout = time.RubyDate
+
+type userTweetsResponse struct {
+	Data struct {
+		User struct {
+			Result struct {
+				TimelineV2 *timeline `json:"timeline_v2"`
+				// Newer responses dropped the version suffix
+				Timeline *timeline `json:"timeline"`
+			} `json:"result"`
+		} `json:"user"`
+	} `json:"data"`
+}
+
+func (r userTweetsResponse) instructions() []instruction {
+	result := r.Data.User.Result
+	t := cmp.Or(result.TimelineV2, result.Timeline)
+	if t == nil {
+		return nil
+	}
+	return t.Timeline.Instructions
+}
+
+type timeline struct {
+	Timeline struct {
+		Instructions []instruction `json:"instructions"`
+	} `json:"timeline"`
+}
+
+type instruction struct {
+	Type string `json:"type"`
+	// Set for TimelineAddEntries
+	Entries []entry `json:"entries"`
+	// Set for TimelinePinEntry
+	Entry *entry `json:"entry"`
+}
+
+type entry struct {
+	EntryID string `json:"entryId"`
+	Content struct {
+		EntryType string `json:"entryType"`
+		// Set for TimelineTimelineItem
+		ItemContent itemContent `json:"itemContent"`
+		// Set for TimelineTimelineModule
+		Items []struct {
+			Item struct {
+				ItemContent itemContent `json:"itemContent"`
+			} `json:"item"`
+		} `json:"items"`
+	} `json:"content"`
+}
+
+type itemContent struct {
+	ItemType     string `json:"itemType"`
+	TweetResults struct {
+		Result *tweetResult `json:"result"`
+	} `json:"tweet_results"`
+}
+
+type tweetResult struct {
+	Typename string `json:"__typename"`
+	RestID   string `json:"rest_id"`
+	// Set for TweetWithVisibilityResults
+	Tweet *tweetResult `json:"tweet"`
+	Core  struct {
+		UserResults struct {
+			Result struct {
+				RestID string `json:"rest_id"`
+				Legacy struct {
+					Name       string `json:"name"`
+					ScreenName string `json:"screen_name"`
+				} `json:"legacy"`
+				// Newer responses moved names out of legacy
+				Core struct {
+					Name       string `json:"name"`
+					ScreenName string `json:"screen_name"`
+				} `json:"core"`
+			} `json:"result"`
+		} `json:"user_results"`
+	} `json:"core"`
+	// Set for long tweets, legacy text is truncated then
+	NoteTweet struct {
+		NoteTweetResults struct {
+			Result struct {
+				Text      string   `json:"text"`
+				EntitySet entities `json:"entity_set"`
+			} `json:"result"`
+		} `json:"note_tweet_results"`
+	} `json:"note_tweet"`
+	QuotedStatus struct {
+		Result *tweetResult `json:"result"`
+	} `json:"quoted_status_result"`
+	Legacy struct {
+		IDStr                string   `json:"id_str"`
+		UserIDStr            string   `json:"user_id_str"`
+		CreatedAt            string   `json:"created_at"`
+		FullText             string   `json:"full_text"`
+		Lang                 string   `json:"lang"`
+		ConversationIDStr    string   `json:"conversation_id_str"`
+		InReplyToStatusIDStr string   `json:"in_reply_to_status_id_str"`
+		InReplyToScreenName  string   `json:"in_reply_to_screen_name"`
+		QuotedStatusIDStr    string   `json:"quoted_status_id_str"`
+		Entities             entities `json:"entities"`
+		ExtendedEntities     struct {
+			Media []media `json:"media"`
+		} `json:"extended_entities"`
+		FavoriteCount   int `json:"favorite_count"`
+		RetweetCount    int `json:"retweet_count"`
+		ReplyCount      int `json:"reply_count"`
+		QuoteCount      int `json:"quote_count"`
+		RetweetedStatus struct {
+			Result *tweetResult `json:"result"`
+		} `json:"retweeted_status_result"`
+	} `json:"legacy"`
+}
+
+type entities struct {
+	URLs []struct {
+		URL         string `json:"url"`
+		ExpandedURL string `json:"expanded_url"`
+	} `json:"urls"`
+	Media []media `json:"media"`
+}
+
+type media struct {
+	Type          string `json:"type"`
+	URL           string `json:"url"`
+	MediaURLHTTPS string `json:"media_url_https"`
+	VideoInfo     struct {
+		Variants []struct {
+			Bitrate     int    `json:"bitrate"`
+			ContentType string `json:"content_type"`
+			URL         string `json:"url"`
+		} `json:"variants"`
+	} `json:"video_info"`
+}
+
+// unwrap returns the tweet itself skipping visibility wrappers and
+// returns nil for tombstones and tweets which are unavailable
+func (r *tweetResult) unwrap() *tweetResult {
+	for r != nil && r.Tweet != nil {
+		r = r.Tweet
+	}
+	if r == nil || r.Legacy.IDStr == "" && r.RestID == "" {
+		return nil
+	}
+	return r
+}
+
+func (r *tweetResult) parse() (Tweet, error) {
+	var t Tweet
+	var err error
+	l := r.Legacy
+	t.ID, err = parseID(cmp.Or(l.IDStr, r.RestID))
+	if err != nil {
+		return t, err
+	}
+	user := r.Core.UserResults.Result
+	t.AuthorID, err = parseID(cmp.Or(l.UserIDStr, user.RestID))
+	if err != nil {
+		return t, fmt.Errorf("failed to parse author of %d with %w", t.ID, err)
+	}
+	t.AuthorScreenName = cmp.Or(user.Core.ScreenName, user.Legacy.ScreenName)
+	t.AuthorName = cmp.Or(user.Core.Name, user.Legacy.Name)
+	t.CreatedAt, err = time.Parse(createdAtLayout, l.CreatedAt)
+	if err != nil {
+		return t, fmt.Errorf("failed to parse creation time of %d with %w", t.ID, err)
+	}
+	t.Lang = l.Lang
+	t.ConversationID = t.ID
+	if l.ConversationIDStr != "" {
+		if t.ConversationID, err = parseID(l.ConversationIDStr); err != nil {
+			return t, err
+		}
+	}
+	if l.InReplyToStatusIDStr != "" {
+		if t.InReplyToID, err = parseID(l.InReplyToStatusIDStr); err != nil {
+			return t, err
+		}
+		t.InReplyToScreenName = l.InReplyToScreenName
+	}
+	if l.QuotedStatusIDStr != "" {
+		if t.QuotedID, err = parseID(l.QuotedStatusIDStr); err != nil {
+			return t, err
+		}
+	}
+	t.FavoriteCount = l.FavoriteCount
+	t.RetweetCount = l.RetweetCount
+	t.ReplyCount = l.ReplyCount
+	t.QuoteCount = l.QuoteCount
+
+	for _, m := range l.ExtendedEntities.Media {
+		t.Media = append(t.Media, Media{Type: m.Type, URL: m.bestURL()})
+	}
+
+	text, ents := l.FullText, l.Entities
+	if note := r.NoteTweet.NoteTweetResults.Result; note.Text != "" {
+		text, ents = note.Text, note.EntitySet
+		// Media links are listed only in the legacy entities
+		ents.Media = l.Entities.Media
+	}
+	if retweeted := l.RetweetedStatus.Result.unwrap(); retweeted != nil {
+		// Retweet's own text is truncated "RT @author: ..." prefix
+		original, err := retweeted.parse()
+		if err != nil {
+			return t, err
+		}
+		t.RetweetedID = original.ID
+		text = fmt.Sprintf("RT @%s: %s", original.AuthorScreenName, original.Text)
+		ents = entities{}
+	}
+	t.Text = expandText(text, ents)
+	return t, nil
+}
+
+// bestURL returns photo URL or the highest bitrate MP4 variant of the video
+func (m media) bestURL() string {
+	var best string
+	bitrate := -1
+	for _, v := range m.VideoInfo.Variants {
+		if v.ContentType == "video/mp4" && v.Bitrate > bitrate {
+			best, bitrate = v.URL, v.Bitrate
+		}
+	}
+	return cmp.Or(best, m.MediaURLHTTPS)
+}
+
+// expandText replaces shortened t.co links with the original ones
+// and drops links to the attached media
+func expandText(text string, ents entities) string {
+	for _, u := range ents.URLs {
+		if u.URL != "" {
+			text = strings.ReplaceAll(text, u.URL, u.ExpandedURL)
+		}
+	}
+	for _, m := range ents.Media {
+		if m.URL != "" {
+			text = strings.ReplaceAll(text, m.URL, "")
+		}
+	}
+	return strings.TrimSpace(html.UnescapeString(text))
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse id '%s' with %w", s, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,56 @@
+package x
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"mimi/internal/persist"
+)
+
+// Save upserts tweets, already imported ones get the latest text and counters
+func Save(ctx context.Context, q *persist.Queries, tweets []Tweet) error {
+	for _, t := range tweets {
+		media, err := json.Marshal(cmpMedia(t.Media))
+		if err != nil {
+			return fmt.Errorf("failed to marshal media of %d with %w", t.ID, err)
+		}
+		err = q.SaveXTweet(ctx, persist.SaveXTweetParams{
+			ID:                  t.ID,
+			AuthorID:            t.AuthorID,
+			AuthorScreenName:    t.AuthorScreenName,
+			AuthorName:          t.AuthorName,
+			Text:                t.Text,
+			Lang:                t.Lang,
+			ConversationID:      t.ConversationID,
+			InReplyToID:         optionalID(t.InReplyToID),
+			InReplyToScreenName: pgtype.Text{String: t.InReplyToScreenName, Valid: t.InReplyToScreenName != ""},
+			QuotedID:            optionalID(t.QuotedID),
+			RetweetedID:         optionalID(t.RetweetedID),
+			Media:               media,
+			FavoriteCount:       int32(t.FavoriteCount),
+			RetweetCount:        int32(t.RetweetCount),
+			ReplyCount:          int32(t.ReplyCount),
+			QuoteCount:          int32(t.QuoteCount),
+			CreatedAt:           pgtype.Timestamptz{Time: t.CreatedAt, Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save tweet %d with %w", t.ID, err)
+		}
+	}
+	return nil
+}
+
+// cmpMedia keeps empty media as JSON array instead of null
+func cmpMedia(media []Media) []Media {
+	if media == nil {
+		return []Media{}
+	}
+	return media
+}
+
+func optionalID(id int64) pgtype.Int8 {
+	return pgtype.Int8{Int64: id, Valid: id != 0}
+}
@@ -0,0 +1,108 @@
+// Package x parses X (Twitter) UserTweets GraphQL responses
+// exported from the browser and stores them in Postgres
+package x
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+)
+
+type Tweet struct {
+	ID               int64     `json:"id"`
+	AuthorID         int64     `json:"authorId"`
+	AuthorScreenName string    `json:"authorScreenName"`
+	AuthorName       string    `json:"authorName"`
+	Text             string    `json:"text"`
+	Lang             string    `json:"lang"`
+	ConversationID   int64     `json:"conversationId"`
+	CreatedAt        time.Time `json:"createdAt"`
+	// Zero unless the tweet replies, quotes or retweets another one
+	InReplyToID         int64   `json:"inReplyToId,omitempty"`
+	InReplyToScreenName string  `json:"inReplyToScreenName,omitempty"`
+	QuotedID            int64   `json:"quotedId,omitempty"`
+	RetweetedID         int64   `json:"retweetedId,omitempty"`
+	Media               []Media `json:"media,omitempty"`
+	FavoriteCount       int     `json:"favoriteCount"`
+	RetweetCount        int     `json:"retweetCount"`
+	ReplyCount          int     `json:"replyCount"`
+	QuoteCount          int     `json:"quoteCount"`
+}
+
+type Media struct {
+	// One of photo, video or animated_gif
+	Type string `json:"type"`
+	// Image itself or the best quality video
+	URL string `json:"url"`
+}
+
+// URL links to the tweet on x.com
+func (t Tweet) URL() string {
+	return TweetURL(t.AuthorScreenName, t.ID)
+}
+
+func TweetURL(screenName string, id int64) string {
+	return fmt.Sprintf("https://x.com/%s/status/%d", screenName, id)
+}
+
+// ParseUserTweets extracts tweets with the referenced quotes and retweets from
+// the UserTweets response, tweets are unique and ordered by creation time
+func ParseUserTweets(r io.Reader) ([]Tweet, error) {
+	var resp userTweetsResponse
+	if err := json.NewDecoder(r).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to decode UserTweets response with %w", err)
+	}
+
+	seen := make(map[int64]bool)
+	var tweets []Tweet
+	var visit func(raw *tweetResult) error
+	visit = func(raw *tweetResult) error {
+		raw = raw.unwrap()
+		if raw == nil {
+			return nil
+		}
+		t, err := raw.parse()
+		if err != nil {
+			return err
+		}
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			tweets = append(tweets, t)
+		}
+		if err := visit(raw.QuotedStatus.Result); err != nil {
+			return err
+		}
+		return visit(raw.Legacy.RetweetedStatus.Result)
+	}
+
+	for _, instruction := range resp.instructions() {
+		entries := instruction.Entries
+		if instruction.Entry != nil {
+			// Pinned tweet
+			entries = append(entries, *instruction.Entry)
+		}
+		for _, entry := range entries {
+			items := []itemContent{entry.Content.ItemContent}
+			for _, item := range entry.Content.Items {
+				// Conversation modules group several tweets
+				items = append(items, item.Item.ItemContent)
+			}
+			for _, item := range items {
+				if item.ItemType != "TimelineTweet" {
+					continue
+				}
+				if err := visit(item.TweetResults.Result); err != nil {
+					return nil, fmt.Errorf("failed to parse entry '%s' with %w", entry.EntryID, err)
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(tweets, func(lhs, rhs Tweet) int {
+		return cmp.Or(lhs.CreatedAt.Compare(rhs.CreatedAt), cmp.Compare(lhs.ID, rhs.ID))
+	})
+	return tweets, nil
+}
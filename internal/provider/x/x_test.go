@@ -0,0 +1,158 @@
+package x
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const exportPath = "../../../user-tweets/mastercyb.json"
+
+func parseExport(t *testing.T) []Tweet {
+	t.Helper()
+	f, err := os.Open(exportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tweets, err := ParseUserTweets(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tweets
+}
+
+func find(tweets []Tweet, id int64) (Tweet, bool) {
+	for _, t := range tweets {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return Tweet{}, false
+}
+
+func TestParseUserTweets(t *testing.T) {
+	tweets := parseExport(t)
+	// 98 timeline entries, the pinned one and 2 quoted tweets of other authors
+	if len(tweets) != 101 {
+		t.Fatalf("expected 101 tweets, got %d", len(tweets))
+	}
+	seen := make(map[int64]bool)
+	for i, tweet := range tweets {
+		if seen[tweet.ID] {
+			t.Fatalf("duplicated tweet %d", tweet.ID)
+		}
+		seen[tweet.ID] = true
+		if i > 0 && tweet.CreatedAt.Before(tweets[i-1].CreatedAt) {
+			t.Fatalf("tweets aren't ordered at %d", i)
+		}
+		if tweet.AuthorScreenName == "" || tweet.Text == "" {
+			t.Fatalf("incomplete tweet %+v", tweet)
+		}
+		if strings.Contains(tweet.Text, "https://t.co/") {
+			t.Fatalf("tweet %d has shortened links: %s", tweet.ID, tweet.Text)
+		}
+	}
+
+	first, ok := find(tweets, 1093385304290066433)
+	if !ok {
+		t.Fatal("expected tweet not found")
+	}
+	if first.AuthorScreenName != "mastercyb" || first.AuthorID != 62768919 {
+		t.Fatalf("unexpected author %s/%d", first.AuthorScreenName, first.AuthorID)
+	}
+	if !first.CreatedAt.Equal(time.Date(2019, 2, 7, 5, 45, 45, 0, time.UTC)) {
+		t.Fatalf("unexpected creation time %s", first.CreatedAt)
+	}
+	if !strings.Contains(first.Text, "https://github.com/cybercongress/cyberd/blob/master/docs/cyberd.md") {
+		t.Fatalf("expected expanded link in %s", first.Text)
+	}
+	if first.URL() != "https://x.com/mastercyb/status/1093385304290066433" {
+		t.Fatalf("unexpected URL %s", first.URL())
+	}
+}
+
+func TestParseUserTweets_References(t *testing.T) {
+	tweets := parseExport(t)
+	var replies, quotes, media, videos, long int
+	for _, tweet := range tweets {
+		if tweet.InReplyToID != 0 {
+			replies++
+			if tweet.InReplyToScreenName == "" {
+				t.Fatalf("reply %d without author", tweet.ID)
+			}
+		}
+		// Quotes of the quoted tweets aren't included into exports
+		if tweet.QuotedID != 0 && tweet.AuthorScreenName == "mastercyb" {
+			quotes++
+			quoted, ok := find(tweets, tweet.QuotedID)
+			if !ok {
+				t.Fatalf("quoted tweet %d not imported", tweet.QuotedID)
+			}
+			if quoted.AuthorScreenName == "mastercyb" {
+				t.Fatalf("unexpected quoted tweet author")
+			}
+		}
+		if len(tweet.Media) > 0 {
+			media++
+		}
+		for _, m := range tweet.Media {
+			if m.Type == "video" {
+				videos++
+				if !strings.Contains(m.URL, ".mp4") {
+					t.Fatalf("expected MP4 video, got %s", m.URL)
+				}
+			}
+		}
+		// Long tweets have more than 280 characters
+		if len([]rune(tweet.Text)) > 280 {
+			long++
+		}
+	}
+	if replies != 13 || quotes != 2 || media != 28 || videos == 0 || long == 0 {
+		t.Fatalf("unexpected counts: %d replies, %d quotes, %d with media, %d videos, %d long", replies, quotes, media, videos, long)
+	}
+}
+
+func TestParseUserTweets_Retweet(t *testing.T) {
+	export := `{"data": {"user": {"result": {"timeline": {"timeline": {"instructions": [
+	  {"type": "TimelineAddEntries", "entries": [{"entryId": "tweet-2", "content": {
+	    "entryType": "TimelineTimelineItem",
+	    "itemContent": {"itemType": "TimelineTweet", "tweet_results": {"result": {
+	      "__typename": "TweetWithVisibilityResults",
+	      "tweet": {
+	        "rest_id": "2",
+	        "core": {"user_results": {"result": {"rest_id": "10", "core": {"name": "Master", "screen_name": "mastercyb"}}}},
+	        "legacy": {
+	          "id_str": "2", "user_id_str": "10", "created_at": "Tue Aug 04 09:20:52 +0000 2020",
+	          "full_text": "RT @other: Truncated…", "lang": "en",
+	          "retweeted_status_result": {"result": {
+	            "rest_id": "1",
+	            "core": {"user_results": {"result": {"rest_id": "20", "legacy": {"name": "Other", "screen_name": "other"}}}},
+	            "legacy": {
+	              "id_str": "1", "user_id_str": "20", "created_at": "Mon Aug 03 09:20:52 +0000 2020",
+	              "full_text": "Tom &amp; Jerry https://t.co/abc", "lang": "en",
+	              "entities": {"urls": [{"url": "https://t.co/abc", "expanded_url": "https://example.com"}]}
+	            }
+	          }}
+	        }
+	      }
+	    }}}
+	  }}]}
+	]}}}}}}`
+	tweets, err := ParseUserTweets(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tweets) != 2 {
+		t.Fatalf("expected retweet with the original tweet, got %+v", tweets)
+	}
+	original, retweet := tweets[0], tweets[1]
+	if original.Text != "Tom & Jerry https://example.com" || original.AuthorScreenName != "other" {
+		t.Fatalf("unexpected original tweet %+v", original)
+	}
+	if retweet.RetweetedID != 1 || retweet.Text != "RT @other: Tom & Jerry https://example.com" || retweet.AuthorName != "Master" {
+		t.Fatalf("unexpected retweet %+v", retweet)
+	}
+}
@@ -0,0 +1,6 @@
+-- Partial summaries of the summary agent keyed by hash of the summarized data
+CREATE TABLE IF NOT EXISTS summary_cache (
+    key text PRIMARY KEY,
+    summary text NOT NULL,
+    created_at timestamp WITH time zone NOT NULL DEFAULT NOW()
+);
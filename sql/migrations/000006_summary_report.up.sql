@@ -0,0 +1,12 @@
+-- Generated summaries with the data they were built from
+CREATE TABLE IF NOT EXISTS summary_report (
+    id serial PRIMARY KEY,
+    since timestamp WITH time zone NOT NULL,
+    until timestamp WITH time zone NOT NULL,
+    -- Data sources which had any data for the period
+    sources text [] NOT NULL,
+    -- Identifiers of the summarized items with their states grouped by the data source
+    items jsonb NOT NULL,
+    report text NOT NULL,
+    created_at timestamp WITH time zone NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1 @@
+DROP TABLE github_project_rule;
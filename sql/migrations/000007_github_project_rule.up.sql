@@ -0,0 +1,21 @@
+-- Rules selecting GitHub project boards for summaries.
+-- Rules are checked by position and the first one matching the project title wins,
+-- projects without matching rules are skipped
+CREATE TABLE IF NOT EXISTS github_project_rule (
+    position int PRIMARY KEY,
+    -- Glob pattern matched against lower cased project title e.g. "supply*"
+    pattern text NOT NULL,
+    -- Set to false to exclude matching projects
+    include boolean NOT NULL DEFAULT TRUE,
+    -- Section of the summary filled by matching projects
+    role text NOT NULL DEFAULT 'tasks' CHECK (role IN ('tasks', 'supply', 'inventory'))
+);
+
+-- Boards summarized before the rules were introduced
+INSERT INTO
+    github_project_rule (position, pattern, include, role)
+VALUES
+    (10, 'supply', TRUE, 'supply'),
+    (20, 'inventory', TRUE, 'inventory'),
+    (30, 'rockets', TRUE, 'tasks'),
+    (40, 'devops force', TRUE, 'tasks');
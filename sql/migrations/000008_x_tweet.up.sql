@@ -0,0 +1,28 @@
+-- Posts imported from X (Twitter) UserTweets timeline exports.
+-- Exports overlap, so tweets are upserted by their id
+CREATE TABLE IF NOT EXISTS x_tweet (
+    id bigint PRIMARY KEY,
+    author_id bigint NOT NULL,
+    author_screen_name text NOT NULL,
+    author_name text NOT NULL,
+    -- Full text with expanded links
+    text text NOT NULL,
+    lang text NOT NULL,
+    conversation_id bigint NOT NULL,
+    -- Set for replies
+    in_reply_to_id bigint,
+    in_reply_to_screen_name text,
+    -- Set for quotes and retweets, referenced tweets are imported as well
+    quoted_id bigint,
+    retweeted_id bigint,
+    -- Attached photos and videos
+    media jsonb NOT NULL,
+    favorite_count int NOT NULL DEFAULT 0,
+    retweet_count int NOT NULL DEFAULT 0,
+    reply_count int NOT NULL DEFAULT 0,
+    quote_count int NOT NULL DEFAULT 0,
+    created_at timestamp WITH time zone NOT NULL,
+    imported_at timestamp WITH time zone NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS x_tweet_author_created_at_idx ON x_tweet (lower(author_screen_name), created_at);
@@ -4,3 +4,27 @@ SELECT
     name
 FROM
     github_repository;
+
+-- name: FindGitHubProjectRules :many
+SELECT
+    *
+FROM
+    github_project_rule
+ORDER BY
+    position;
+
+-- name: SaveGitHubProjectRule :exec
+INSERT INTO
+    github_project_rule (position, pattern, include, role)
+VALUES
+    ($1, $2, $3, $4)
+ON CONFLICT (position) DO UPDATE
+SET
+    pattern = excluded.pattern,
+    include = excluded.include,
+    role = excluded.role;
+
+-- name: DeleteGitHubProjectRule :exec
+DELETE FROM github_project_rule
+WHERE
+    position = $1;
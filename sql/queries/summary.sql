@@ -0,0 +1,52 @@
+-- name: FindSummaryCache :one
+SELECT
+    summary
+FROM
+    summary_cache
+WHERE
+    key = $1;
+
+-- name: SaveSummaryCache :exec
+INSERT INTO
+    summary_cache (key, summary)
+VALUES
+    ($1, $2) ON conflict (key) DO
+UPDATE
+SET
+    summary = excluded.summary;
+
+-- name: SaveSummaryReport :one
+INSERT INTO
+    summary_report (since, until, sources, items, report)
+VALUES
+    ($1, $2, $3, $4, $5)
+RETURNING
+    id;
+
+-- name: FindSummaryReports :many
+SELECT
+    id,
+    since,
+    until,
+    sources,
+    created_at
+FROM
+    summary_report
+ORDER BY
+    created_at DESC
+LIMIT
+    $1;
+
+-- name: FindSummaryReport :one
+SELECT
+    id,
+    since,
+    until,
+    sources,
+    items,
+    report,
+    created_at
+FROM
+    summary_report
+WHERE
+    id = $1;
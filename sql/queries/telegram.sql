@@ -23,15 +23,22 @@ WHERE
 
 -- name: FindTelegramMessages :many
 SELECT
+    m.id,
+    m.peer_id,
     m.message,
+    m.created_at,
     p.chat_name AS chat_name,
     t.title AS topic_title
 FROM
     telegram_message m
     INNER JOIN telegram_peer p ON m.peer_id = p.id
-    JOIN telegram_topic t ON m.topic_id = t.id
+    LEFT JOIN telegram_topic t ON m.topic_id = t.id
+    AND m.peer_id = t.peer_id
 WHERE
-    m.created_at >= $1;
+    m.created_at >= sqlc.arg(since)
+    AND m.created_at < sqlc.arg(until)
+ORDER BY
+    m.created_at;
 
 -- name: SaveTelegramTopic :exec
 INSERT INTO
@@ -57,3 +64,22 @@ FROM
 WHERE
     peer_id = $1
     AND id = $2;
+
+-- name: CountTelegramMessages :many
+SELECT
+    p.chat_name,
+    date_trunc(sqlc.arg(bucket)::text, m.created_at, sqlc.arg(timezone)::text)::timestamptz AS period,
+    count(*) AS messages
+FROM
+    telegram_message m
+    INNER JOIN telegram_peer p ON m.peer_id = p.id
+WHERE
+    p.enabled
+    AND m.created_at >= sqlc.arg(since)
+    AND m.created_at < sqlc.arg(until)
+GROUP BY
+    p.chat_name,
+    period
+ORDER BY
+    period,
+    p.chat_name;
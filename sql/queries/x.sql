@@ -0,0 +1,97 @@
+-- name: SaveXTweet :exec
+INSERT INTO
+    x_tweet (
+        id,
+        author_id,
+        author_screen_name,
+        author_name,
+        text,
+        lang,
+        conversation_id,
+        in_reply_to_id,
+        in_reply_to_screen_name,
+        quoted_id,
+        retweeted_id,
+        media,
+        favorite_count,
+        retweet_count,
+        reply_count,
+        quote_count,
+        created_at
+    )
+VALUES
+    (
+        $1,
+        $2,
+        $3,
+        $4,
+        $5,
+        $6,
+        $7,
+        $8,
+        $9,
+        $10,
+        $11,
+        $12,
+        $13,
+        $14,
+        $15,
+        $16,
+        $17
+    ) ON conflict (id) DO
+UPDATE
+SET
+    author_screen_name = excluded.author_screen_name,
+    author_name = excluded.author_name,
+    text = excluded.text,
+    media = excluded.media,
+    -- Older exports could be imported after the newer ones
+    favorite_count = greatest(x_tweet.favorite_count, excluded.favorite_count),
+    retweet_count = greatest(x_tweet.retweet_count, excluded.retweet_count),
+    reply_count = greatest(x_tweet.reply_count, excluded.reply_count),
+    quote_count = greatest(x_tweet.quote_count, excluded.quote_count);
+
+-- name: FindXTweets :many
+SELECT
+    id,
+    author_id,
+    author_screen_name,
+    author_name,
+    text,
+    lang,
+    conversation_id,
+    in_reply_to_id,
+    in_reply_to_screen_name,
+    quoted_id,
+    retweeted_id,
+    media,
+    favorite_count,
+    retweet_count,
+    reply_count,
+    quote_count,
+    created_at,
+    imported_at
+FROM
+    x_tweet
+WHERE
+    (
+        sqlc.narg(author)::text IS NULL
+        OR lower(author_screen_name) = lower(sqlc.narg(author))
+    )
+    AND created_at >= sqlc.arg(since)
+    AND created_at < sqlc.arg(until)
+ORDER BY
+    created_at;
+
+-- name: FindXAuthors :many
+SELECT
+    author_screen_name,
+    -- Display name could change between exports
+    max(author_name)::text AS author_name,
+    count(*) AS tweets
+FROM
+    x_tweet
+GROUP BY
+    author_screen_name
+ORDER BY
+    tweets DESC;